@@ -2,11 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
+	"sync"
+	"time"
 
 	"github.com/rhythin/sever-management/internal"
 	"github.com/rhythin/sever-management/internal/api"
@@ -26,25 +26,98 @@ func main() {
 			persistence.NewServerRepo,
 			persistence.NewIPRepo,
 			persistence.NewEventRepo,
+			persistence.NewOperationRepo,
+			persistence.NewUnitOfWork,
+			persistence.NewRegionRepo,
+			persistence.NewWebhookRepo,
+			persistence.NewOutboxRepo,
+			persistence.NewUsageRepo,
+			persistence.NewPriceRepo,
+			persistence.NewInvoiceRepo,
+			persistence.NewLeaseRepo,
+			persistence.NewBudgetRepo,
+			persistence.NewReaperPolicyRepo,
+			persistence.NewJobRunRepo,
+			persistence.NewIPPoolRepo,
+			service.NewOperationQueue,
+			service.NewEventBus,
+			service.NewCatalogService,
+			service.NewPriceService,
 			service.NewServerService,
+			service.NewOperationWorker,
 			service.NewBillingDaemon,
+			service.NewInvoiceService,
+			service.NewBillingService,
+			service.NewBudgetService,
+			service.NewInvoiceDaemon,
+			service.NewReaperService,
 			service.NewIdleReaper,
+			service.NewIPPoolService,
+			service.NewWebhookService,
+			service.NewWebhookDispatcher,
+			newOutboxRelay,
+			newJobRunner,
+			func(jobs *service.JobRunner) service.JobService { return jobs },
 			logging.InitLogger,
-			func(svc service.ServerService) handlers.ServerHandler {
-				return handlers.NewServerHandler(svc)
+			func(svc service.ServerService, reaper service.ReaperService) handlers.ServerHandler {
+				return handlers.NewServerHandler(svc, reaper)
 			},
+			handlers.NewCatalogHandler,
+			handlers.NewStreamHandler,
+			handlers.NewWebhookHandler,
+			handlers.NewPriceHandler,
+			handlers.NewInvoiceHandler,
+			handlers.NewBillingHandler,
+			handlers.NewBudgetHandler,
+			handlers.NewReaperHandler,
+			handlers.NewJobHandler,
+			handlers.NewIPPoolHandler,
 			api.NewRouter,
 		),
 		fx.Invoke(runServer),
+		// Room for in-flight jobs to finish and for their stop hooks on shutdown
+		fx.StopTimeout(time.Minute),
 	).Run()
 }
 
+// newOutboxRelay publishes committed events to this replica's stream subscribers and,
+// from the replica holding the outbox lease, to webhooks and, when OUTBOX_NDJSON_PATH is
+// set, an NDJSON file
+func newOutboxRelay(lc fx.Lifecycle, cfg *internal.Config, repo persistence.OutboxRepo, leases persistence.LeaseRepo, bus *service.EventBus, webhooks *service.WebhookDispatcher) (*service.OutboxRelay, error) {
+	sinks := []service.EventSink{webhooks}
+	if cfg.OutboxNDJSONPath != "" {
+		file, err := service.NewNDJSONSink(cfg.OutboxNDJSONPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open NDJSON event sink: %w", err)
+		}
+		lc.Append(fx.Hook{OnStop: func(ctx context.Context) error { return file.Close() }})
+		sinks = append(sinks, file)
+	}
+	return service.NewOutboxRelay(repo, leases, bus, cfg, sinks...), nil
+}
+
+// newJobRunner schedules the periodic background jobs; the idle reaper only when
+// ENABLE_IDLE_REAPER is set
+func newJobRunner(cfg *internal.Config, repo persistence.JobRunRepo, billing *service.BillingDaemon, invoices *service.InvoiceDaemon, reaper *service.IdleReaper) *service.JobRunner {
+	jobs := []service.Job{billing.Job(), invoices.Job()}
+	if cfg.EnableIdleReaper {
+		jobs = append(jobs, reaper.Job())
+	}
+	return service.NewJobRunner(repo, cfg, jobs...)
+}
+
+// runServer starts the HTTP server and the background workers. Stopping the app, which fx
+// does on SIGINT or SIGTERM, shuts the server down, cancels the workers and waits for them
+// to return, so jobs run their stop hooks and the billing and outbox leases are released.
 func runServer(
 	lc fx.Lifecycle,
+	shutdowner fx.Shutdowner,
 	cfg *internal.Config,
 	r http.Handler,
-	billing *service.BillingDaemon,
-	reaper *service.IdleReaper,
+	jobs *service.JobRunner,
+	operations *service.OperationWorker,
+	webhooks *service.WebhookDispatcher,
+	relay *service.OutboxRelay,
 	logger *zap.Logger,
 ) {
 	server := &http.Server{
@@ -52,31 +125,45 @@ func runServer(
 		Handler: r,
 	}
 
+	var cancel context.CancelFunc
+	var workers sync.WaitGroup
 	lc.Append(fx.Hook{
-		OnStart: func(ctx context.Context) error {
+		OnStart: func(context.Context) error {
 			zap.S().Infof("Starting server on :%d", cfg.HTTPPort)
-			go billing.Run(context.Background())
-			go reaper.Run(context.Background())
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			for _, run := range []func(context.Context){jobs.Run, operations.Run, webhooks.Run, relay.Run} {
+				workers.Add(1)
+				go func() {
+					defer workers.Done()
+					run(ctx)
+				}()
+			}
 			go func() {
 				if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-					zap.S().Errorw("HTTP server error: %v", err)
+					zap.S().Errorw("HTTP server error", "error", err)
+					shutdowner.Shutdown(fx.ExitCode(1))
 				}
 			}()
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
 			zap.S().Infow("Shutting down HTTP server...")
-			return server.Shutdown(ctx)
+			err := server.Shutdown(ctx)
+			cancel()
+			stopped := make(chan struct{})
+			go func() {
+				workers.Wait()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+				zap.S().Infow("Background workers stopped")
+			case <-ctx.Done():
+				zap.S().Warnw("Timed out waiting for background workers to stop")
+				return errors.Join(err, ctx.Err())
+			}
+			return err
 		},
 	})
-
-	// Graceful shutdown on SIGINT/SIGTERM
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		<-c
-		zap.S().Warnw("Received shutdown signal, shutting down...")
-		server.Shutdown(context.Background())
-		os.Exit(0)
-	}()
 }
@@ -0,0 +1,18 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/handlers"
+)
+
+// NewBillingRouter sets up chi routes for billing reports
+func NewBillingRouter(h handlers.BillingHandler) http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/summary", h.GetSummary)
+	r.Get("/forecast", h.GetForecast)
+
+	return r
+}
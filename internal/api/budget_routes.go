@@ -0,0 +1,20 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/handlers"
+)
+
+// NewBudgetRouter sets up chi routes for budgets
+func NewBudgetRouter(h handlers.BudgetHandler) http.Handler {
+	r := chi.NewRouter()
+
+	r.Post("/", h.CreateBudget)
+	r.Get("/", h.ListBudgets)
+	r.Get("/{id}", h.GetBudget)
+	r.Delete("/{id}", h.DeleteBudget)
+
+	return r
+}
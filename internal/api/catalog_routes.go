@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/handlers"
+)
+
+// NewTypeRouter sets up chi routes for the server type catalog
+func NewTypeRouter(h handlers.CatalogHandler) http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/", h.ListTypes)
+
+	return r
+}
+
+// NewRegionRouter sets up chi routes for the region registry
+func NewRegionRouter(h handlers.CatalogHandler) http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/", h.ListRegions)
+	r.Put("/{name}", h.UpdateRegion)
+
+	return r
+}
@@ -0,0 +1,18 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/handlers"
+)
+
+// NewInvoiceRouter sets up chi routes for invoices
+func NewInvoiceRouter(h handlers.InvoiceHandler) http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/", h.ListInvoices)
+	r.Get("/{id}", h.GetInvoice)
+
+	return r
+}
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/handlers"
+)
+
+// NewIPPoolRouter sets up chi routes for IP pools
+func NewIPPoolRouter(h handlers.IPPoolHandler) http.Handler {
+	r := chi.NewRouter()
+
+	r.Post("/", h.CreatePool)
+	r.Get("/", h.ListPools)
+	r.Get("/{id}", h.GetPool)
+	r.Delete("/{id}", h.DeletePool)
+	r.Post("/{id}/cidrs", h.AddCIDR)
+	r.Delete("/{id}/cidrs/{cidrID}", h.RetireCIDR)
+
+	return r
+}
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/handlers"
+)
+
+// NewJobRouter sets up chi routes for background jobs
+func NewJobRouter(h handlers.JobHandler) http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/", h.ListJobs)
+	r.Get("/{name}/runs", h.ListRuns)
+	r.Post("/{name}/run", h.RunJob)
+
+	return r
+}
@@ -0,0 +1,18 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/handlers"
+)
+
+// NewPriceRouter sets up chi routes for the price book
+func NewPriceRouter(h handlers.PriceHandler) http.Handler {
+	r := chi.NewRouter()
+
+	r.Post("/", h.CreatePrice)
+	r.Get("/", h.ListPrices)
+
+	return r
+}
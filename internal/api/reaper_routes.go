@@ -0,0 +1,22 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/handlers"
+)
+
+// NewReaperRouter sets up chi routes for idle reaper policies
+func NewReaperRouter(h handlers.ReaperHandler) http.Handler {
+	r := chi.NewRouter()
+
+	r.Post("/policies", h.CreatePolicy)
+	r.Get("/policies", h.ListPolicies)
+	r.Get("/policies/{id}", h.GetPolicy)
+	r.Put("/policies/{id}", h.UpdatePolicy)
+	r.Delete("/policies/{id}", h.DeletePolicy)
+	r.Get("/preview", h.Preview)
+
+	return r
+}
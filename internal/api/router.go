@@ -9,7 +9,7 @@ import (
 	"github.com/rhythin/sever-management/internal/metrics"
 )
 
-func NewRouter(serverHandler handlers.ServerHandler) http.Handler {
+func NewRouter(serverHandler handlers.ServerHandler, catalogHandler handlers.CatalogHandler, streamHandler handlers.StreamHandler, webhookHandler handlers.WebhookHandler, priceHandler handlers.PriceHandler, invoiceHandler handlers.InvoiceHandler, billingHandler handlers.BillingHandler, budgetHandler handlers.BudgetHandler, reaperHandler handlers.ReaperHandler, jobHandler handlers.JobHandler, ipPoolHandler handlers.IPPoolHandler) http.Handler {
 	r := chi.NewRouter()
 
 	r.Use(logging.RequestIDMiddleware)
@@ -24,8 +24,37 @@ func NewRouter(serverHandler handlers.ServerHandler) http.Handler {
 	// Swagger UI
 	r.Get("/swagger/*", handlers.NewSwaggerHandler().ServeHTTP)
 
+	// State machine introspection
+	r.Get("/fsm", handlers.FSMHandler)
+
 	// Server API
 	r.Mount("/", NewServerRouter(serverHandler))
 
+	// Event streams (Server-Sent Events)
+	r.Get("/events/stream", streamHandler.StreamEvents)
+	r.Get("/servers/{id}/events/stream", streamHandler.StreamServerEvents)
+
+	// Catalog API
+	r.Mount("/types", NewTypeRouter(catalogHandler))
+	r.Mount("/regions", NewRegionRouter(catalogHandler))
+
+	// Webhook subscriptions
+	r.Mount("/webhooks", NewWebhookRouter(webhookHandler))
+
+	// Billing reports
+	r.Mount("/billing", NewBillingRouter(billingHandler))
+
+	// Budgets
+	r.Mount("/budgets", NewBudgetRouter(budgetHandler))
+
+	// Invoices
+	r.Mount("/invoices", NewInvoiceRouter(invoiceHandler))
+
+	// Administration
+	r.Mount("/admin/prices", NewPriceRouter(priceHandler))
+	r.Mount("/admin/reaper", NewReaperRouter(reaperHandler))
+	r.Mount("/admin/jobs", NewJobRouter(jobHandler))
+	r.Mount("/admin/ip-pools", NewIPPoolRouter(ipPoolHandler))
+
 	return r
 }
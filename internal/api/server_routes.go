@@ -17,8 +17,14 @@ func NewServerRouter(h handlers.ServerHandler) http.Handler {
 		r.Get("/", h.ListServers)
 		r.Get("/{id}", h.GetServer)
 		r.Post("/{id}/action", h.ServerAction)
+		r.Post("/{id}/reap-postpone", h.PostponeReap)
 		r.Get("/{id}/logs", h.GetServerLogs)
+		r.Get("/{id}/usage", h.GetServerUsage)
 	})
 
+	r.Get("/events", h.ListEvents)
+
+	r.Get("/operations/{id}", h.GetOperation)
+
 	return r
 }
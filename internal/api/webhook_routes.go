@@ -0,0 +1,21 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/handlers"
+)
+
+// NewWebhookRouter sets up chi routes for webhook subscriptions
+func NewWebhookRouter(h handlers.WebhookHandler) http.Handler {
+	r := chi.NewRouter()
+
+	r.Post("/", h.CreateWebhook)
+	r.Get("/", h.ListWebhooks)
+	r.Get("/{id}", h.GetWebhook)
+	r.Delete("/{id}", h.DeleteWebhook)
+	r.Get("/{id}/deliveries", h.ListWebhookDeliveries)
+
+	return r
+}
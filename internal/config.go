@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/kelseyhightower/envconfig"
+	"github.com/rhythin/sever-management/internal/domain"
 )
 
 // Config holds all environment configuration for the service
@@ -21,13 +22,62 @@ type Config struct {
 	DBName     string `envconfig:"DB_NAME" default:"servermgmt"`
 	DBSSLMode  string `envconfig:"DB_SSLMODE" default:"disable"`
 
-	BillingRate      float64       `envconfig:"BILLING_RATE" default:"0.01"` // $/hr
-	IdleTimeout      time.Duration `envconfig:"IDLE_TIMEOUT" default:"30m"`
-	BillingInterval  time.Duration `envconfig:"BILLING_INTERVAL" default:"1m"`
-	ReaperInterval   time.Duration `envconfig:"REAPER_INTERVAL" default:"5m"`
-	EnableIdleReaper bool          `envconfig:"ENABLE_IDLE_REAPER" default:"true"`
+	BillingRate        domain.Micros `envconfig:"BILLING_RATE" default:"0.01"` // per hour, for types missing from the catalog
+	IdleTimeout        time.Duration `envconfig:"IDLE_TIMEOUT" default:"30m"`
+	BillingInterval    time.Duration `envconfig:"BILLING_INTERVAL" default:"1m"`
+	BillingConcurrency int           `envconfig:"BILLING_CONCURRENCY" default:"8"` // sessions billed at once, each in its own transaction
+	ReaperInterval     time.Duration `envconfig:"REAPER_INTERVAL" default:"5m"`
+	ReaperConcurrency  int           `envconfig:"REAPER_CONCURRENCY" default:"4"` // terminations run at once by the idle reaper
+	EnableIdleReaper   bool          `envconfig:"ENABLE_IDLE_REAPER" default:"true"`
 
-	IPCIDR         string        `envconfig:"IP_CIDR" default:"192.168.0.0/16"`
+	ReaperDryRun      bool   `envconfig:"REAPER_DRY_RUN" default:"false"`                // report what every policy would reap without reaping
+	ReaperOptOutLabel string `envconfig:"REAPER_OPT_OUT_LABEL" default:"reaper-opt-out"` // servers with this label are never reaped
+
+	ReaperWarning     time.Duration `envconfig:"REAPER_WARNING" default:"10m"`       // notice given before reaping; 0 reaps without warning
+	ReaperMaxPostpone time.Duration `envconfig:"REAPER_MAX_POSTPONE" default:"168h"` // furthest from now a postponement may move reap_at
+
+	BillingCurrency     string              `envconfig:"BILLING_CURRENCY" default:"USD"`
+	BillingRounding     domain.RoundingMode `envconfig:"BILLING_ROUNDING" default:"half-even"`     // applied to the cost of each billing increment
+	BillingRoundingUnit domain.Micros       `envconfig:"BILLING_ROUNDING_UNIT" default:"0.000001"` // e.g. 0.01 to bill whole cents
+
+	InvoiceInterval   time.Duration `envconfig:"INVOICE_INTERVAL" default:"15m"`   // how often the current period's draft invoice is regenerated
+	InvoiceCloseDelay time.Duration `envconfig:"INVOICE_CLOSE_DELAY" default:"1h"` // after a period ends, before its invoice is frozen
+
+	JobTimeout  time.Duration            `envconfig:"JOB_TIMEOUT" default:"5m"`  // bounds each background job run
+	JobTimeouts map[string]time.Duration `envconfig:"JOB_TIMEOUTS"`              // per-job override, e.g. "billing:10m,reaper:1m"
+	JobJitter   float64                  `envconfig:"JOB_JITTER" default:"0.1"`  // fraction of the interval added at random to each wait
+	JobBackoff  time.Duration            `envconfig:"JOB_BACKOFF" default:"10s"` // retry delay after a failed run, doubling up to the interval
+	JobHistory  int                      `envconfig:"JOB_HISTORY" default:"100"` // runs kept per job
+
+	Regions     map[string]int    `envconfig:"REGIONS" default:"us-east-1:100,us-west-1:100,eu-west-1:100"` // name:maxServers, seeded into the region registry
+	ServerTypes ServerTypeCatalog `envconfig:"SERVER_TYPES" default:"t2.micro:1:1024:8:0.0116:1s,t2.small:1:2048:20:0.023:2s,t2.medium:2:4096:40:0.0464:3s"`
+
+	ProvisionDelay     time.Duration `envconfig:"PROVISION_DELAY" default:"1s"` // boot time for types without one
+	OperationQueueSize int           `envconfig:"OPERATION_QUEUE_SIZE" default:"1024"`
+
+	RebootDuration  time.Duration            `envconfig:"REBOOT_DURATION" default:"5s"`
+	RebootDurations map[string]time.Duration `envconfig:"REBOOT_DURATIONS"` // per-type override, e.g. "t2.micro:3s,t2.small:8s"
+
+	EventStreamBuffer    int           `envconfig:"EVENT_STREAM_BUFFER" default:"256"` // events queued per SSE subscriber before it is disconnected
+	EventStreamKeepAlive time.Duration `envconfig:"EVENT_STREAM_KEEPALIVE" default:"15s"`
+
+	WebhookTimeout      time.Duration `envconfig:"WEBHOOK_TIMEOUT" default:"10s"`
+	WebhookMaxAttempts  int           `envconfig:"WEBHOOK_MAX_ATTEMPTS" default:"8"` // after which a delivery is dead-lettered
+	WebhookBackoff      time.Duration `envconfig:"WEBHOOK_BACKOFF" default:"5s"`     // delay before the first retry, doubling after each failure
+	WebhookMaxBackoff   time.Duration `envconfig:"WEBHOOK_MAX_BACKOFF" default:"1h"`
+	WebhookPollInterval time.Duration `envconfig:"WEBHOOK_POLL_INTERVAL" default:"1s"`
+	WebhookConcurrency  int           `envconfig:"WEBHOOK_CONCURRENCY" default:"8"` // webhooks sent to at once; each one's deliveries go in order
+
+	OutboxPollInterval time.Duration `envconfig:"OUTBOX_POLL_INTERVAL" default:"1s"` // relay sweep; commits also wake the relay
+	OutboxBatchSize    int           `envconfig:"OUTBOX_BATCH_SIZE" default:"100"`
+	OutboxRetention    time.Duration `envconfig:"OUTBOX_RETENTION" default:"24h"` // dispatched entries are purged after this
+	OutboxNDJSONPath   string        `envconfig:"OUTBOX_NDJSON_PATH"`             // optional file sink, one JSON event per line
+
+	IPPools map[string]string `envconfig:"IP_POOLS" default:"us-east-1:192.168.0.0/22,us-west-1:192.168.4.0/22,eu-west-1:192.168.8.0/22"` // region:cidr, seeded as a pool for regions without one
+	// IPCIDR is the fleet-wide CIDR that IP_POOLS replaced; it is ignored with a warning
+	IPCIDR string `envconfig:"IP_CIDR"`
+
+	IPQuarantine   time.Duration `envconfig:"IP_QUARANTINE" default:"0s"` // how long a terminated server's IP stays unallocatable
 	LogLevel       string        `envconfig:"LOG_LEVEL" default:"info"`
 	MetricsPort    int           `envconfig:"METRICS_PORT" default:"9090"`
 	RequestTimeout time.Duration `envconfig:"REQUEST_TIMEOUT" default:"30s"`
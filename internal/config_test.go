@@ -4,6 +4,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/rhythin/sever-management/internal/domain"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -15,23 +17,57 @@ func TestLoadConfig(t *testing.T) {
 		{
 			name: "valid config",
 			want: &Config{
-				Env:              "development",
-				HTTPPort:         8080,
-				DBHost:           "localhost",
-				DBPort:           5432,
-				DBUser:           "postgres",
-				DBPassword:       "password",
-				DBName:           "servermgmt",
-				DBSSLMode:        "disable",
-				BillingRate:      0.01,
-				IdleTimeout:      30 * time.Minute,
-				BillingInterval:  time.Minute,
-				ReaperInterval:   5 * time.Minute,
-				EnableIdleReaper: true,
-				IPCIDR:           "192.168.0.0/16",
-				LogLevel:         "info",
-				MetricsPort:      9090,
-				RequestTimeout:   30 * time.Second,
+				Env:                 "development",
+				HTTPPort:            8080,
+				DBHost:              "localhost",
+				DBPort:              5432,
+				DBUser:              "postgres",
+				DBPassword:          "password",
+				DBName:              "servermgmt",
+				DBSSLMode:           "disable",
+				BillingRate:         10000,
+				IdleTimeout:         30 * time.Minute,
+				BillingInterval:     time.Minute,
+				BillingConcurrency:  8,
+				ReaperInterval:      5 * time.Minute,
+				ReaperConcurrency:   4,
+				InvoiceInterval:     15 * time.Minute,
+				InvoiceCloseDelay:   time.Hour,
+				JobTimeout:          5 * time.Minute,
+				JobJitter:           0.1,
+				JobBackoff:          10 * time.Second,
+				JobHistory:          100,
+				EnableIdleReaper:    true,
+				ReaperOptOutLabel:   "reaper-opt-out",
+				ReaperWarning:       10 * time.Minute,
+				ReaperMaxPostpone:   168 * time.Hour,
+				BillingCurrency:     "USD",
+				BillingRounding:     domain.RoundHalfEven,
+				BillingRoundingUnit: 1,
+				Regions:             map[string]int{"us-east-1": 100, "us-west-1": 100, "eu-west-1": 100},
+				ServerTypes: ServerTypeCatalog{
+					{Name: "t2.micro", VCPU: 1, MemoryMiB: 1024, DiskGiB: 8, HourlyPrice: 11600, BootTime: time.Second},
+					{Name: "t2.small", VCPU: 1, MemoryMiB: 2048, DiskGiB: 20, HourlyPrice: 23000, BootTime: 2 * time.Second},
+					{Name: "t2.medium", VCPU: 2, MemoryMiB: 4096, DiskGiB: 40, HourlyPrice: 46400, BootTime: 3 * time.Second},
+				},
+				ProvisionDelay:       time.Second,
+				OperationQueueSize:   1024,
+				RebootDuration:       5 * time.Second,
+				EventStreamBuffer:    256,
+				EventStreamKeepAlive: 15 * time.Second,
+				WebhookTimeout:       10 * time.Second,
+				WebhookMaxAttempts:   8,
+				WebhookBackoff:       5 * time.Second,
+				WebhookMaxBackoff:    time.Hour,
+				WebhookPollInterval:  time.Second,
+				WebhookConcurrency:   8,
+				OutboxPollInterval:   time.Second,
+				OutboxBatchSize:      100,
+				OutboxRetention:      24 * time.Hour,
+				IPPools:              map[string]string{"us-east-1": "192.168.0.0/22", "us-west-1": "192.168.4.0/22", "eu-west-1": "192.168.8.0/22"},
+				LogLevel:             "info",
+				MetricsPort:          9090,
+				RequestTimeout:       30 * time.Second,
 			},
 			wantErr: false,
 		},
@@ -0,0 +1,21 @@
+package domain
+
+// BudgetAction is what happens when a budget's spend reaches one of its thresholds
+
+type BudgetAction string
+
+const (
+	BudgetActionEvent   BudgetAction = "event"   // record and publish a budget_threshold event
+	BudgetActionWebhook BudgetAction = "webhook" // deliver a budget_threshold event to one webhook
+	BudgetActionStop    BudgetAction = "stop"    // stop the budget's running servers
+)
+
+// IsValidBudgetAction checks if the provided action is a known budget action
+func IsValidBudgetAction(action BudgetAction) bool {
+	switch action {
+	case BudgetActionEvent, BudgetActionWebhook, BudgetActionStop:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,140 @@
+package domain
+
+import "sort"
+
+// Stamp names the lifecycle timestamp a transition records
+
+type Stamp string
+
+const (
+	StampNone       Stamp = ""
+	StampStarted    Stamp = "started_at"
+	StampStopped    Stamp = "stopped_at"
+	StampTerminated Stamp = "terminated_at"
+)
+
+// TransitionRule is a single row of the server FSM
+
+type TransitionRule struct {
+	From     ServerState
+	Action   ServerAction
+	To       ServerState
+	Events   []EventType
+	Stamp    Stamp
+	Internal bool // issued by workers and daemons only; rejected when sent by clients
+}
+
+// InitialState is the state every server is created in
+const InitialState = ServerProvisioning
+
+// Transitions is the authoritative transition table. The service, handlers and
+// daemons consult it instead of hard-coding allowed transitions; add rows here
+// when introducing new states or actions.
+var Transitions = []TransitionRule{
+	{From: ServerProvisioning, Action: ActionCompleteProvision, To: ServerRunning, Events: []EventType{EventStarted}, Stamp: StampStarted, Internal: true},
+	{From: ServerProvisioning, Action: ActionTerminate, To: ServerTerminated, Events: []EventType{EventTerminated}, Stamp: StampTerminated},
+	{From: ServerStopped, Action: ActionStart, To: ServerRunning, Events: []EventType{EventStarted}, Stamp: StampStarted},
+	{From: ServerStopped, Action: ActionTerminate, To: ServerTerminated, Events: []EventType{EventTerminated}, Stamp: StampTerminated},
+	{From: ServerRunning, Action: ActionStop, To: ServerStopped, Events: []EventType{EventStopped}, Stamp: StampStopped},
+	{From: ServerRunning, Action: ActionReboot, To: ServerRebooting, Events: []EventType{EventRebooting}},
+	{From: ServerRunning, Action: ActionTerminate, To: ServerTerminated, Events: []EventType{EventTerminated}, Stamp: StampTerminated},
+	{From: ServerRebooting, Action: ActionCompleteReboot, To: ServerRunning, Events: []EventType{EventRebooted}, Internal: true},
+	{From: ServerRebooting, Action: ActionTerminate, To: ServerTerminated, Events: []EventType{EventTerminated}, Stamp: StampTerminated},
+}
+
+// eventMessages holds the human-readable message recorded for each event type
+var eventMessages = map[EventType]string{
+	EventProvisioned: "Server provisioned",
+	EventStarted:     "Server started",
+	EventStopped:     "Server stopped",
+	EventRebooting:   "Server rebooting",
+	EventRebooted:    "Server reboot completed",
+	EventTerminated:  "Server terminated",
+	EventBilled:      "Server billed",
+	EventReaped:      "Server reaped after idle timeout",
+
+	EventReapWarning:   "Server will be reaped soon",
+	EventReapPostponed: "Server reaping postponed",
+
+	EventBudgetThreshold: "Budget threshold reached",
+}
+
+var transitionIndex = buildTransitionIndex(Transitions)
+
+func buildTransitionIndex(rules []TransitionRule) map[ServerState]map[ServerAction]TransitionRule {
+	idx := make(map[ServerState]map[ServerAction]TransitionRule)
+	for _, r := range rules {
+		if idx[r.From] == nil {
+			idx[r.From] = make(map[ServerAction]TransitionRule)
+		}
+		if _, dup := idx[r.From][r.Action]; dup {
+			panic("duplicate FSM transition: " + string(r.From) + " --" + string(r.Action) + "->")
+		}
+		idx[r.From][r.Action] = r
+	}
+	return idx
+}
+
+// LookupTransition returns the rule for applying action in state from
+func LookupTransition(from ServerState, action ServerAction) (TransitionRule, bool) {
+	r, ok := transitionIndex[from][action]
+	return r, ok
+}
+
+// AllowedActions lists the client actions accepted in the given state
+func AllowedActions(from ServerState) []ServerAction {
+	var actions []ServerAction
+	for _, r := range Transitions {
+		if r.From == from && !r.Internal {
+			actions = append(actions, r.Action)
+		}
+	}
+	return actions
+}
+
+// ClientActions lists every action clients may request, in table order
+func ClientActions() []ServerAction {
+	seen := make(map[ServerAction]bool)
+	var actions []ServerAction
+	for _, r := range Transitions {
+		if !r.Internal && !seen[r.Action] {
+			seen[r.Action] = true
+			actions = append(actions, r.Action)
+		}
+	}
+	return actions
+}
+
+// States lists every state referenced by the transition table, sorted
+func States() []ServerState {
+	seen := map[ServerState]bool{InitialState: true}
+	for _, r := range Transitions {
+		seen[r.From] = true
+		seen[r.To] = true
+	}
+	states := make([]ServerState, 0, len(seen))
+	for st := range seen {
+		states = append(states, st)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i] < states[j] })
+	return states
+}
+
+// IsTerminal reports whether no transitions leave the given state
+func IsTerminal(state ServerState) bool {
+	return len(transitionIndex[state]) == 0
+}
+
+// EventMessage returns the default message for an event type
+func EventMessage(t EventType) string {
+	if m, ok := eventMessages[t]; ok {
+		return m
+	}
+	return "Server " + string(t)
+}
+
+// IsValidEventType reports whether t is a known event type
+func IsValidEventType(t EventType) bool {
+	_, ok := eventMessages[t]
+	return ok
+}
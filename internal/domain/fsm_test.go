@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransitions_TableIsConsistent(t *testing.T) {
+	known := map[ServerState]bool{}
+	for _, st := range States() {
+		known[st] = true
+	}
+	for _, r := range Transitions {
+		assert.True(t, known[r.From], "unknown from-state %s", r.From)
+		assert.True(t, known[r.To], "unknown to-state %s", r.To)
+		assert.NotEmpty(t, r.Events, "transition %s --%s-> emits no events", r.From, r.Action)
+		got, ok := LookupTransition(r.From, r.Action)
+		assert.True(t, ok)
+		assert.Equal(t, r.To, got.To)
+	}
+}
+
+func TestBuildTransitionIndex_PanicsOnDuplicate(t *testing.T) {
+	assert.Panics(t, func() {
+		buildTransitionIndex([]TransitionRule{
+			{From: ServerRunning, Action: ActionStop, To: ServerStopped},
+			{From: ServerRunning, Action: ActionStop, To: ServerTerminated},
+		})
+	})
+}
+
+func TestAllowedActions(t *testing.T) {
+	assert.ElementsMatch(t, []ServerAction{ActionStart, ActionTerminate}, AllowedActions(ServerStopped))
+	assert.ElementsMatch(t, []ServerAction{ActionTerminate}, AllowedActions(ServerProvisioning))
+	assert.Empty(t, AllowedActions(ServerTerminated))
+}
+
+func TestClientActions_ExcludeInternal(t *testing.T) {
+	actions := ClientActions()
+	assert.ElementsMatch(t, []ServerAction{ActionStart, ActionStop, ActionReboot, ActionTerminate}, actions)
+	assert.False(t, IsValidAction(ActionCompleteProvision))
+}
+
+func TestIsTerminal(t *testing.T) {
+	assert.True(t, IsTerminal(ServerTerminated))
+	assert.False(t, IsTerminal(ServerRunning))
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// InvoiceStatus represents the lifecycle of a period's invoice
+
+type InvoiceStatus string
+
+const (
+	InvoiceDraft  InvoiceStatus = "draft"  // the period is open; lines are regenerated as uptime is billed
+	InvoiceClosed InvoiceStatus = "closed" // frozen; later charges for the period are adjustments on a later invoice
+)
+
+// periodLayout formats billing periods, which are calendar months in UTC
+const periodLayout = "2006-01"
+
+// PeriodOf returns the billing period containing t, e.g. "2026-03"
+func PeriodOf(t time.Time) string {
+	return t.UTC().Format(periodLayout)
+}
+
+// PeriodBounds returns the start and exclusive end of a billing period
+func PeriodBounds(period string) (time.Time, time.Time, error) {
+	start, err := time.Parse(periodLayout, period)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid billing period %q: want YYYY-MM", period)
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
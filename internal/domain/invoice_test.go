@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriod(t *testing.T) {
+	// Periods are UTC months regardless of the caller's zone
+	at := time.Date(2026, 3, 1, 0, 30, 0, 0, time.FixedZone("UTC+1", 3600))
+	assert.Equal(t, "2026-02", PeriodOf(at))
+
+	start, end, err := PeriodBounds("2026-12")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), end)
+
+	_, _, err = PeriodBounds("March")
+	assert.Error(t, err)
+}
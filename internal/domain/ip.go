@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrInvalidCIDR is returned for IP pool CIDRs that break the rules below
+var ErrInvalidCIDR = errors.New("invalid CIDR")
+
+// MinPoolCIDRPrefix bounds pool CIDRs to a /16, since every usable address is stored as a row
+const MinPoolCIDRPrefix = 16
+
+// ParsePoolCIDR parses a CIDR for an IP pool: an IPv4 network address with a prefix of at
+// least /16, such as 10.1.0.0/24
+func ParsePoolCIDR(s string) (*net.IPNet, error) {
+	ip, ipnet, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidCIDR, s)
+	}
+	if ip.To4() == nil {
+		return nil, fmt.Errorf("%w: %q is not IPv4", ErrInvalidCIDR, s)
+	}
+	if !ip.Equal(ipnet.IP) {
+		return nil, fmt.Errorf("%w: %q has host bits set; use %s", ErrInvalidCIDR, s, ipnet)
+	}
+	if ones, _ := ipnet.Mask.Size(); ones < MinPoolCIDRPrefix {
+		return nil, fmt.Errorf("%w: %q is larger than /%d", ErrInvalidCIDR, s, MinPoolCIDRPrefix)
+	}
+	return ipnet, nil
+}
+
+// CIDRsOverlap reports whether two CIDRs share any address
+func CIDRsOverlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePoolCIDR(t *testing.T) {
+	tests := []struct {
+		name    string
+		cidr    string
+		wantErr bool
+	}{
+		{name: "valid", cidr: "10.1.0.0/24"},
+		{name: "smallest", cidr: "10.1.0.7/32"},
+		{name: "largest", cidr: "10.1.0.0/16"},
+		{name: "too large", cidr: "10.0.0.0/15", wantErr: true},
+		{name: "host bits set", cidr: "10.1.0.1/24", wantErr: true},
+		{name: "IPv6", cidr: "2001:db8::/120", wantErr: true},
+		{name: "malformed", cidr: "10.1.0.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePoolCIDR(tt.cidr)
+			if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrInvalidCIDR)) {
+				t.Errorf("ParsePoolCIDR() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCIDRsOverlap(t *testing.T) {
+	parse := func(s string) *net.IPNet {
+		ipnet, err := ParsePoolCIDR(s)
+		require.NoError(t, err)
+		return ipnet
+	}
+	assert.True(t, CIDRsOverlap(parse("10.1.0.0/16"), parse("10.1.2.0/24")))
+	assert.True(t, CIDRsOverlap(parse("10.1.2.0/24"), parse("10.1.0.0/16")))
+	assert.True(t, CIDRsOverlap(parse("10.1.2.0/24"), parse("10.1.2.0/24")))
+	assert.False(t, CIDRsOverlap(parse("10.1.2.0/24"), parse("10.1.3.0/24")))
+}
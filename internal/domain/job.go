@@ -0,0 +1,21 @@
+package domain
+
+// JobTrigger records what started a background job run
+
+type JobTrigger string
+
+const (
+	JobTriggerSchedule JobTrigger = "schedule" // the job's interval elapsed
+	JobTriggerManual   JobTrigger = "manual"   // an admin asked for a run
+)
+
+// JobRunStatus represents the outcome of one background job run
+
+type JobRunStatus string
+
+const (
+	JobRunRunning   JobRunStatus = "running"
+	JobRunSucceeded JobRunStatus = "succeeded"
+	JobRunFailed    JobRunStatus = "failed"
+	JobRunSkipped   JobRunStatus = "skipped" // nothing to do on this replica, e.g. without the billing lease
+)
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// ErrInvalidLabel is returned for label keys or values that break the rules below
+var ErrInvalidLabel = errors.New("invalid label")
+
+// Labels are key/value pairs attached to servers, such as team=payments, that costs can be
+// grouped by. Keys are 1-63 characters of lowercase letters, digits, '-', '_', '.' and '/',
+// starting with a letter or digit; values are at most 63 characters.
+const (
+	MaxLabels         = 32
+	maxLabelKeyLength = 63
+	maxLabelValueLen  = 63
+)
+
+// IsValidLabelKey checks a label key against the rules above
+func IsValidLabelKey(key string) bool {
+	if key == "" || len(key) > maxLabelKeyLength {
+		return false
+	}
+	for i, c := range key {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case i > 0 && (c == '-' || c == '_' || c == '.' || c == '/'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateLabels checks a server's labels
+func ValidateLabels(labels map[string]string) error {
+	if len(labels) > MaxLabels {
+		return fmt.Errorf("%w: at most %d labels", ErrInvalidLabel, MaxLabels)
+	}
+	for k, v := range labels {
+		if !IsValidLabelKey(k) {
+			return fmt.Errorf("%w: key %q", ErrInvalidLabel, k)
+		}
+		if !utf8.ValidString(v) || utf8.RuneCountInString(v) > maxLabelValueLen {
+			return fmt.Errorf("%w: value of %q", ErrInvalidLabel, k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wantErr bool
+	}{
+		{name: "none", labels: nil},
+		{name: "valid", labels: map[string]string{"team": "payments", "cost-center": "CC 1042", "k8s.io/app": ""}},
+		{name: "uppercase key", labels: map[string]string{"Team": "payments"}, wantErr: true},
+		{name: "empty key", labels: map[string]string{"": "payments"}, wantErr: true},
+		{name: "leading dash", labels: map[string]string{"-team": "payments"}, wantErr: true},
+		{name: "long key", labels: map[string]string{strings.Repeat("k", 64): "v"}, wantErr: true},
+		{name: "long value", labels: map[string]string{"team": strings.Repeat("v", 64)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateLabels(tt.labels)
+			if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrInvalidLabel)) {
+				t.Errorf("ValidateLabels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
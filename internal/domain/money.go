@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Micros is an amount of money in millionths of a currency unit. Billing arithmetic is done
+// in integer micro-units so that totals are exact; amounts cross the API as decimal strings.
+
+type Micros int64
+
+const microsPerUnit = 1_000_000
+
+// ParseMicros parses a decimal amount such as "0.0116" exactly. Amounts with more than six
+// fractional digits are rejected rather than rounded.
+func ParseMicros(s string) (Micros, error) {
+	whole, frac, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	if whole == "" && frac == "" || len(frac) > 6 || !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	n, err := strconv.ParseInt(whole+frac+strings.Repeat("0", 6-len(frac)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", s, err)
+	}
+	if strings.HasPrefix(s, "-") {
+		n = -n
+	}
+	return Micros(n), nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats m as a decimal with six fractional digits, e.g. "0.011600"
+func (m Micros) String() string {
+	sign, v := "", int64(m)
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	return fmt.Sprintf("%s%d.%06d", sign, v/microsPerUnit, v%microsPerUnit)
+}
+
+// Decode implements envconfig.Decoder
+func (m *Micros) Decode(value string) error {
+	v, err := ParseMicros(value)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// RoundingMode selects how amounts that fall between two multiples of the rounding unit are rounded
+
+type RoundingMode string
+
+const (
+	RoundHalfEven RoundingMode = "half-even" // to the nearest unit, ties to even (banker's rounding)
+	RoundHalfUp   RoundingMode = "half-up"   // to the nearest unit, ties away from zero
+	RoundUp       RoundingMode = "up"        // away from zero
+	RoundDown     RoundingMode = "down"      // toward zero
+)
+
+// IsValidRoundingMode checks if the provided mode is a known rounding mode
+func IsValidRoundingMode(mode RoundingMode) bool {
+	switch mode {
+	case RoundHalfEven, RoundHalfUp, RoundUp, RoundDown:
+		return true
+	default:
+		return false
+	}
+}
+
+// Decode implements envconfig.Decoder
+func (m *RoundingMode) Decode(value string) error {
+	if !IsValidRoundingMode(RoundingMode(value)) {
+		return fmt.Errorf("invalid rounding mode %q: want half-even, half-up, up or down", value)
+	}
+	*m = RoundingMode(value)
+	return nil
+}
+
+// Rounding rounds amounts to a multiple of Unit. The zero value rounds half-even to the micro-unit.
+
+type Rounding struct {
+	Mode RoundingMode
+	Unit Micros
+}
+
+// Charge is a span of uptime billed at one hourly rate
+
+type Charge struct {
+	Rate     Micros // per hour
+	Duration time.Duration
+}
+
+// HourlyCost returns the cost of seconds at an hourly rate, computed exactly and rounded once
+func (r Rounding) HourlyCost(rate Micros, seconds int64) Micros {
+	return r.Cost(Charge{Rate: rate, Duration: time.Duration(seconds) * time.Second})
+}
+
+// Cost returns the exact total of charges, rounded once
+func (r Rounding) Cost(charges ...Charge) Micros {
+	unit := r.Unit
+	if unit <= 0 {
+		unit = 1
+	}
+	num := new(big.Int)
+	for _, c := range charges {
+		num.Add(num, new(big.Int).Mul(big.NewInt(int64(c.Rate)), big.NewInt(int64(c.Duration))))
+	}
+	den := new(big.Int).Mul(big.NewInt(int64(time.Hour)), big.NewInt(int64(unit)))
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() != 0 && r.awayFromZero(q, rem, den) {
+		q.Add(q, big.NewInt(int64(num.Sign())))
+	}
+	return Micros(q.Int64()) * unit
+}
+
+// awayFromZero reports whether a quotient q truncated toward zero, with remainder rem of
+// divisor den, rounds away from zero
+func (r Rounding) awayFromZero(q, rem, den *big.Int) bool {
+	switch r.Mode {
+	case RoundUp:
+		return true
+	case RoundDown:
+		return false
+	}
+	twice := new(big.Int).Abs(rem)
+	twice.Lsh(twice, 1)
+	switch twice.Cmp(den) {
+	case 1:
+		return true
+	case 0:
+		return r.Mode == RoundHalfUp || q.Bit(0) == 1
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMicros(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Micros
+		wantErr bool
+	}{
+		{in: "0.0116", want: 11600},
+		{in: "12", want: 12_000_000},
+		{in: "-1.5", want: -1_500_000},
+		{in: ".25", want: 250_000},
+		{in: "0.000001", want: 1},
+		{in: "0.0000001", wantErr: true},
+		{in: "1e-3", wantErr: true},
+		{in: "", wantErr: true},
+		{in: ".", wantErr: true},
+		{in: "99999999999999", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMicros(tt.in)
+		if tt.wantErr {
+			assert.Error(t, err, tt.in)
+			continue
+		}
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+}
+
+func TestMicros_String(t *testing.T) {
+	assert.Equal(t, "0.011600", Micros(11600).String())
+	assert.Equal(t, "12.000000", Micros(12_000_000).String())
+	assert.Equal(t, "-0.000001", Micros(-1).String())
+}
+
+func TestRounding_HourlyCost(t *testing.T) {
+	tests := []struct {
+		name     string
+		rounding Rounding
+		rate     Micros
+		seconds  int64
+		want     Micros
+	}{
+		{name: "exact", rate: 3600, seconds: 90, want: 90},
+		{name: "half-even rounds down below half", rate: 11600, seconds: 60, want: 193},
+		{name: "half-even tie to even", rate: 1, seconds: 1800, want: 0},
+		{name: "half-even tie to even above", rate: 1, seconds: 5400, want: 2},
+		{name: "half-up tie", rounding: Rounding{Mode: RoundHalfUp}, rate: 1, seconds: 1800, want: 1},
+		{name: "up to whole cents", rounding: Rounding{Mode: RoundUp, Unit: 10000}, rate: 11600, seconds: 60, want: 10000},
+		{name: "down to whole cents", rounding: Rounding{Mode: RoundDown, Unit: 10000}, rate: 11600, seconds: 3600 * 10, want: 110000},
+		{name: "half-even to whole cents", rounding: Rounding{Mode: RoundHalfEven, Unit: 10000}, rate: 11600, seconds: 3600 * 10, want: 120000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.rounding.HourlyCost(tt.rate, tt.seconds))
+		})
+	}
+}
+
+func TestRounding_Cost(t *testing.T) {
+	// Half an hour at 0.0116 and half an hour at 0.0232 is rounded once, not per charge
+	charges := []Charge{{Rate: 11601, Duration: 30 * time.Minute}, {Rate: 23201, Duration: 30 * time.Minute}}
+	assert.Equal(t, Micros(17401), Rounding{}.Cost(charges...))
+	assert.Equal(t, Micros(0), Rounding{}.Cost())
+}
+
+func TestRoundingMode_Decode(t *testing.T) {
+	var m RoundingMode
+	assert.NoError(t, m.Decode("half-up"))
+	assert.Equal(t, RoundHalfUp, m)
+	assert.Error(t, m.Decode("nearest"))
+}
@@ -0,0 +1,20 @@
+package domain
+
+// OperationType identifies the kind of asynchronous work an operation tracks
+
+type OperationType string
+
+const (
+	OperationProvision OperationType = "provision"
+	OperationReboot    OperationType = "reboot"
+)
+
+// OperationStatus represents the lifecycle of an asynchronous operation
+
+type OperationStatus string
+
+const (
+	OperationPending   OperationStatus = "pending"
+	OperationSucceeded OperationStatus = "succeeded"
+	OperationFailed    OperationStatus = "failed"
+)
@@ -0,0 +1,21 @@
+package domain
+
+// RegionStatus controls whether a region accepts new servers
+
+type RegionStatus string
+
+const (
+	RegionEnabled  RegionStatus = "enabled"  // accepts new servers
+	RegionDraining RegionStatus = "draining" // existing servers keep running; no new servers
+	RegionDisabled RegionStatus = "disabled" // out of service; no new servers and no starts
+)
+
+// IsValidRegionStatus checks if the provided status is a known region status
+func IsValidRegionStatus(status RegionStatus) bool {
+	switch status {
+	case RegionEnabled, RegionDraining, RegionDisabled:
+		return true
+	default:
+		return false
+	}
+}
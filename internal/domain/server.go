@@ -28,8 +28,9 @@ const (
 type ServerType string
 
 const (
-	TypeT2Micro ServerType = "t2.micro"
-	TypeT2Small ServerType = "t2.small"
+	TypeT2Micro  ServerType = "t2.micro"
+	TypeT2Small  ServerType = "t2.small"
+	TypeT2Medium ServerType = "t2.medium"
 )
 
 // Server represents a virtual server instance
@@ -46,8 +47,9 @@ type Server struct {
 	StoppedAt    *time.Time // for idle reaper
 	TerminatedAt *time.Time
 	Billing      BillingInfo
-	Log          *EventRingBuffer
-	mu           sync.Mutex // protects FSM transitions, timestamps, billing
+	Log          *EventRingBuffer // optional in-memory history
+	pending      []EventLogEntry  // raised by transitions, not yet taken for persistence
+	mu           sync.Mutex       // protects FSM transitions, timestamps, billing
 }
 
 // BillingInfo tracks cost and uptime
@@ -55,7 +57,8 @@ type Server struct {
 type BillingInfo struct {
 	AccumulatedSeconds int64 // total uptime in seconds
 	LastBilledAt       *time.Time
-	TotalCost          float64
+	TotalCost          Micros
+	Currency           string
 }
 
 // ServerAction represents allowed actions on a server
@@ -66,6 +69,22 @@ const (
 	ActionStop      ServerAction = "stop"
 	ActionReboot    ServerAction = "reboot"
 	ActionTerminate ServerAction = "terminate"
+
+	// Completion actions are issued by the operation worker, never by clients
+	ActionCompleteProvision ServerAction = "complete_provision"
+	ActionCompleteReboot    ServerAction = "complete_reboot"
+
+	// ActionProvision is recorded on the provisioned event; it is not an FSM transition
+	ActionProvision ServerAction = "provision"
+)
+
+// TerminationCause records who terminated a server
+
+type TerminationCause string
+
+const (
+	CauseUser   TerminationCause = "user"   // a client's terminate action
+	CauseReaper TerminationCause = "reaper" // the idle reaper
 )
 
 // EventType for server lifecycle events
@@ -76,10 +95,17 @@ const (
 	EventProvisioned EventType = "provisioned"
 	EventStarted     EventType = "started"
 	EventStopped     EventType = "stopped"
-	EventRebooted    EventType = "rebooted"
+	EventRebooting   EventType = "rebooting"
+	EventRebooted    EventType = "rebooted" // reboot completed
 	EventTerminated  EventType = "terminated"
 	EventBilled      EventType = "billed"
 	EventReaped      EventType = "reaped"
+
+	EventReapWarning   EventType = "reap_warning"   // the idle reaper will reap the server soon
+	EventReapPostponed EventType = "reap_postponed" // an owner moved the server's reap time back
+
+	// EventBudgetThreshold is raised for a budget rather than a server; its server ID is empty
+	EventBudgetThreshold EventType = "budget_threshold"
 )
 
 // EventLogEntry represents a single server event
@@ -88,6 +114,9 @@ type EventLogEntry struct {
 	Timestamp time.Time
 	Type      EventType
 	Message   string
+	From      ServerState
+	To        ServerState
+	Action    ServerAction
 }
 
 // EventRingBuffer holds the last N events (thread-safe)
@@ -125,7 +154,7 @@ func (r *EventRingBuffer) List() []EventLogEntry {
 	return res
 }
 
-// FSM transition logic (thread-safe)
+// FSM transition logic (thread-safe), driven by the Transitions table
 
 var ErrInvalidTransition = errors.New("invalid state transition")
 
@@ -135,52 +164,46 @@ func (s *Server) Transition(ctx context.Context, action ServerAction) error {
 	log := logging.S(ctx)
 	now := time.Now()
 	log.Infow("FSM transition attempt", "server_id", s.ID, "from", s.State, "action", string(action))
-	switch action {
-	case ActionStart:
-		if s.State == ServerStopped {
-			s.State = ServerRunning
-			s.StartedAt = &now
-			s.Log.Add(EventLogEntry{Timestamp: now, Type: EventStarted, Message: "Server started"})
-			log.Infow("FSM transition success", "server_id", s.ID, "to", s.State)
-			return nil
-		}
-	case ActionStop:
-		if s.State == ServerRunning {
-			s.State = ServerStopped
-			s.StoppedAt = &now
-			s.Log.Add(EventLogEntry{Timestamp: now, Type: EventStopped, Message: "Server stopped"})
-			log.Infow("FSM transition success", "server_id", s.ID, "to", s.State)
-			return nil
-		}
-	case ActionReboot:
-		if s.State == ServerRunning {
-			s.State = ServerRebooting
-			s.Log.Add(EventLogEntry{Timestamp: now, Type: EventRebooted, Message: "Server rebooting"})
-			// Simulate reboot: immediately back to running
-			s.State = ServerRunning
-			s.Log.Add(EventLogEntry{Timestamp: now, Type: EventStarted, Message: "Server rebooted and running"})
-			log.Infow("FSM transition success", "server_id", s.ID, "to", s.State)
-			return nil
-		}
-	case ActionTerminate:
-		if s.State != ServerTerminated {
-			s.State = ServerTerminated
-			s.TerminatedAt = &now
-			s.Log.Add(EventLogEntry{Timestamp: now, Type: EventTerminated, Message: "Server terminated"})
-			log.Infow("FSM transition success", "server_id", s.ID, "to", s.State)
-			return nil
+	rule, ok := LookupTransition(s.State, action)
+	if !ok {
+		log.Warnw("FSM invalid transition", "server_id", s.ID, "from", s.State, "action", string(action))
+		return ErrInvalidTransition
+	}
+	s.State = rule.To
+	switch rule.Stamp {
+	case StampStarted:
+		s.StartedAt = &now
+	case StampStopped:
+		s.StoppedAt = &now
+	case StampTerminated:
+		s.TerminatedAt = &now
+	}
+	for _, e := range rule.Events {
+		entry := EventLogEntry{Timestamp: now, Type: e, Message: EventMessage(e), From: rule.From, To: rule.To, Action: action}
+		s.pending = append(s.pending, entry)
+		if s.Log != nil {
+			s.Log.Add(entry)
 		}
 	}
-	log.Warnw("FSM invalid transition", "server_id", s.ID, "from", s.State, "action", string(action))
-	return ErrInvalidTransition
+	log.Infow("FSM transition success", "server_id", s.ID, "to", s.State)
+	return nil
 }
 
-// IsValidAction checks if the provided action is a valid server action
+// TakeEvents returns the events raised by transitions since the last call, in order
+func (s *Server) TakeEvents() []EventLogEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.pending
+	s.pending = nil
+	return events
+}
+
+// IsValidAction checks if the provided action may be requested by clients
 func IsValidAction(action ServerAction) bool {
-	switch action {
-	case ActionStart, ActionStop, ActionReboot, ActionTerminate:
-		return true
-	default:
-		return false
+	for _, a := range ClientActions() {
+		if a == action {
+			return true
+		}
 	}
+	return false
 }
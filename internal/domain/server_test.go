@@ -34,7 +34,8 @@ func TestServer_Transition_ValidTransitions(t *testing.T) {
 	}{
 		{ServerStopped, ActionStart, ServerRunning},
 		{ServerRunning, ActionStop, ServerStopped},
-		{ServerRunning, ActionReboot, ServerRunning},
+		{ServerRunning, ActionReboot, ServerRebooting},
+		{ServerRebooting, ActionCompleteReboot, ServerRunning},
 		{ServerStopped, ActionTerminate, ServerTerminated},
 		{ServerRunning, ActionTerminate, ServerTerminated},
 	}
@@ -96,6 +97,22 @@ func TestServer_Transition_EventLogging(t *testing.T) {
 	assert.Contains(t, events[0].Message, "started")
 }
 
+func TestServer_TakeEvents(t *testing.T) {
+	server := &Server{ID: "test-server", State: ServerRunning}
+	ctx := context.Background()
+
+	require.NoError(t, server.Transition(ctx, ActionReboot))
+	require.NoError(t, server.Transition(ctx, ActionCompleteReboot))
+
+	events := server.TakeEvents()
+	require.Len(t, events, 2)
+	assert.Equal(t, EventLogEntry{Timestamp: events[0].Timestamp, Type: EventRebooting, Message: "Server rebooting", From: ServerRunning, To: ServerRebooting, Action: ActionReboot}, events[0])
+	assert.Equal(t, EventRebooted, events[1].Type)
+	assert.Equal(t, ServerRebooting, events[1].From)
+	assert.Equal(t, ServerRunning, events[1].To)
+	assert.Empty(t, server.TakeEvents(), "events are taken once")
+}
+
 func TestServer_Transition_TimestampUpdates(t *testing.T) {
 	server := &Server{
 		ID:     "test-server",
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidGroupBy is returned for unknown or repeated cost summary groupings
+var ErrInvalidGroupBy = errors.New("invalid group_by")
+
+// SummaryGroup is a dimension that cost summaries are grouped by
+
+type SummaryGroup string
+
+const (
+	GroupRegion SummaryGroup = "region"
+	GroupType   SummaryGroup = "type"
+	GroupState  SummaryGroup = "state" // the server's current state
+	// "label:<key>" groups by the value of a label; servers without it fall in the "" group
+	labelGroupPrefix = "label:"
+)
+
+// LabelKey returns the label key of a "label:<key>" group
+func (g SummaryGroup) LabelKey() (string, bool) {
+	return strings.CutPrefix(string(g), labelGroupPrefix)
+}
+
+// ParseSummaryGroups parses a comma-separated list of groupings, such as "region,label:team"
+func ParseSummaryGroups(s string) ([]SummaryGroup, error) {
+	var groups []SummaryGroup
+	seen := make(map[SummaryGroup]bool)
+	for _, part := range strings.Split(s, ",") {
+		g := SummaryGroup(strings.TrimSpace(part))
+		switch g {
+		case GroupRegion, GroupType, GroupState:
+		default:
+			if key, ok := g.LabelKey(); !ok || !IsValidLabelKey(key) {
+				return nil, fmt.Errorf("%w: %q; want region, type, state or label:<key>", ErrInvalidGroupBy, g)
+			}
+		}
+		if seen[g] {
+			return nil, fmt.Errorf("%w: %q repeated", ErrInvalidGroupBy, g)
+		}
+		seen[g] = true
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
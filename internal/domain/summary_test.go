@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSummaryGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []SummaryGroup
+		wantErr bool
+	}{
+		{name: "one", s: "region", want: []SummaryGroup{GroupRegion}},
+		{name: "several", s: "region, type,label:team", want: []SummaryGroup{GroupRegion, GroupType, "label:team"}},
+		{name: "unknown", s: "zone", wantErr: true},
+		{name: "invalid label key", s: "label:Team", wantErr: true},
+		{name: "repeated", s: "type,type", wantErr: true},
+		{name: "empty", s: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSummaryGroups(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSummaryGroups() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if strings.Join(toStrings(got), ",") != strings.Join(toStrings(tt.want), ",") {
+				t.Errorf("ParseSummaryGroups() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func toStrings(groups []SummaryGroup) []string {
+	var s []string
+	for _, g := range groups {
+		s = append(s, string(g))
+	}
+	return s
+}
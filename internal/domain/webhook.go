@@ -0,0 +1,11 @@
+package domain
+
+// WebhookDeliveryStatus represents the lifecycle of one event delivery to a webhook
+
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending"   // waiting for its first or next attempt
+	DeliverySucceeded WebhookDeliveryStatus = "succeeded" // the receiver answered 2xx
+	DeliveryDead      WebhookDeliveryStatus = "dead"      // retries exhausted; kept for inspection
+)
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/rhythin/sever-management/internal/service"
+)
+
+// billingHandler provides HTTP handlers for billing reports
+type billingHandler struct {
+	Service service.BillingService
+}
+
+// @Summary Summarize cost and uptime
+// @Description Aggregate billed cost and uptime over a time range, grouped by region, type, state or label. Export as CSV with format=csv or Accept: text/csv.
+// @Tags billing
+// @Produce json
+// @Produce text/csv
+// @Param group_by query string false "Comma-separated: region, type, state or label:<key> (default region)"
+// @Param from query string false "RFC3339 start, inclusive (default start of the current month, UTC)"
+// @Param to query string false "RFC3339 end, exclusive (default now)"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} BillingSummaryResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /billing/summary [get]
+func (h *billingHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("GET /billing/summary - GetSummary called")
+
+	q := r.URL.Query()
+	groupBy := q.Get("group_by")
+	if groupBy == "" {
+		groupBy = string(domain.GroupRegion)
+	}
+	groups, err := domain.ParseSummaryGroups(groupBy)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	to := time.Now().UTC()
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid to: must be RFC3339")
+			return
+		}
+	}
+	from, _, _ := domain.PeriodBounds(domain.PeriodOf(to))
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid from: must be RFC3339")
+			return
+		}
+	}
+	asCSV, err := wantsCSV(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := h.Service.Summary(r.Context(), groups, from, to)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTimeRange) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Errorw("Failed to summarize billing", "groupBy", groupBy, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to summarize billing")
+		return
+	}
+
+	if asCSV {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="billing-summary.csv"`)
+		if err := writeSummaryCSV(w, groups, rows); err != nil {
+			log.Errorw("Failed to encode response", "error", err)
+		}
+		return
+	}
+	resp := packets.BillingSummaryResponse{
+		From:   from.Format(time.RFC3339),
+		To:     to.Format(time.RFC3339),
+		Groups: make([]*packets.BillingSummaryGroup, 0, len(rows)),
+	}
+	for _, g := range groups {
+		resp.GroupBy = append(resp.GroupBy, string(g))
+	}
+	for _, row := range rows {
+		key := make(map[string]string, len(groups))
+		for i, g := range groups {
+			key[string(g)] = row.Groups[i]
+		}
+		resp.Groups = append(resp.Groups, &packets.BillingSummaryGroup{
+			Key:           key,
+			Currency:      row.Currency,
+			Servers:       row.Servers,
+			UptimeSeconds: row.Seconds,
+			Cost:          row.Cost.String(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// writeSummaryCSV writes a column per grouping followed by the totals
+func writeSummaryCSV(w http.ResponseWriter, groups []domain.SummaryGroup, rows []*persistence.UsageSummaryRow) error {
+	cw := csv.NewWriter(w)
+	header := make([]string, 0, len(groups)+4)
+	for _, g := range groups {
+		header = append(header, string(g))
+	}
+	cw.Write(append(header, "currency", "servers", "uptime_seconds", "cost"))
+	for _, row := range rows {
+		record := append(append([]string(nil), row.Groups...),
+			row.Currency, strconv.FormatInt(row.Servers, 10), strconv.FormatInt(row.Seconds, 10), row.Cost.String())
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// @Summary Forecast spend
+// @Description Project the spend of running, rebooting and provisioning servers through until, per server, region and type, assuming current states persist. Servers are priced from their last billing, or from when provisioning is expected to finish, with the same rates, scheduled prices and rounding as billing, so billed spend plus the forecast is the expected total.
+// @Tags billing
+// @Produce json
+// @Param until query string false "RFC3339 time or YYYY-MM-DD date (UTC midnight); at most a year ahead (default end of the current month, UTC)"
+// @Success 200 {object} BillingForecastResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /billing/forecast [get]
+func (h *billingHandler) GetForecast(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("GET /billing/forecast - GetForecast called")
+
+	now := time.Now().UTC()
+	_, until, _ := domain.PeriodBounds(domain.PeriodOf(now))
+	if v := r.URL.Query().Get("until"); v != "" {
+		var err error
+		if until, err = time.Parse(time.RFC3339, v); err != nil {
+			if until, err = time.Parse(time.DateOnly, v); err != nil {
+				respondError(w, http.StatusBadRequest, "invalid until: must be RFC3339 or YYYY-MM-DD")
+				return
+			}
+		}
+	}
+
+	forecasts, err := h.Service.Forecast(r.Context(), now, until)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTimeRange) || errors.Is(err, service.ErrForecastTooFar) {
+			respondError(w, http.StatusBadRequest, "invalid until: "+err.Error())
+			return
+		}
+		log.Errorw("Failed to forecast billing", "until", until, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to forecast billing")
+		return
+	}
+
+	resp := packets.BillingForecastResponse{
+		From:    now.Format(time.RFC3339),
+		Until:   until.Format(time.RFC3339),
+		Totals:  forecastGroups(forecasts, func(*service.ServerForecast) string { return "" }),
+		Regions: forecastGroups(forecasts, func(f *service.ServerForecast) string { return f.Region }),
+		Types:   forecastGroups(forecasts, func(f *service.ServerForecast) string { return f.Type }),
+		Servers: make([]*packets.BillingForecastServer, 0, len(forecasts)),
+	}
+	for _, f := range forecasts {
+		resp.Servers = append(resp.Servers, &packets.BillingForecastServer{
+			ServerID:      f.ServerID,
+			Type:          f.Type,
+			Region:        f.Region,
+			Currency:      f.Currency,
+			UnbilledFrom:  f.From.Format(time.RFC3339),
+			UptimeSeconds: f.Seconds,
+			Cost:          f.Amount.String(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// forecastGroups totals server forecasts by key and currency, ordered by key then currency
+func forecastGroups(forecasts []*service.ServerForecast, key func(*service.ServerForecast) string) []*packets.BillingForecastGroup {
+	type groupKey struct{ key, currency string }
+	totals := make(map[groupKey]*packets.BillingForecastGroup)
+	costs := make(map[groupKey]domain.Micros)
+	for _, f := range forecasts {
+		k := groupKey{key(f), f.Currency}
+		g, ok := totals[k]
+		if !ok {
+			g = &packets.BillingForecastGroup{Key: k.key, Currency: k.currency}
+			totals[k] = g
+		}
+		g.Servers++
+		g.UptimeSeconds += f.Seconds
+		costs[k] += f.Amount
+	}
+	groups := make([]*packets.BillingForecastGroup, 0, len(totals))
+	for k, g := range totals {
+		g.Cost = costs[k].String()
+		groups = append(groups, g)
+	}
+	slices.SortFunc(groups, func(a, b *packets.BillingForecastGroup) int {
+		if a.Key != b.Key {
+			return strings.Compare(a.Key, b.Key)
+		}
+		return strings.Compare(a.Currency, b.Currency)
+	})
+	return groups
+}
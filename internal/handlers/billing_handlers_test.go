@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/rhythin/sever-management/internal/service"
+	mockService "github.com/rhythin/sever-management/internal/service/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_billingHandler_GetSummary(t *testing.T) {
+	from := time.Date(2026, 4, 6, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 4, 13, 0, 0, 0, 0, time.UTC)
+	groups := []domain.SummaryGroup{domain.GroupRegion, "label:team"}
+	svc := &mockService.BillingService{}
+	svc.On("Summary", mock.Anything, groups, from, to).Return([]*persistence.UsageSummaryRow{
+		{Groups: []string{"eu-west-1", ""}, Currency: "USD", Servers: 1, Seconds: 3600, Cost: 11600},
+		{Groups: []string{"us-east-1", "payments"}, Currency: "USD", Servers: 3, Seconds: 36000, Cost: 116000},
+	}, nil)
+	svc.On("Summary", mock.Anything, []domain.SummaryGroup{domain.GroupType}, from, to).Return(nil, errors.New("service layer error"))
+	svc.On("Summary", mock.Anything, []domain.SummaryGroup{domain.GroupRegion}, to, from).Return(nil, service.ErrInvalidTimeRange)
+
+	const week = "from=2026-04-06T00:00:00Z&to=2026-04-13T00:00:00Z"
+	tests := []struct {
+		name  string
+		query string
+		code  int
+		csv   bool
+	}{
+		{name: "json", query: "group_by=region,label:team&" + week, code: http.StatusOK},
+		{name: "csv", query: "group_by=region,label:team&format=csv&" + week, code: http.StatusOK, csv: true},
+		{name: "unknown group", query: "group_by=zone&" + week, code: http.StatusBadRequest},
+		{name: "invalid from", query: "group_by=region&from=yesterday", code: http.StatusBadRequest},
+		{name: "reversed range", query: "group_by=region&from=2026-04-13T00:00:00Z&to=2026-04-06T00:00:00Z", code: http.StatusBadRequest},
+		{name: "service layer error", query: "group_by=type&" + week, code: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			(&billingHandler{Service: svc}).GetSummary(w, httptest.NewRequest("GET", "/billing/summary?"+tt.query, nil))
+			if w.Code != tt.code {
+				t.Fatalf("GetSummary() code = %d, want %d", w.Code, tt.code)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			if tt.csv {
+				rows, err := csv.NewReader(w.Body).ReadAll()
+				if err != nil || len(rows) != 3 {
+					t.Fatalf("GetSummary() returned %d CSV rows (err %v), want 3", len(rows), err)
+				}
+				if got := strings.Join(rows[0], ","); got != "region,label:team,currency,servers,uptime_seconds,cost" {
+					t.Errorf("GetSummary() CSV header = %s", got)
+				}
+				if got := strings.Join(rows[2], ","); got != "us-east-1,payments,USD,3,36000,0.116000" {
+					t.Errorf("GetSummary() CSV row = %s", got)
+				}
+				return
+			}
+			var resp packets.BillingSummaryResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("GetSummary() returned invalid JSON: %v", err)
+			}
+			if len(resp.Groups) != 2 || resp.Groups[1].Key["label:team"] != "payments" || resp.Groups[1].Cost != "0.116000" || resp.From != "2026-04-06T00:00:00Z" {
+				t.Errorf("GetSummary() = %+v", resp)
+			}
+		})
+	}
+}
+
+func Test_billingHandler_GetForecast(t *testing.T) {
+	until := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	svc := &mockService.BillingService{}
+	svc.On("Forecast", mock.Anything, mock.Anything, until).Return([]*service.ServerForecast{
+		{ServerID: "srv-1", Type: "t2.micro", Region: "us-east-1", Currency: "USD", Seconds: 3600, Amount: 11600},
+		{ServerID: "srv-2", Type: "t2.micro", Region: "eu-west-1", Currency: "USD", Seconds: 7200, Amount: 23200},
+		{ServerID: "srv-3", Type: "m5.large", Region: "us-east-1", Currency: "USD", Seconds: 3600, Amount: 96000},
+	}, nil)
+	svc.On("Forecast", mock.Anything, mock.Anything, until.AddDate(5, 0, 0)).Return(nil, service.ErrForecastTooFar)
+
+	tests := []struct {
+		name  string
+		query string
+		code  int
+	}{
+		{name: "date", query: "?until=2026-05-01", code: http.StatusOK},
+		{name: "RFC3339", query: "?until=2026-05-01T00:00:00Z", code: http.StatusOK},
+		{name: "too far", query: "?until=2031-05-01", code: http.StatusBadRequest},
+		{name: "invalid until", query: "?until=tomorrow", code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			(&billingHandler{Service: svc}).GetForecast(w, httptest.NewRequest("GET", "/billing/forecast"+tt.query, nil))
+			if w.Code != tt.code {
+				t.Fatalf("GetForecast() code = %d, want %d", w.Code, tt.code)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			var resp packets.BillingForecastResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("GetForecast() returned invalid JSON: %v", err)
+			}
+			if len(resp.Servers) != 3 || len(resp.Totals) != 1 || resp.Totals[0].Cost != "0.130800" || resp.Totals[0].Servers != 3 {
+				t.Errorf("GetForecast() servers = %d, totals = %+v", len(resp.Servers), resp.Totals)
+			}
+			if len(resp.Regions) != 2 || resp.Regions[1].Key != "us-east-1" || resp.Regions[1].Cost != "0.107600" || resp.Regions[1].UptimeSeconds != 7200 {
+				t.Errorf("GetForecast() regions = %+v", resp.Regions)
+			}
+			if len(resp.Types) != 2 || resp.Types[1].Key != "t2.micro" || resp.Types[1].Servers != 2 {
+				t.Errorf("GetForecast() types = %+v", resp.Types)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/rhythin/sever-management/internal/service"
+)
+
+// budgetHandler provides HTTP handlers for budgets
+type budgetHandler struct {
+	Service service.BudgetService
+}
+
+// @Summary Create a budget
+// @Description Cap the monthly spend of the servers in a region and/or matching a label selector. Each threshold, a percentage of the limit, emits an event, calls a webhook or stops the budget's running servers when spend reaches it.
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param budget body CreateBudgetRequest true "Budget scope, limit and thresholds"
+// @Success 201 {object} BudgetResponse
+// @Failure 400 {object} errorResponse
+// @Router /budgets [post]
+func (h *budgetHandler) CreateBudget(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("POST /budgets - CreateBudget called")
+
+	var req packets.CreateBudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warnw("Invalid request body", "error", err)
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	limit, err := domain.ParseMicros(req.MonthlyLimit)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid monthly_limit: "+err.Error())
+		return
+	}
+	budget := &persistence.Budget{Name: req.Name, Region: req.Region, Selector: req.Selector, Limit: limit}
+	for _, t := range req.Thresholds {
+		budget.Thresholds = append(budget.Thresholds, &persistence.BudgetThreshold{Percent: t.Percent, Action: t.Action, WebhookID: t.WebhookID})
+	}
+	budget, err = h.Service.Create(r.Context(), budget)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidBudget) || errors.Is(err, service.ErrUnknownRegion) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Errorw("Failed to create budget", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to create budget")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(toBudgetResponse(budget)); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary List budgets
+// @Description List budgets with their thresholds
+// @Tags budgets
+// @Produce json
+// @Success 200 {array} BudgetResponse
+// @Failure 500 {object} errorResponse
+// @Router /budgets [get]
+func (h *budgetHandler) ListBudgets(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("GET /budgets - ListBudgets called")
+
+	budgets, err := h.Service.List(r.Context())
+	if err != nil {
+		log.Errorw("Failed to list budgets", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to list budgets")
+		return
+	}
+	resp := make([]*packets.BudgetResponse, 0, len(budgets))
+	for _, budget := range budgets {
+		resp = append(resp, toBudgetResponse(budget))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary Get a budget
+// @Description Get a budget with this month's spend and the thresholds it has fired this month
+// @Tags budgets
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Success 200 {object} BudgetResponse
+// @Failure 404 {object} errorResponse
+// @Router /budgets/{id} [get]
+func (h *budgetHandler) GetBudget(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	id := chi.URLParam(r, "id")
+	log.Infow("GET /budgets/{id} - GetBudget called", "id", id)
+
+	budget, err := h.Service.Get(r.Context(), id)
+	if err != nil {
+		respondBudgetError(w, r, err)
+		return
+	}
+	now := time.Now().UTC()
+	spend, alerts, err := h.Service.Status(r.Context(), budget, now)
+	if err != nil {
+		respondBudgetError(w, r, err)
+		return
+	}
+	resp := toBudgetResponse(budget)
+	resp.Period = domain.PeriodOf(now)
+	resp.Spend = spend.String()
+	for _, a := range alerts {
+		resp.Alerts = append(resp.Alerts, &packets.BudgetAlertResponse{
+			Percent: a.Percent,
+			Action:  a.Action,
+			Spend:   a.Spend.String(),
+			FiredAt: a.FiredAt.Format(time.RFC3339),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary Delete a budget
+// @Description Delete a budget and its alert history
+// @Tags budgets
+// @Param id path string true "Budget ID"
+// @Success 204
+// @Failure 404 {object} errorResponse
+// @Router /budgets/{id} [delete]
+func (h *budgetHandler) DeleteBudget(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	id := chi.URLParam(r, "id")
+	log.Infow("DELETE /budgets/{id} - DeleteBudget called", "id", id)
+
+	if err := h.Service.Delete(r.Context(), id); err != nil {
+		respondBudgetError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func respondBudgetError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, service.ErrBudgetNotFound) {
+		respondError(w, http.StatusNotFound, "budget not found")
+		return
+	}
+	logging.S(r.Context()).Errorw("Budget request failed", "error", err)
+	respondError(w, http.StatusInternalServerError, "internal error")
+}
+
+func toBudgetResponse(budget *persistence.Budget) *packets.BudgetResponse {
+	resp := &packets.BudgetResponse{
+		ID:           budget.ID,
+		Name:         budget.Name,
+		Region:       budget.Region,
+		Selector:     budget.Selector,
+		MonthlyLimit: budget.Limit.String(),
+		Currency:     budget.Currency,
+		Thresholds:   make([]*packets.BudgetThresholdRequest, 0, len(budget.Thresholds)),
+		CreatedAt:    budget.CreatedAt.Format(time.RFC3339),
+	}
+	for _, t := range budget.Thresholds {
+		resp.Thresholds = append(resp.Thresholds, &packets.BudgetThresholdRequest{Percent: t.Percent, Action: t.Action, WebhookID: t.WebhookID})
+	}
+	return resp
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/rhythin/sever-management/internal/service"
+	mockService "github.com/rhythin/sever-management/internal/service/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_budgetHandler_CreateBudget(t *testing.T) {
+	svc := &mockService.BudgetService{}
+	svc.On("Create", mock.Anything, mock.MatchedBy(func(b *persistence.Budget) bool { return b.Name == "prod" })).
+		Return(func(_ context.Context, b *persistence.Budget) *persistence.Budget {
+			created := *b
+			created.ID, created.Currency = "budget-1", "USD"
+			return &created
+		}, nil)
+	svc.On("Create", mock.Anything, mock.MatchedBy(func(b *persistence.Budget) bool { return b.Name == "" })).
+		Return(nil, fmt.Errorf("%w: name is required", service.ErrInvalidBudget))
+	svc.On("Create", mock.Anything, mock.MatchedBy(func(b *persistence.Budget) bool { return b.Name == "mars" })).
+		Return(nil, service.ErrUnknownRegion)
+
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{name: "created", body: `{"name":"prod","selector":{"env":"prod"},"monthly_limit":"1000.50","thresholds":[{"percent":80,"action":"event"},{"percent":100,"action":"stop"}]}`, code: http.StatusCreated},
+		{name: "invalid budget", body: `{"monthly_limit":"10","thresholds":[{"percent":80,"action":"event"}]}`, code: http.StatusBadRequest},
+		{name: "unknown region", body: `{"name":"mars","region":"mars-1","monthly_limit":"10","thresholds":[{"percent":80,"action":"event"}]}`, code: http.StatusBadRequest},
+		{name: "invalid limit", body: `{"name":"prod","monthly_limit":"ten"}`, code: http.StatusBadRequest},
+		{name: "invalid body", body: `{`, code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			(&budgetHandler{Service: svc}).CreateBudget(w, httptest.NewRequest("POST", "/budgets", strings.NewReader(tt.body)))
+			if w.Code != tt.code {
+				t.Fatalf("CreateBudget() code = %d, want %d", w.Code, tt.code)
+			}
+			if tt.code != http.StatusCreated {
+				return
+			}
+			var resp packets.BudgetResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("CreateBudget() returned invalid JSON: %v", err)
+			}
+			if resp.ID != "budget-1" || resp.MonthlyLimit != "1000.500000" || resp.Selector["env"] != "prod" || len(resp.Thresholds) != 2 || resp.Thresholds[1].Action != "stop" {
+				t.Errorf("CreateBudget() = %+v", resp)
+			}
+		})
+	}
+}
+
+func Test_budgetHandler_GetBudget(t *testing.T) {
+	budget := &persistence.Budget{ID: "budget-1", Name: "prod", Limit: 100_000_000, Currency: "USD",
+		Thresholds: []*persistence.BudgetThreshold{{Percent: 50, Action: string(domain.BudgetActionEvent)}}}
+	svc := &mockService.BudgetService{}
+	svc.On("Get", mock.Anything, "budget-1").Return(budget, nil)
+	svc.On("Get", mock.Anything, "missing").Return(nil, service.ErrBudgetNotFound)
+	svc.On("Status", mock.Anything, budget, mock.Anything).Return(domain.Micros(60_000_000), []*persistence.BudgetAlert{
+		{Percent: 50, Action: string(domain.BudgetActionEvent), Spend: 51_000_000, FiredAt: time.Now()},
+	}, nil)
+
+	tests := []struct {
+		name string
+		id   string
+		code int
+	}{
+		{name: "with status", id: "budget-1", code: http.StatusOK},
+		{name: "unknown budget", id: "missing", code: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/budgets/"+tt.id, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			w := httptest.NewRecorder()
+			(&budgetHandler{Service: svc}).GetBudget(w, req)
+			if w.Code != tt.code {
+				t.Fatalf("GetBudget() code = %d, want %d", w.Code, tt.code)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			var resp packets.BudgetResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("GetBudget() returned invalid JSON: %v", err)
+			}
+			if resp.Spend != "60.000000" || resp.Period == "" || len(resp.Alerts) != 1 || resp.Alerts[0].Spend != "51.000000" {
+				t.Errorf("GetBudget() = %+v", resp)
+			}
+		})
+	}
+}
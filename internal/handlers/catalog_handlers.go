@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/service"
+)
+
+// catalogHandler provides HTTP handlers for catalog endpoints
+type catalogHandler struct {
+	Service service.CatalogService
+}
+
+// @Summary List server types
+// @Description List the server type catalog with resources, hourly price and boot time
+// @Tags catalog
+// @Produce json
+// @Success 200 {array} ServerTypeResponse
+// @Router /types [get]
+func (h *catalogHandler) ListTypes(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("GET /types - ListTypes called")
+
+	types := h.Service.ListTypes(r.Context())
+	resp := make([]packets.ServerTypeResponse, 0, len(types))
+	for _, t := range types {
+		resp = append(resp, packets.ServerTypeResponse{
+			Name:        t.Name,
+			VCPU:        t.VCPU,
+			MemoryMiB:   t.MemoryMiB,
+			DiskGiB:     t.DiskGiB,
+			HourlyPrice: t.HourlyPrice.String(),
+			BootTime:    t.BootTime.String(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary List regions
+// @Description List managed regions with their status and live usage against capacity
+// @Tags catalog
+// @Produce json
+// @Success 200 {array} RegionResponse
+// @Failure 500 {object} errorResponse
+// @Router /regions [get]
+func (h *catalogHandler) ListRegions(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("GET /regions - ListRegions called")
+
+	regions, err := h.Service.ListRegions(r.Context())
+	if err != nil {
+		log.Errorw("Failed to list regions", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to list regions")
+		return
+	}
+	resp := make([]packets.RegionResponse, 0, len(regions))
+	for _, region := range regions {
+		available := int64(region.MaxServers) - region.ActiveServers
+		if available < 0 {
+			available = 0
+		}
+		resp = append(resp, packets.RegionResponse{
+			Name:          region.Name,
+			Status:        region.Status,
+			MaxServers:    region.MaxServers,
+			ActiveServers: region.ActiveServers,
+			Available:     available,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary Register or update a region
+// @Description Set a region's status (enabled, draining, disabled) and maximum server count
+// @Tags catalog
+// @Accept json
+// @Produce json
+// @Param name path string true "Region name"
+// @Param region body UpdateRegionRequest true "Region settings"
+// @Success 204
+// @Failure 400 {object} errorResponse
+// @Router /regions/{name} [put]
+func (h *catalogHandler) UpdateRegion(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	name := chi.URLParam(r, "name")
+	log.Infow("PUT /regions/{name} - UpdateRegion called", "name", name)
+
+	var req packets.UpdateRegionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warnw("Invalid request body", "error", err)
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := h.Service.UpdateRegion(r.Context(), name, domain.RegionStatus(req.Status), req.MaxServers); err != nil {
+		if errors.Is(err, service.ErrInvalidRegion) {
+			respondError(w, http.StatusBadRequest, "status must be one of enabled, draining, disabled and max_servers must not be negative")
+			return
+		}
+		log.Errorw("Failed to update region", "name", name, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to update region")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/service"
+	mockService "github.com/rhythin/sever-management/internal/service/mocks"
+)
+
+func Test_catalogHandler_ListTypes(t *testing.T) {
+	svc := service.NewCatalogService(&internal.Config{ServerTypes: internal.ServerTypeCatalog{
+		{Name: "t2.micro", VCPU: 1, MemoryMiB: 1024, DiskGiB: 8, HourlyPrice: 11600, BootTime: time.Second},
+	}}, nil)
+	h := &catalogHandler{Service: svc}
+
+	w := httptest.NewRecorder()
+	h.ListTypes(w, httptest.NewRequest("GET", "/types", nil))
+
+	var resp []packets.ServerTypeResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("ListTypes() returned invalid JSON: %v", err)
+	}
+	if len(resp) != 1 || resp[0].Name != "t2.micro" || resp[0].BootTime != "1s" || resp[0].HourlyPrice != "0.011600" {
+		t.Errorf("ListTypes() = %+v", resp)
+	}
+}
+
+func Test_catalogHandler_ListRegions(t *testing.T) {
+	ok := &mockService.CatalogService{}
+	ok.On("ListRegions", mock.Anything).Return([]service.RegionUsage{
+		{Region: persistence.Region{Name: "us-east-1", Status: "enabled", MaxServers: 2}, ActiveServers: 3},
+	}, nil)
+	failing := &mockService.CatalogService{}
+	failing.On("ListRegions", mock.Anything).Return(nil, errors.New("service layer error"))
+
+	w := httptest.NewRecorder()
+	(&catalogHandler{Service: ok}).ListRegions(w, httptest.NewRequest("GET", "/regions", nil))
+	var resp []packets.RegionResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("ListRegions() returned invalid JSON: %v", err)
+	}
+	if len(resp) != 1 || resp[0].ActiveServers != 3 || resp[0].Available != 0 {
+		t.Errorf("ListRegions() = %+v", resp)
+	}
+
+	w = httptest.NewRecorder()
+	(&catalogHandler{Service: failing}).ListRegions(w, httptest.NewRequest("GET", "/regions", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("ListRegions() code = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}
+
+func UpdateRegionRequestGenerator(name, body string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("name", name)
+	req := httptest.NewRequest("PUT", "/regions/"+name, strings.NewReader(body))
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func Test_catalogHandler_UpdateRegion(t *testing.T) {
+	svc := &mockService.CatalogService{}
+	svc.On("UpdateRegion", mock.Anything, "eu-west-1", domain.RegionDraining, 10).Return(nil)
+	svc.On("UpdateRegion", mock.Anything, "eu-west-1", domain.RegionStatus("closed"), 10).Return(service.ErrInvalidRegion)
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{name: "drain region", body: `{"status":"draining","max_servers":10}`, wantCode: http.StatusNoContent},
+		{name: "invalid status", body: `{"status":"closed","max_servers":10}`, wantCode: http.StatusBadRequest},
+		{name: "invalid body", body: `{`, wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			(&catalogHandler{Service: svc}).UpdateRegion(w, UpdateRegionRequestGenerator("eu-west-1", tt.body))
+			if w.Code != tt.wantCode {
+				t.Errorf("UpdateRegion() code = %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/packets"
+)
+
+// @Summary Describe the server state machine
+// @Description Return the FSM transition table so clients can render state diagrams and pre-validate actions
+// @Tags fsm
+// @Produce json
+// @Success 200 {object} FSMResponse
+// @Router /fsm [get]
+func FSMHandler(w http.ResponseWriter, r *http.Request) {
+	resp := packets.FSMResponse{
+		InitialState:   string(domain.InitialState),
+		States:         []string{},
+		TerminalStates: []string{},
+		Actions:        []string{},
+		Transitions:    make([]packets.TransitionResponse, 0, len(domain.Transitions)),
+	}
+	for _, st := range domain.States() {
+		resp.States = append(resp.States, string(st))
+		if domain.IsTerminal(st) {
+			resp.TerminalStates = append(resp.TerminalStates, string(st))
+		}
+	}
+	for _, a := range domain.ClientActions() {
+		resp.Actions = append(resp.Actions, string(a))
+	}
+	for _, t := range domain.Transitions {
+		events := make([]string, 0, len(t.Events))
+		for _, e := range t.Events {
+			events = append(events, string(e))
+		}
+		resp.Transitions = append(resp.Transitions, packets.TransitionResponse{
+			From:     string(t.From),
+			Action:   string(t.Action),
+			To:       string(t.To),
+			Events:   events,
+			Sets:     string(t.Stamp),
+			Internal: t.Internal,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/packets"
+)
+
+func TestFSMHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	FSMHandler(w, httptest.NewRequest("GET", "/fsm", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("FSMHandler() code = %d, want %d", w.Code, http.StatusOK)
+	}
+	var resp packets.FSMResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("FSMHandler() returned invalid JSON: %v", err)
+	}
+	if len(resp.Transitions) != len(domain.Transitions) {
+		t.Errorf("FSMHandler() transitions = %d, want %d", len(resp.Transitions), len(domain.Transitions))
+	}
+	if resp.InitialState != string(domain.ServerProvisioning) {
+		t.Errorf("FSMHandler() initial_state = %s", resp.InitialState)
+	}
+}
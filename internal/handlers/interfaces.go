@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 
+	"github.com/rhythin/sever-management/internal"
 	"github.com/rhythin/sever-management/internal/service"
 )
 
@@ -10,10 +11,119 @@ type ServerHandler interface {
 	ProvisionServer(w http.ResponseWriter, r *http.Request)
 	ServerAction(w http.ResponseWriter, r *http.Request)
 	GetServerLogs(w http.ResponseWriter, r *http.Request)
+	GetServerUsage(w http.ResponseWriter, r *http.Request)
+	ListEvents(w http.ResponseWriter, r *http.Request)
 	GetServer(w http.ResponseWriter, r *http.Request)
 	ListServers(w http.ResponseWriter, r *http.Request)
+	GetOperation(w http.ResponseWriter, r *http.Request)
+	PostponeReap(w http.ResponseWriter, r *http.Request)
 }
 
-func NewServerHandler(service service.ServerService) ServerHandler {
-	return &serverHandler{Service: service}
+func NewServerHandler(service service.ServerService, reaper service.ReaperService) ServerHandler {
+	return &serverHandler{Service: service, Reaper: reaper}
+}
+
+type StreamHandler interface {
+	StreamServerEvents(w http.ResponseWriter, r *http.Request)
+	StreamEvents(w http.ResponseWriter, r *http.Request)
+}
+
+func NewStreamHandler(service service.ServerService, cfg *internal.Config) StreamHandler {
+	return &streamHandler{Service: service, KeepAlive: cfg.EventStreamKeepAlive}
+}
+
+type CatalogHandler interface {
+	ListTypes(w http.ResponseWriter, r *http.Request)
+	ListRegions(w http.ResponseWriter, r *http.Request)
+	UpdateRegion(w http.ResponseWriter, r *http.Request)
+}
+
+func NewCatalogHandler(service service.CatalogService) CatalogHandler {
+	return &catalogHandler{Service: service}
+}
+
+type WebhookHandler interface {
+	CreateWebhook(w http.ResponseWriter, r *http.Request)
+	ListWebhooks(w http.ResponseWriter, r *http.Request)
+	GetWebhook(w http.ResponseWriter, r *http.Request)
+	DeleteWebhook(w http.ResponseWriter, r *http.Request)
+	ListWebhookDeliveries(w http.ResponseWriter, r *http.Request)
+}
+
+func NewWebhookHandler(service service.WebhookService) WebhookHandler {
+	return &webhookHandler{Service: service}
+}
+
+type PriceHandler interface {
+	CreatePrice(w http.ResponseWriter, r *http.Request)
+	ListPrices(w http.ResponseWriter, r *http.Request)
+}
+
+func NewPriceHandler(service service.PriceService) PriceHandler {
+	return &priceHandler{Service: service}
+}
+
+type InvoiceHandler interface {
+	ListInvoices(w http.ResponseWriter, r *http.Request)
+	GetInvoice(w http.ResponseWriter, r *http.Request)
+}
+
+func NewInvoiceHandler(service service.InvoiceService) InvoiceHandler {
+	return &invoiceHandler{Service: service}
+}
+
+type BillingHandler interface {
+	GetSummary(w http.ResponseWriter, r *http.Request)
+	GetForecast(w http.ResponseWriter, r *http.Request)
+}
+
+func NewBillingHandler(service service.BillingService) BillingHandler {
+	return &billingHandler{Service: service}
+}
+
+type BudgetHandler interface {
+	CreateBudget(w http.ResponseWriter, r *http.Request)
+	ListBudgets(w http.ResponseWriter, r *http.Request)
+	GetBudget(w http.ResponseWriter, r *http.Request)
+	DeleteBudget(w http.ResponseWriter, r *http.Request)
+}
+
+func NewBudgetHandler(service service.BudgetService) BudgetHandler {
+	return &budgetHandler{Service: service}
+}
+
+type ReaperHandler interface {
+	CreatePolicy(w http.ResponseWriter, r *http.Request)
+	ListPolicies(w http.ResponseWriter, r *http.Request)
+	GetPolicy(w http.ResponseWriter, r *http.Request)
+	UpdatePolicy(w http.ResponseWriter, r *http.Request)
+	DeletePolicy(w http.ResponseWriter, r *http.Request)
+	Preview(w http.ResponseWriter, r *http.Request)
+}
+
+func NewReaperHandler(service service.ReaperService) ReaperHandler {
+	return &reaperHandler{Service: service}
+}
+
+type JobHandler interface {
+	ListJobs(w http.ResponseWriter, r *http.Request)
+	ListRuns(w http.ResponseWriter, r *http.Request)
+	RunJob(w http.ResponseWriter, r *http.Request)
+}
+
+func NewJobHandler(service service.JobService) JobHandler {
+	return &jobHandler{Service: service}
+}
+
+type IPPoolHandler interface {
+	CreatePool(w http.ResponseWriter, r *http.Request)
+	ListPools(w http.ResponseWriter, r *http.Request)
+	GetPool(w http.ResponseWriter, r *http.Request)
+	DeletePool(w http.ResponseWriter, r *http.Request)
+	AddCIDR(w http.ResponseWriter, r *http.Request)
+	RetireCIDR(w http.ResponseWriter, r *http.Request)
+}
+
+func NewIPPoolHandler(service service.IPPoolService) IPPoolHandler {
+	return &ipPoolHandler{Service: service}
 }
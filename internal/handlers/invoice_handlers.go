@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/rhythin/sever-management/internal/service"
+)
+
+// invoiceHandler provides HTTP handlers for invoices
+type invoiceHandler struct {
+	Service service.InvoiceService
+}
+
+// @Summary List invoices
+// @Description List invoices, newest period first, without their lines
+// @Tags invoices
+// @Produce json
+// @Param status query string false "draft or closed"
+// @Success 200 {array} InvoiceResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /invoices [get]
+func (h *invoiceHandler) ListInvoices(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("GET /invoices - ListInvoices called")
+
+	status := r.URL.Query().Get("status")
+	if status != "" && status != string(domain.InvoiceDraft) && status != string(domain.InvoiceClosed) {
+		respondError(w, http.StatusBadRequest, "invalid status: must be draft or closed")
+		return
+	}
+	invoices, err := h.Service.List(r.Context(), status)
+	if err != nil {
+		log.Errorw("Failed to list invoices", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to list invoices")
+		return
+	}
+	resp := make([]*packets.InvoiceResponse, 0, len(invoices))
+	for _, invoice := range invoices {
+		resp = append(resp, toInvoiceResponse(invoice))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary Get an invoice
+// @Description Get an invoice with a line per server and rate. Export as CSV with format=csv or Accept: text/csv.
+// @Tags invoices
+// @Produce json
+// @Produce text/csv
+// @Param id path int true "Invoice ID"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} InvoiceResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Router /invoices/{id} [get]
+func (h *invoiceHandler) GetInvoice(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	idParam := chi.URLParam(r, "id")
+	log.Infow("GET /invoices/{id} - GetInvoice called", "id", idParam)
+
+	id, err := strconv.ParseUint(idParam, 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid invoice id")
+		return
+	}
+	asCSV, err := wantsCSV(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	invoice, err := h.Service.Get(r.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrInvoiceNotFound) {
+			respondError(w, http.StatusNotFound, "invoice not found")
+			return
+		}
+		log.Errorw("Failed to get invoice", "id", id, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to get invoice")
+		return
+	}
+
+	if asCSV {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="invoice-%d-%s.csv"`, invoice.ID, invoice.Period))
+		if err := writeInvoiceCSV(w, invoice); err != nil {
+			log.Errorw("Failed to encode response", "error", err)
+		}
+		return
+	}
+	resp := toInvoiceResponse(invoice)
+	resp.Lines = make([]*packets.InvoiceLineResponse, 0, len(invoice.Lines))
+	for _, line := range invoice.Lines {
+		resp.Lines = append(resp.Lines, &packets.InvoiceLineResponse{
+			ServerID:      line.ServerID,
+			Type:          line.Type,
+			Region:        line.Region,
+			Seconds:       line.Seconds,
+			HourlyRate:    line.Rate.String(),
+			Amount:        line.Amount.String(),
+			AdjustsPeriod: line.AdjustsPeriod,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// writeInvoiceCSV writes an invoice's lines with a header row; every row repeats the invoice
+// ID, period and currency so that exports can be concatenated
+func writeInvoiceCSV(w http.ResponseWriter, invoice *persistence.Invoice) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"invoice_id", "period", "server_id", "type", "region", "seconds", "hourly_rate", "amount", "currency", "adjusts_period"})
+	id := strconv.FormatUint(uint64(invoice.ID), 10)
+	for _, line := range invoice.Lines {
+		cw.Write([]string{
+			id, invoice.Period, line.ServerID, line.Type, line.Region, strconv.FormatInt(line.Seconds, 10),
+			line.Rate.String(), line.Amount.String(), invoice.Currency, line.AdjustsPeriod,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func toInvoiceResponse(invoice *persistence.Invoice) *packets.InvoiceResponse {
+	resp := &packets.InvoiceResponse{
+		ID:          invoice.ID,
+		Period:      invoice.Period,
+		PeriodStart: invoice.PeriodStart.Format(time.RFC3339),
+		PeriodEnd:   invoice.PeriodEnd.Format(time.RFC3339),
+		Status:      invoice.Status,
+		Currency:    invoice.Currency,
+		Total:       invoice.Total.String(),
+	}
+	if invoice.ClosedAt != nil {
+		closed := invoice.ClosedAt.Format(time.RFC3339)
+		resp.ClosedAt = &closed
+	}
+	return resp
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/rhythin/sever-management/internal/service"
+	mockService "github.com/rhythin/sever-management/internal/service/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_invoiceHandler_GetInvoice(t *testing.T) {
+	invoice := &persistence.Invoice{
+		ID:          7,
+		Period:      "2026-04",
+		PeriodStart: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:   time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC),
+		Status:      "closed",
+		Currency:    "USD",
+		Total:       23300,
+		Lines: []*persistence.InvoiceLine{
+			{ServerID: "srv-1", Type: "t2.micro", Region: "us-east-1", Seconds: 7200, Rate: 11600, Amount: 23200},
+			{ServerID: "srv-1", Type: "t2.micro", Region: "us-east-1", Seconds: 31, Rate: 11600, Amount: 100, AdjustsPeriod: "2026-03"},
+		},
+	}
+	svc := &mockService.InvoiceService{}
+	svc.On("Get", mock.Anything, uint(7)).Return(invoice, nil)
+	svc.On("Get", mock.Anything, uint(8)).Return(nil, service.ErrInvoiceNotFound)
+	svc.On("Get", mock.Anything, uint(9)).Return(nil, errors.New("service layer error"))
+
+	get := func(id, query, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/invoices/"+id+query, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		(&invoiceHandler{Service: svc}).GetInvoice(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name   string
+		id     string
+		query  string
+		accept string
+		code   int
+		csv    bool
+	}{
+		{name: "json", id: "7", code: http.StatusOK},
+		{name: "csv by query", id: "7", query: "?format=csv", code: http.StatusOK, csv: true},
+		{name: "csv by accept header", id: "7", accept: "text/csv", code: http.StatusOK, csv: true},
+		{name: "invalid format", id: "7", query: "?format=xml", code: http.StatusBadRequest},
+		{name: "not found", id: "8", code: http.StatusNotFound},
+		{name: "service layer error", id: "9", code: http.StatusInternalServerError},
+		{name: "invalid id", id: "abc", code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(tt.id, tt.query, tt.accept)
+			if w.Code != tt.code {
+				t.Fatalf("GetInvoice() code = %d, want %d", w.Code, tt.code)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			if tt.csv {
+				rows, err := csv.NewReader(w.Body).ReadAll()
+				if err != nil || len(rows) != 3 {
+					t.Fatalf("GetInvoice() returned %d CSV rows (err %v), want 3", len(rows), err)
+				}
+				want := []string{"7", "2026-04", "srv-1", "t2.micro", "us-east-1", "31", "0.011600", "0.000100", "USD", "2026-03"}
+				for i := range want {
+					if rows[2][i] != want[i] {
+						t.Errorf("GetInvoice() CSV row = %v, want %v", rows[2], want)
+						break
+					}
+				}
+				return
+			}
+			var resp packets.InvoiceResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("GetInvoice() returned invalid JSON: %v", err)
+			}
+			if resp.Total != "0.023300" || resp.Status != "closed" || len(resp.Lines) != 2 || resp.Lines[1].AdjustsPeriod != "2026-03" {
+				t.Errorf("GetInvoice() = %+v", resp)
+			}
+		})
+	}
+}
+
+func Test_invoiceHandler_ListInvoices(t *testing.T) {
+	svc := &mockService.InvoiceService{}
+	svc.On("List", mock.Anything, "draft").Return([]*persistence.Invoice{{ID: 8, Period: "2026-05", Status: "draft", Total: 1}}, nil)
+	svc.On("List", mock.Anything, "").Return(nil, errors.New("service layer error"))
+
+	tests := []struct {
+		name   string
+		status string
+		code   int
+	}{
+		{name: "drafts", status: "draft", code: http.StatusOK},
+		{name: "invalid status", status: "paid", code: http.StatusBadRequest},
+		{name: "service layer error", code: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			(&invoiceHandler{Service: svc}).ListInvoices(w, httptest.NewRequest("GET", "/invoices?status="+tt.status, nil))
+			if w.Code != tt.code {
+				t.Fatalf("ListInvoices() code = %d, want %d", w.Code, tt.code)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			var resp []packets.InvoiceResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || len(resp) != 1 || resp[0].Total != "0.000001" || resp[0].Lines != nil {
+				t.Errorf("ListInvoices() = %+v (err %v)", resp, err)
+			}
+		})
+	}
+}
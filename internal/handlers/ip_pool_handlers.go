@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/rhythin/sever-management/internal/service"
+)
+
+// ipPoolHandler provides HTTP handlers for IP pools
+type ipPoolHandler struct {
+	Service service.IPPoolService
+}
+
+// @Summary Create an IP pool
+// @Description Create a named pool of CIDRs for a region. Servers provisioned in the region are given addresses from its pools only. CIDRs are IPv4, /16 or smaller, and must not overlap any other pool's.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param pool body CreateIPPoolRequest true "Pool name, region and CIDRs"
+// @Success 201 {object} IPPoolResponse
+// @Failure 400 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Router /admin/ip-pools [post]
+func (h *ipPoolHandler) CreatePool(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("POST /admin/ip-pools - CreatePool called")
+
+	var req packets.CreateIPPoolRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warnw("Invalid request body", "error", err)
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	pool, err := h.Service.CreatePool(r.Context(), req.Name, req.Region, req.CIDRs)
+	if err != nil {
+		respondIPPoolError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(toIPPoolResponse(pool)); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary List IP pools
+// @Description List IP pools with their CIDRs and address usage
+// @Tags admin
+// @Produce json
+// @Param region query string false "Only pools in this region"
+// @Success 200 {array} IPPoolResponse
+// @Failure 500 {object} errorResponse
+// @Router /admin/ip-pools [get]
+func (h *ipPoolHandler) ListPools(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("GET /admin/ip-pools - ListPools called")
+
+	pools, err := h.Service.ListPools(r.Context(), r.URL.Query().Get("region"))
+	if err != nil {
+		respondIPPoolError(w, r, err)
+		return
+	}
+	resp := make([]*packets.IPPoolResponse, 0, len(pools))
+	for _, p := range pools {
+		resp = append(resp, toIPPoolResponse(p))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary Get an IP pool
+// @Description Get an IP pool with its CIDRs and address usage
+// @Tags admin
+// @Produce json
+// @Param id path string true "Pool ID"
+// @Success 200 {object} IPPoolResponse
+// @Failure 404 {object} errorResponse
+// @Router /admin/ip-pools/{id} [get]
+func (h *ipPoolHandler) GetPool(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	id := chi.URLParam(r, "id")
+	log.Infow("GET /admin/ip-pools/{id} - GetPool called", "id", id)
+
+	pool, err := h.Service.GetPool(r.Context(), id)
+	if err != nil {
+		respondIPPoolError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toIPPoolResponse(pool)); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary Delete an IP pool
+// @Description Delete an IP pool and its addresses. Refused while any of its addresses is allocated; retire its CIDRs and wait for their servers to terminate first.
+// @Tags admin
+// @Param id path string true "Pool ID"
+// @Success 204
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Router /admin/ip-pools/{id} [delete]
+func (h *ipPoolHandler) DeletePool(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	id := chi.URLParam(r, "id")
+	log.Infow("DELETE /admin/ip-pools/{id} - DeletePool called", "id", id)
+
+	if err := h.Service.DeletePool(r.Context(), id); err != nil {
+		respondIPPoolError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Add a CIDR to an IP pool
+// @Description Add a CIDR to an IP pool, making its addresses available to the pool's region
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Pool ID"
+// @Param cidr body AddIPPoolCIDRRequest true "CIDR to add"
+// @Success 201 {object} IPPoolResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Router /admin/ip-pools/{id}/cidrs [post]
+func (h *ipPoolHandler) AddCIDR(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	id := chi.URLParam(r, "id")
+	log.Infow("POST /admin/ip-pools/{id}/cidrs - AddCIDR called", "id", id)
+
+	var req packets.AddIPPoolCIDRRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warnw("Invalid request body", "error", err)
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	pool, err := h.Service.AddCIDR(r.Context(), id, req.CIDR)
+	if err != nil {
+		respondIPPoolError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(toIPPoolResponse(pool)); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary Retire a CIDR
+// @Description Stop allocating addresses from a pool's CIDR. Servers holding its addresses keep them, and the CIDR stays in the pool so that no other pool can claim them.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Pool ID"
+// @Param cidrID path int true "CIDR ID"
+// @Success 200 {object} IPPoolResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Router /admin/ip-pools/{id}/cidrs/{cidrID} [delete]
+func (h *ipPoolHandler) RetireCIDR(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	id := chi.URLParam(r, "id")
+	log.Infow("DELETE /admin/ip-pools/{id}/cidrs/{cidrID} - RetireCIDR called", "id", id, "cidrID", chi.URLParam(r, "cidrID"))
+
+	cidrID, err := strconv.ParseUint(chi.URLParam(r, "cidrID"), 10, 0)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid CIDR ID")
+		return
+	}
+	pool, err := h.Service.RetireCIDR(r.Context(), id, uint(cidrID))
+	if err != nil {
+		respondIPPoolError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toIPPoolResponse(pool)); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+func respondIPPoolError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrIPPoolNotFound), errors.Is(err, service.ErrPoolCIDRNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidIPPool), errors.Is(err, service.ErrUnknownRegion), errors.Is(err, domain.ErrInvalidCIDR):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrIPPoolConflict), errors.Is(err, service.ErrIPPoolInUse):
+		respondError(w, http.StatusConflict, err.Error())
+	default:
+		logging.S(r.Context()).Errorw("IP pool request failed", "error", err)
+		respondError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+func toIPPoolResponse(p *persistence.IPPool) *packets.IPPoolResponse {
+	resp := &packets.IPPoolResponse{
+		ID:        p.ID,
+		Name:      p.Name,
+		Region:    p.Region,
+		CIDRs:     make([]*packets.IPPoolCIDRResponse, 0, len(p.CIDRs)),
+		CreatedAt: p.CreatedAt.Format(time.RFC3339),
+	}
+	for _, c := range p.CIDRs {
+		cidr := &packets.IPPoolCIDRResponse{
+			ID:        c.ID,
+			CIDR:      c.CIDR,
+			Addresses: c.Addresses,
+			Allocated: c.Allocated,
+			CreatedAt: c.CreatedAt.Format(time.RFC3339),
+		}
+		resp.Allocated += c.Allocated
+		if c.RetiredAt != nil {
+			retired := c.RetiredAt.Format(time.RFC3339)
+			cidr.RetiredAt = &retired
+		} else {
+			resp.Addresses += c.Addresses
+			resp.Available += c.Addresses - c.Allocated
+		}
+		resp.CIDRs = append(resp.CIDRs, cidr)
+	}
+	return resp
+}
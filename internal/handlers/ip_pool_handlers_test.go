@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/rhythin/sever-management/internal/service"
+	mockService "github.com/rhythin/sever-management/internal/service/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_ipPoolHandler_CreatePool(t *testing.T) {
+	retired := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	svc := &mockService.IPPoolService{}
+	svc.On("CreatePool", mock.Anything, "us-east", "us-east-1", []string{"10.1.0.0/24", "10.1.1.0/24"}).Return(&persistence.IPPool{
+		ID: "pool-1", Name: "us-east", Region: "us-east-1", CIDRs: []*persistence.IPPoolCIDR{
+			{ID: 1, CIDR: "10.1.0.0/24", Addresses: 254, Allocated: 4},
+			{ID: 2, CIDR: "10.1.1.0/24", Addresses: 254, Allocated: 1, RetiredAt: &retired},
+		},
+	}, nil)
+	svc.On("CreatePool", mock.Anything, "us-east", "us-east-1", []string{"10.1.0.1/24"}).
+		Return(nil, fmt.Errorf("%w: %w", service.ErrInvalidIPPool, domain.ErrInvalidCIDR))
+	svc.On("CreatePool", mock.Anything, "us-west", "us-east-1", []string(nil)).
+		Return(nil, fmt.Errorf("%w: name %q is taken", service.ErrIPPoolConflict, "us-west"))
+
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{name: "created", body: `{"name":"us-east","region":"us-east-1","cidrs":["10.1.0.0/24","10.1.1.0/24"]}`, code: http.StatusCreated},
+		{name: "invalid CIDR", body: `{"name":"us-east","region":"us-east-1","cidrs":["10.1.0.1/24"]}`, code: http.StatusBadRequest},
+		{name: "name taken", body: `{"name":"us-west","region":"us-east-1"}`, code: http.StatusConflict},
+		{name: "invalid body", body: `{`, code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			(&ipPoolHandler{Service: svc}).CreatePool(w, httptest.NewRequest("POST", "/admin/ip-pools", strings.NewReader(tt.body)))
+			if w.Code != tt.code {
+				t.Fatalf("CreatePool() code = %d, want %d", w.Code, tt.code)
+			}
+			if tt.code != http.StatusCreated {
+				return
+			}
+			var resp packets.IPPoolResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("CreatePool() returned invalid JSON: %v", err)
+			}
+			if resp.ID != "pool-1" || len(resp.CIDRs) != 2 || resp.CIDRs[1].RetiredAt == nil ||
+				resp.Addresses != 254 || resp.Allocated != 5 || resp.Available != 250 {
+				t.Errorf("CreatePool() = %+v", resp)
+			}
+		})
+	}
+}
+
+func Test_ipPoolHandler_RetireCIDR(t *testing.T) {
+	svc := &mockService.IPPoolService{}
+	svc.On("RetireCIDR", mock.Anything, "pool-1", uint(1)).Return(&persistence.IPPool{ID: "pool-1"}, nil)
+	svc.On("RetireCIDR", mock.Anything, "pool-1", uint(9)).Return(nil, service.ErrPoolCIDRNotFound)
+
+	tests := []struct {
+		name   string
+		cidrID string
+		code   int
+	}{
+		{name: "retired", cidrID: "1", code: http.StatusOK},
+		{name: "unknown CIDR", cidrID: "9", code: http.StatusNotFound},
+		{name: "invalid CIDR ID", cidrID: "10.1.0.0", code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("DELETE", "/admin/ip-pools/pool-1/cidrs/"+tt.cidrID, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "pool-1")
+			rctx.URLParams.Add("cidrID", tt.cidrID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			w := httptest.NewRecorder()
+			(&ipPoolHandler{Service: svc}).RetireCIDR(w, req)
+			if w.Code != tt.code {
+				t.Fatalf("RetireCIDR() code = %d, want %d", w.Code, tt.code)
+			}
+		})
+	}
+}
+
+func Test_ipPoolHandler_DeletePool(t *testing.T) {
+	svc := &mockService.IPPoolService{}
+	svc.On("DeletePool", mock.Anything, "idle").Return(nil)
+	svc.On("DeletePool", mock.Anything, "busy").Return(fmt.Errorf("%w: 3 in use", service.ErrIPPoolInUse))
+	svc.On("DeletePool", mock.Anything, "missing").Return(service.ErrIPPoolNotFound)
+
+	tests := []struct {
+		id   string
+		code int
+	}{
+		{id: "idle", code: http.StatusNoContent},
+		{id: "busy", code: http.StatusConflict},
+		{id: "missing", code: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			req := httptest.NewRequest("DELETE", "/admin/ip-pools/"+tt.id, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			w := httptest.NewRecorder()
+			(&ipPoolHandler{Service: svc}).DeletePool(w, req)
+			if w.Code != tt.code {
+				t.Fatalf("DeletePool() code = %d, want %d", w.Code, tt.code)
+			}
+		})
+	}
+}
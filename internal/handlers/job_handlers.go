@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/rhythin/sever-management/internal/service"
+)
+
+// jobHandler provides HTTP handlers for background jobs
+type jobHandler struct {
+	Service service.JobService
+}
+
+// @Summary List background jobs
+// @Description List the background jobs on this replica with their schedule and last run
+// @Tags admin
+// @Produce json
+// @Success 200 {array} JobResponse
+// @Failure 500 {object} errorResponse
+// @Router /admin/jobs [get]
+func (h *jobHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("GET /admin/jobs - ListJobs called")
+
+	jobs, err := h.Service.ListJobs(r.Context())
+	if err != nil {
+		respondJobError(w, r, err)
+		return
+	}
+	resp := make([]*packets.JobResponse, 0, len(jobs))
+	for _, j := range jobs {
+		job := &packets.JobResponse{
+			Name:     j.Name,
+			Interval: j.Interval.String(),
+			Timeout:  j.Timeout.String(),
+			Running:  j.Running,
+			Failures: j.Failures,
+		}
+		if !j.NextRunAt.IsZero() && !j.Running {
+			job.NextRunAt = j.NextRunAt.Format(time.RFC3339)
+		}
+		if j.LastRun != nil {
+			job.LastRun = toJobRunResponse(j.LastRun)
+		}
+		resp = append(resp, job)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary List job runs
+// @Description A background job's run history, newest first
+// @Tags admin
+// @Produce json
+// @Param name path string true "Job name"
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {array} JobRunResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Router /admin/jobs/{name}/runs [get]
+func (h *jobHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	name := chi.URLParam(r, "name")
+	log.Infow("GET /admin/jobs/{name}/runs - ListRuns called", "name", name)
+
+	limit := 20
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 1000 {
+			respondError(w, http.StatusBadRequest, "invalid limit: must be between 1 and 1000")
+			return
+		}
+		limit = n
+	}
+	runs, err := h.Service.ListRuns(r.Context(), name, limit)
+	if err != nil {
+		respondJobError(w, r, err)
+		return
+	}
+	resp := make([]*packets.JobRunResponse, 0, len(runs))
+	for _, run := range runs {
+		resp = append(resp, toJobRunResponse(run))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary Run a job now
+// @Description Queue a run of a background job on this replica, to start as soon as any current run ends. The run appears in the job's history.
+// @Tags admin
+// @Produce json
+// @Param name path string true "Job name"
+// @Success 202
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Router /admin/jobs/{name}/run [post]
+func (h *jobHandler) RunJob(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	name := chi.URLParam(r, "name")
+	log.Infow("POST /admin/jobs/{name}/run - RunJob called", "name", name)
+
+	if err := h.Service.Trigger(r.Context(), name); err != nil {
+		respondJobError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func respondJobError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrJobNotFound):
+		respondError(w, http.StatusNotFound, "job not found")
+	case errors.Is(err, service.ErrJobBusy):
+		respondError(w, http.StatusConflict, err.Error())
+	default:
+		logging.S(r.Context()).Errorw("Job request failed", "error", err)
+		respondError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+func toJobRunResponse(run *persistence.JobRun) *packets.JobRunResponse {
+	resp := &packets.JobRunResponse{
+		ID:        run.ID,
+		Job:       run.Job,
+		Trigger:   run.Trigger,
+		Status:    run.Status,
+		Error:     run.Error,
+		StartedAt: run.StartedAt.Format(time.RFC3339),
+	}
+	if run.FinishedAt != nil {
+		finished := run.FinishedAt.Format(time.RFC3339)
+		resp.FinishedAt = &finished
+		resp.Duration = run.FinishedAt.Sub(run.StartedAt).String()
+	}
+	return resp
+}
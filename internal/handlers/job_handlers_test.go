@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/rhythin/sever-management/internal/service"
+	mockService "github.com/rhythin/sever-management/internal/service/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_jobHandler_ListJobs(t *testing.T) {
+	started := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	finished := started.Add(1500 * time.Millisecond)
+	svc := &mockService.JobService{}
+	svc.On("ListJobs", mock.Anything).Return([]*service.JobStatus{
+		{Name: "billing", Interval: time.Minute, Timeout: 5 * time.Minute, Failures: 2, NextRunAt: started.Add(time.Minute), LastRun: &persistence.JobRun{
+			ID: 9, Job: "billing", Trigger: "schedule", Status: "failed", Error: "db error", StartedAt: started, FinishedAt: &finished,
+		}},
+		{Name: "reaper", Interval: 5 * time.Minute, Timeout: 5 * time.Minute, Running: true},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	(&jobHandler{Service: svc}).ListJobs(w, httptest.NewRequest("GET", "/admin/jobs", nil))
+
+	var resp []packets.JobResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("ListJobs() returned invalid JSON: %v", err)
+	}
+	if len(resp) != 2 || resp[0].Failures != 2 || resp[0].NextRunAt != "2026-04-01T12:01:00Z" || resp[0].LastRun == nil || resp[0].LastRun.Duration != "1.5s" {
+		t.Errorf("ListJobs() = %+v", resp)
+	}
+	if !resp[1].Running || resp[1].NextRunAt != "" || resp[1].LastRun != nil {
+		t.Errorf("ListJobs()[1] = %+v, want running with no next or last run", resp[1])
+	}
+}
+
+func Test_jobHandler_RunJob(t *testing.T) {
+	svc := &mockService.JobService{}
+	svc.On("Trigger", mock.Anything, "billing").Return(nil)
+	svc.On("Trigger", mock.Anything, "reaper").Return(service.ErrJobBusy)
+	svc.On("Trigger", mock.Anything, "unknown").Return(service.ErrJobNotFound)
+
+	for name, want := range map[string]int{"billing": http.StatusAccepted, "reaper": http.StatusConflict, "unknown": http.StatusNotFound} {
+		t.Run(name, func(t *testing.T) {
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("name", name)
+			r := httptest.NewRequest("POST", "/admin/jobs/"+name+"/run", nil)
+			w := httptest.NewRecorder()
+			(&jobHandler{Service: svc}).RunJob(w, r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx)))
+			if w.Code != want {
+				t.Errorf("RunJob() code = %d, want %d", w.Code, want)
+			}
+		})
+	}
+}
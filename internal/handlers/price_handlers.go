@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/rhythin/sever-management/internal/service"
+)
+
+// priceHandler provides HTTP handlers for the price book
+type priceHandler struct {
+	Service service.PriceService
+}
+
+// @Summary Add a price
+// @Description Schedule an hourly price for a server type, in one region or in all regions, taking effect at a future time. Uptime is billed at the price in effect when it ran.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param price body CreatePriceRequest true "Type, optional region, hourly price and effective time"
+// @Success 201 {object} PriceResponse
+// @Failure 400 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Router /admin/prices [post]
+func (h *priceHandler) CreatePrice(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("POST /admin/prices - CreatePrice called")
+
+	var req packets.CreatePriceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warnw("Invalid request body", "error", err)
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	hourly, err := domain.ParseMicros(req.HourlyPrice)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid hourly_price: must be a decimal with at most 6 places")
+		return
+	}
+	effectiveFrom, err := time.Parse(time.RFC3339, req.EffectiveFrom)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid effective_from: must be RFC3339")
+		return
+	}
+	price, err := h.Service.AddPrice(r.Context(), req.Type, req.Region, hourly, effectiveFrom)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPrice), errors.Is(err, service.ErrUnknownServerType), errors.Is(err, service.ErrUnknownRegion):
+			respondError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, persistence.ErrPriceExists):
+			respondError(w, http.StatusConflict, err.Error())
+		default:
+			log.Errorw("Failed to add price", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to add price")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(toPriceResponse(price)); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary List prices
+// @Description List price history, including scheduled prices, ordered by type, region and effective time
+// @Tags admin
+// @Produce json
+// @Param type query string false "Server type"
+// @Param region query string false "Region"
+// @Success 200 {array} PriceResponse
+// @Failure 500 {object} errorResponse
+// @Router /admin/prices [get]
+func (h *priceHandler) ListPrices(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("GET /admin/prices - ListPrices called")
+
+	prices, err := h.Service.ListPrices(r.Context(), r.URL.Query().Get("type"), r.URL.Query().Get("region"))
+	if err != nil {
+		log.Errorw("Failed to list prices", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to list prices")
+		return
+	}
+	resp := make([]*packets.PriceResponse, 0, len(prices))
+	for _, price := range prices {
+		resp = append(resp, toPriceResponse(price))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+func toPriceResponse(p *persistence.Price) *packets.PriceResponse {
+	return &packets.PriceResponse{
+		ID:            p.ID,
+		Type:          p.ServerType,
+		Region:        p.Region,
+		HourlyPrice:   p.HourlyPrice.String(),
+		Currency:      p.Currency,
+		EffectiveFrom: p.EffectiveFrom.Format(time.RFC3339),
+		CreatedAt:     p.CreatedAt.Format(time.RFC3339),
+	}
+}
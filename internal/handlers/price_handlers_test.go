@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/rhythin/sever-management/internal/service"
+	mockService "github.com/rhythin/sever-management/internal/service/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_priceHandler_CreatePrice(t *testing.T) {
+	effective := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc := &mockService.PriceService{}
+	svc.On("AddPrice", mock.Anything, "t2.micro", "", domain.Micros(12500), effective).
+		Return(&persistence.Price{ID: 4, ServerType: "t2.micro", HourlyPrice: 12500, Currency: "USD", EffectiveFrom: effective}, nil)
+	svc.On("AddPrice", mock.Anything, "t2.micro", "eu-west-1", domain.Micros(12500), effective).Return(nil, persistence.ErrPriceExists)
+	svc.On("AddPrice", mock.Anything, "t2.nano", "", domain.Micros(12500), effective).Return(nil, service.ErrUnknownServerType)
+	svc.On("AddPrice", mock.Anything, "t2.small", "", domain.Micros(12500), effective).Return(nil, errors.New("service layer error"))
+
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{name: "created", body: `{"type":"t2.micro","hourly_price":"0.0125","effective_from":"2030-01-01T00:00:00Z"}`, code: http.StatusCreated},
+		{name: "already scheduled", body: `{"type":"t2.micro","region":"eu-west-1","hourly_price":"0.0125","effective_from":"2030-01-01T00:00:00Z"}`, code: http.StatusConflict},
+		{name: "unknown type", body: `{"type":"t2.nano","hourly_price":"0.0125","effective_from":"2030-01-01T00:00:00Z"}`, code: http.StatusBadRequest},
+		{name: "service layer error", body: `{"type":"t2.small","hourly_price":"0.0125","effective_from":"2030-01-01T00:00:00Z"}`, code: http.StatusInternalServerError},
+		{name: "inexact price", body: `{"type":"t2.micro","hourly_price":"0.00000001","effective_from":"2030-01-01T00:00:00Z"}`, code: http.StatusBadRequest},
+		{name: "invalid effective_from", body: `{"type":"t2.micro","hourly_price":"0.0125","effective_from":"tomorrow"}`, code: http.StatusBadRequest},
+		{name: "invalid body", body: `{`, code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			(&priceHandler{Service: svc}).CreatePrice(w, httptest.NewRequest("POST", "/admin/prices", strings.NewReader(tt.body)))
+			if w.Code != tt.code {
+				t.Fatalf("CreatePrice() code = %d, want %d", w.Code, tt.code)
+			}
+			if tt.code != http.StatusCreated {
+				return
+			}
+			var resp packets.PriceResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("CreatePrice() returned invalid JSON: %v", err)
+			}
+			if resp.ID != 4 || resp.HourlyPrice != "0.012500" || resp.EffectiveFrom != "2030-01-01T00:00:00Z" {
+				t.Errorf("CreatePrice() = %+v", resp)
+			}
+		})
+	}
+}
+
+func Test_priceHandler_ListPrices(t *testing.T) {
+	svc := &mockService.PriceService{}
+	svc.On("ListPrices", mock.Anything, "t2.micro", "").Return([]*persistence.Price{
+		{ID: 1, ServerType: "t2.micro", HourlyPrice: 11600, EffectiveFrom: time.Unix(0, 0)},
+		{ID: 4, ServerType: "t2.micro", HourlyPrice: 12500, EffectiveFrom: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}, nil)
+	svc.On("ListPrices", mock.Anything, "t2.small", "").Return(nil, errors.New("service layer error"))
+
+	w := httptest.NewRecorder()
+	(&priceHandler{Service: svc}).ListPrices(w, httptest.NewRequest("GET", "/admin/prices?type=t2.micro", nil))
+	var resp []packets.PriceResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || w.Code != http.StatusOK || len(resp) != 2 {
+		t.Fatalf("ListPrices() = %d %+v (err %v), want 2 prices", w.Code, resp, err)
+	}
+
+	w = httptest.NewRecorder()
+	(&priceHandler{Service: svc}).ListPrices(w, httptest.NewRequest("GET", "/admin/prices?type=t2.small", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("ListPrices() code = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}
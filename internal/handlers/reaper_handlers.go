@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/rhythin/sever-management/internal/service"
+)
+
+// reaperHandler provides HTTP handlers for idle reaper policies
+type reaperHandler struct {
+	Service service.ReaperService
+}
+
+// @Summary Create a reaper policy
+// @Description Reap stopped servers in a region, of a type and/or matching a label selector after their own idle timeout. The most specific matching policy applies; dry-run policies only report what they would reap.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param policy body ReaperPolicyRequest true "Policy scope, idle timeout and mode"
+// @Success 201 {object} ReaperPolicyResponse
+// @Failure 400 {object} errorResponse
+// @Router /admin/reaper/policies [post]
+func (h *reaperHandler) CreatePolicy(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("POST /admin/reaper/policies - CreatePolicy called")
+
+	policy, ok := decodeReaperPolicy(w, r)
+	if !ok {
+		return
+	}
+	policy, err := h.Service.CreatePolicy(r.Context(), policy)
+	if err != nil {
+		respondReaperError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(toReaperPolicyResponse(policy)); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary List reaper policies
+// @Description List reaper policies
+// @Tags admin
+// @Produce json
+// @Success 200 {array} ReaperPolicyResponse
+// @Failure 500 {object} errorResponse
+// @Router /admin/reaper/policies [get]
+func (h *reaperHandler) ListPolicies(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("GET /admin/reaper/policies - ListPolicies called")
+
+	policies, err := h.Service.ListPolicies(r.Context())
+	if err != nil {
+		respondReaperError(w, r, err)
+		return
+	}
+	resp := make([]*packets.ReaperPolicyResponse, 0, len(policies))
+	for _, p := range policies {
+		resp = append(resp, toReaperPolicyResponse(p))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary Get a reaper policy
+// @Description Get a reaper policy
+// @Tags admin
+// @Produce json
+// @Param id path string true "Policy ID"
+// @Success 200 {object} ReaperPolicyResponse
+// @Failure 404 {object} errorResponse
+// @Router /admin/reaper/policies/{id} [get]
+func (h *reaperHandler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	id := chi.URLParam(r, "id")
+	log.Infow("GET /admin/reaper/policies/{id} - GetPolicy called", "id", id)
+
+	policy, err := h.Service.GetPolicy(r.Context(), id)
+	if err != nil {
+		respondReaperError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toReaperPolicyResponse(policy)); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary Replace a reaper policy
+// @Description Replace a reaper policy's name, scope, idle timeout and mode, e.g. to take it out of dry run
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Policy ID"
+// @Param policy body ReaperPolicyRequest true "Policy scope, idle timeout and mode"
+// @Success 200 {object} ReaperPolicyResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Router /admin/reaper/policies/{id} [put]
+func (h *reaperHandler) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	id := chi.URLParam(r, "id")
+	log.Infow("PUT /admin/reaper/policies/{id} - UpdatePolicy called", "id", id)
+
+	policy, ok := decodeReaperPolicy(w, r)
+	if !ok {
+		return
+	}
+	policy.ID = id
+	policy, err := h.Service.UpdatePolicy(r.Context(), policy)
+	if err != nil {
+		respondReaperError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toReaperPolicyResponse(policy)); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary Delete a reaper policy
+// @Description Delete a reaper policy; the servers it matched fall back to the next most specific policy
+// @Tags admin
+// @Param id path string true "Policy ID"
+// @Success 204
+// @Failure 404 {object} errorResponse
+// @Router /admin/reaper/policies/{id} [delete]
+func (h *reaperHandler) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	id := chi.URLParam(r, "id")
+	log.Infow("DELETE /admin/reaper/policies/{id} - DeletePolicy called", "id", id)
+
+	if err := h.Service.DeletePolicy(r.Context(), id); err != nil {
+		respondReaperError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Preview the idle reaper
+// @Description Report every stopped server a policy will reap, soonest first, with the policy that applies and when it is reaped. Due servers are reaped on the next run unless their policy is a dry run.
+// @Tags admin
+// @Produce json
+// @Param due query bool false "Only servers whose reap time has passed"
+// @Success 200 {array} ReapCandidateResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /admin/reaper/preview [get]
+func (h *reaperHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("GET /admin/reaper/preview - Preview called")
+
+	dueOnly := false
+	if v := r.URL.Query().Get("due"); v != "" {
+		var err error
+		if dueOnly, err = strconv.ParseBool(v); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid due: must be a boolean")
+			return
+		}
+	}
+	candidates, err := h.Service.Candidates(r.Context())
+	if err != nil {
+		respondReaperError(w, r, err)
+		return
+	}
+	now := time.Now()
+	resp := make([]*packets.ReapCandidateResponse, 0, len(candidates))
+	for _, c := range candidates {
+		due := !c.ReapAt.After(now)
+		if dueOnly && !due {
+			continue
+		}
+		resp = append(resp, &packets.ReapCandidateResponse{
+			ServerID:    c.Server.ID,
+			Region:      c.Server.Region,
+			Type:        c.Server.Type,
+			StoppedAt:   c.Server.StoppedAt.Format(time.RFC3339),
+			PolicyID:    c.PolicyID,
+			Policy:      c.PolicyName,
+			IdleTimeout: c.IdleTimeout.String(),
+			ReapAt:      c.ReapAt.Format(time.RFC3339),
+			Due:         due,
+			DryRun:      c.DryRun,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// decodeReaperPolicy reads a policy from the request body, responding 400 if it is malformed
+func decodeReaperPolicy(w http.ResponseWriter, r *http.Request) (*persistence.ReaperPolicy, bool) {
+	var req packets.ReaperPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.S(r.Context()).Warnw("Invalid request body", "error", err)
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return nil, false
+	}
+	timeout, err := time.ParseDuration(req.IdleTimeout)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid idle_timeout: must be a duration such as 72h")
+		return nil, false
+	}
+	return &persistence.ReaperPolicy{
+		Name:        req.Name,
+		Region:      req.Region,
+		Type:        req.Type,
+		Selector:    req.Selector,
+		IdleTimeout: timeout,
+		DryRun:      req.DryRun,
+	}, true
+}
+
+func respondReaperError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrReaperPolicyNotFound):
+		respondError(w, http.StatusNotFound, "reaper policy not found")
+	case errors.Is(err, service.ErrInvalidReaperPolicy), errors.Is(err, service.ErrUnknownRegion),
+		errors.Is(err, service.ErrUnknownServerType), errors.Is(err, domain.ErrInvalidLabel):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		logging.S(r.Context()).Errorw("Reaper request failed", "error", err)
+		respondError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+func toReaperPolicyResponse(p *persistence.ReaperPolicy) *packets.ReaperPolicyResponse {
+	return &packets.ReaperPolicyResponse{
+		ID:          p.ID,
+		Name:        p.Name,
+		Region:      p.Region,
+		Type:        p.Type,
+		Selector:    p.Selector,
+		IdleTimeout: p.IdleTimeout.String(),
+		DryRun:      p.DryRun,
+		CreatedAt:   p.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   p.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/rhythin/sever-management/internal/service"
+	mockService "github.com/rhythin/sever-management/internal/service/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_reaperHandler_CreatePolicy(t *testing.T) {
+	svc := &mockService.ReaperService{}
+	svc.On("CreatePolicy", mock.Anything, mock.MatchedBy(func(p *persistence.ReaperPolicy) bool { return p.Name == "dev" })).
+		Return(func(_ context.Context, p *persistence.ReaperPolicy) *persistence.ReaperPolicy {
+			created := *p
+			created.ID = "policy-1"
+			return &created
+		}, nil)
+	svc.On("CreatePolicy", mock.Anything, mock.MatchedBy(func(p *persistence.ReaperPolicy) bool { return p.Name == "" })).
+		Return(nil, fmt.Errorf("%w: name is required", service.ErrInvalidReaperPolicy))
+
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{name: "created", body: `{"name":"dev","selector":{"env":"dev"},"idle_timeout":"90m","dry_run":true}`, code: http.StatusCreated},
+		{name: "invalid policy", body: `{"idle_timeout":"1h"}`, code: http.StatusBadRequest},
+		{name: "invalid timeout", body: `{"name":"dev","idle_timeout":"soon"}`, code: http.StatusBadRequest},
+		{name: "invalid body", body: `{`, code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			(&reaperHandler{Service: svc}).CreatePolicy(w, httptest.NewRequest("POST", "/admin/reaper/policies", strings.NewReader(tt.body)))
+			if w.Code != tt.code {
+				t.Fatalf("CreatePolicy() code = %d, want %d", w.Code, tt.code)
+			}
+			if tt.code != http.StatusCreated {
+				return
+			}
+			var resp packets.ReaperPolicyResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("CreatePolicy() returned invalid JSON: %v", err)
+			}
+			if resp.ID != "policy-1" || resp.IdleTimeout != "1h30m0s" || !resp.DryRun || resp.Selector["env"] != "dev" {
+				t.Errorf("CreatePolicy() = %+v", resp)
+			}
+		})
+	}
+}
+
+func Test_reaperHandler_Preview(t *testing.T) {
+	stoppedAt := time.Now().Add(-2 * time.Hour)
+	svc := &mockService.ReaperService{}
+	svc.On("Candidates", mock.Anything).Return([]*service.ReapCandidate{
+		{Server: &persistence.Server{ID: "srv-1", StoppedAt: &stoppedAt}, PolicyName: service.DefaultReaperPolicy, IdleTimeout: time.Hour, ReapAt: stoppedAt.Add(time.Hour)},
+		{Server: &persistence.Server{ID: "srv-2", StoppedAt: &stoppedAt}, PolicyID: "policy-1", PolicyName: "prod", IdleTimeout: 72 * time.Hour, ReapAt: stoppedAt.Add(72 * time.Hour)},
+	}, nil)
+
+	tests := []struct {
+		name  string
+		query string
+		code  int
+		want  int
+	}{
+		{name: "all", code: http.StatusOK, want: 2},
+		{name: "due only", query: "?due=true", code: http.StatusOK, want: 1},
+		{name: "invalid due", query: "?due=soon", code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			(&reaperHandler{Service: svc}).Preview(w, httptest.NewRequest("GET", "/admin/reaper/preview"+tt.query, nil))
+			if w.Code != tt.code {
+				t.Fatalf("Preview() code = %d, want %d", w.Code, tt.code)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			var resp []packets.ReapCandidateResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Preview() returned invalid JSON: %v", err)
+			}
+			if len(resp) != tt.want || !resp[0].Due || resp[0].Policy != "default" {
+				t.Errorf("Preview() = %+v", resp)
+			}
+		})
+	}
+}
@@ -2,29 +2,34 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rhythin/sever-management/internal/domain"
 	"github.com/rhythin/sever-management/internal/logging"
 	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
 	"github.com/rhythin/sever-management/internal/service"
 )
 
 // ServerHandlers provides HTTP handlers for server endpoints
 type serverHandler struct {
 	Service service.ServerService
+	Reaper  service.ReaperService // reap_at of stopped servers and postponements
 }
 
 // @Summary Provision a new virtual server
-// @Description Provision a new virtual server
+// @Description Start provisioning a new virtual server; progress is tracked by the returned operation
 // @Tags servers
 // @Accept json
 // @Produce json
 // @Param server body ProvisionRequest true "Server spec"
-// @Success 201 {object} map[string]string
+// @Success 202 {object} ProvisionResponse
 // @Failure 400 {object} errorResponse
 // @Failure 409 {object} errorResponse
 // @Router /server [post]
@@ -41,9 +46,25 @@ func (h *serverHandler) ProvisionServer(w http.ResponseWriter, r *http.Request)
 		respondError(w, http.StatusBadRequest, "region and type are required")
 		return
 	}
-	id, err := h.Service.Provision(r.Context(), req.Region, req.Type)
+	op, err := h.Service.Provision(r.Context(), req.Region, req.Type, req.Labels)
 	if err != nil {
 		log.Errorw("Failed to provision server", "error", err)
+		if errors.Is(err, service.ErrUnknownServerType) {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown server type: %s", req.Type))
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidLabel) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrUnknownRegion) {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown region: %s", req.Region))
+			return
+		}
+		if errors.Is(err, service.ErrRegionUnavailable) || errors.Is(err, service.ErrRegionAtCapacity) {
+			respondError(w, http.StatusConflict, err.Error())
+			return
+		}
 		if err.Error() == "no available IPs" {
 			respondError(w, http.StatusConflict, "no available IPs")
 			return
@@ -51,9 +72,55 @@ func (h *serverHandler) ProvisionServer(w http.ResponseWriter, r *http.Request)
 		respondError(w, http.StatusInternalServerError, "failed to provision server")
 		return
 	}
-	log.Infow("Provisioned server", "id", id, "request", req)
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]string{"id": id})
+	log.Infow("Provisioning accepted", "id", op.ServerID, "operationID", op.ID, "request", req)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/operations/"+op.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(packets.ProvisionResponse{ID: op.ServerID, OperationID: op.ID})
+}
+
+// @Summary Get operation status
+// @Description Report the status of an asynchronous operation such as provisioning
+// @Tags operations
+// @Produce json
+// @Param id path string true "Operation ID"
+// @Success 200 {object} OperationResponse
+// @Failure 404 {object} errorResponse
+// @Router /operations/{id} [get]
+func (h *serverHandler) GetOperation(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	id := chi.URLParam(r, "id")
+	log.Infow("GET /operations/{id} - GetOperation called", "id", id)
+
+	op, err := h.Service.GetOperation(r.Context(), id)
+	if err != nil {
+		log.Errorw("Failed to fetch operation", "id", id, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to fetch operation")
+		return
+	}
+	if op == nil {
+		respondError(w, http.StatusNotFound, "operation not found")
+		return
+	}
+
+	resp := packets.OperationResponse{
+		ID:        op.ID,
+		Type:      op.Type,
+		ServerID:  op.ServerID,
+		Status:    op.Status,
+		Error:     op.Error,
+		CreatedAt: op.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: op.UpdatedAt.Format(time.RFC3339),
+	}
+	if op.CompletedAt != nil {
+		completed := op.CompletedAt.Format(time.RFC3339)
+		resp.CompletedAt = &completed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
 }
 
 // @Summary Get server metadata
@@ -62,6 +129,7 @@ func (h *serverHandler) ProvisionServer(w http.ResponseWriter, r *http.Request)
 // @Produce json
 // @Param id path string true "Server ID"
 // @Success 200 {object} ServerResponse
+// @Header 200 {string} ETag "Server version, for use in If-Match"
 // @Failure 404 {object} errorResponse
 // @Router /servers/{id} [get]
 func (h *serverHandler) GetServer(w http.ResponseWriter, r *http.Request) {
@@ -70,7 +138,7 @@ func (h *serverHandler) GetServer(w http.ResponseWriter, r *http.Request) {
 	log.Infow("GET /servers/{id} - GetServer called", "id", id)
 
 	server, err := h.Service.GetServerByID(r.Context(), id)
-	if err != nil {
+	if err != nil || server == nil {
 		log.Warnw("Server not found", "id", id, "error", err)
 		respondError(w, http.StatusNotFound, "server not found")
 		return
@@ -82,14 +150,41 @@ func (h *serverHandler) GetServer(w http.ResponseWriter, r *http.Request) {
 		Region: server.Region,
 		Type:   server.Type,
 		State:  server.State,
+		Labels: toLabelMap(server.Labels),
 	}
 
 	// Add IP address if available
 	if server.IP != nil {
 		resp.IPAddress = server.IP.Address
 	}
+	// Billing totals are derived from the usage ledger
+	if server.Billing != nil {
+		resp.Billing = &packets.BillingResponse{
+			AccumulatedSeconds: server.Billing.AccumulatedSeconds,
+			TotalCost:          server.Billing.TotalCost.String(),
+			Currency:           server.Billing.Currency,
+		}
+		if server.Billing.LastBilledAt != nil {
+			billed := server.Billing.LastBilledAt.Format(time.RFC3339)
+			resp.Billing.LastBilledAt = &billed
+		}
+	}
+	// Only stopped servers are reaped
+	if server.State == string(domain.ServerStopped) {
+		reapAt, err := h.Reaper.ReapAt(r.Context(), server)
+		if err != nil {
+			log.Errorw("Failed to compute reap time", "id", id, "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to get server")
+			return
+		}
+		if reapAt != nil {
+			at := reapAt.Format(time.RFC3339)
+			resp.ReapAt = &at
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(server.Version))
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		log.Errorw("Failed to encode response", "error", err)
 	}
@@ -102,8 +197,11 @@ func (h *serverHandler) GetServer(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path string true "Server ID"
 // @Param action body ActionRequest true "Action"
+// @Param If-Match header string false "ETag from GET /servers/{id}; the action is rejected if the server has changed since"
 // @Success 200 {object} ActionResponse
+// @Header 200 {string} ETag "Server version after the action"
 // @Failure 409 {object} errorResponse
+// @Failure 412 {object} errorResponse
 // @Failure 404 {object} errorResponse
 // @Router /servers/{id}/action [post]
 func (h *serverHandler) ServerAction(w http.ResponseWriter, r *http.Request) {
@@ -127,13 +225,29 @@ func (h *serverHandler) ServerAction(w http.ResponseWriter, r *http.Request) {
 	action := domain.ServerAction(req.Action)
 	if !domain.IsValidAction(action) {
 		log.Warnw("Invalid action", "action", req.Action)
-		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid action: %s, must be one of: start, stop, reboot, terminate", req.Action))
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid action: %s, must be one of: %s", req.Action, joinActions(domain.ClientActions())))
+		return
+	}
+
+	ifMatch, ok := parseIfMatch(r.Header.Get("If-Match"))
+	if !ok {
+		log.Warnw("Invalid If-Match header", "value", r.Header.Get("If-Match"))
+		respondError(w, http.StatusPreconditionFailed, "If-Match does not match the current server version")
 		return
 	}
 
-	if err := h.Service.Action(r.Context(), id, action); err != nil {
+	server, err := h.Service.Action(r.Context(), id, action, ifMatch)
+	if err != nil {
 		log.Errorw("Failed to perform action", "error", err, "server_id", id, "action", action)
-		if err.Error() == "invalid state transition" { // Check error message instead of type
+		if errors.Is(err, service.ErrServerNotFound) {
+			respondError(w, http.StatusNotFound, "server not found")
+			return
+		}
+		if errors.Is(err, service.ErrVersionMismatch) {
+			respondError(w, http.StatusPreconditionFailed, "If-Match does not match the current server version")
+			return
+		}
+		if err.Error() == "invalid state transition" || errors.Is(err, service.ErrRegionUnavailable) || errors.Is(err, service.ErrConcurrentUpdate) { // Check error message instead of type
 			respondError(w, http.StatusConflict, err.Error())
 			return
 		}
@@ -142,12 +256,66 @@ func (h *serverHandler) ServerAction(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(server.Version))
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(packets.ActionResponse{
 		Result: fmt.Sprintf("Action '%s' initiated successfully", action),
 	})
 }
 
+// @Summary Postpone reaping a server
+// @Description Move a stopped server's reap_at later by a duration, up to REAPER_MAX_POSTPONE from now
+// @Tags servers
+// @Accept json
+// @Produce json
+// @Param id path string true "Server ID"
+// @Param postponement body ReapPostponeRequest true "Postponement"
+// @Success 200 {object} ReapPostponeResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Router /servers/{id}/reap-postpone [post]
+func (h *serverHandler) PostponeReap(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	id := chi.URLParam(r, "id")
+	log.Infow("POST /servers/{id}/reap-postpone - PostponeReap called", "id", id)
+
+	var req packets.ReapPostponeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warnw("Invalid request body", "error", err)
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	by, err := time.ParseDuration(req.ExtendBy)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid extend_by: must be a duration such as 24h")
+		return
+	}
+
+	reapAt, err := h.Reaper.Postpone(r.Context(), id, by)
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrServerNotFound):
+		respondError(w, http.StatusNotFound, "server not found")
+		return
+	case errors.Is(err, service.ErrInvalidPostponement):
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, service.ErrNotReapable), errors.Is(err, service.ErrConcurrentUpdate):
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	default:
+		log.Errorw("Failed to postpone reaping", "id", id, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to postpone reaping")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(packets.ReapPostponeResponse{ServerID: id, ReapAt: reapAt.Format(time.RFC3339)}); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
 // @Summary List servers
 // @Description List all servers with optional filtering
 // @Tags servers
@@ -191,6 +359,7 @@ func (h *serverHandler) ListServers(w http.ResponseWriter, r *http.Request) {
 			Region: s.Region,
 			Type:   s.Type,
 			State:  s.State,
+			Labels: toLabelMap(s.Labels),
 		}
 
 		// Add IP address if available
@@ -207,37 +376,251 @@ func (h *serverHandler) ListServers(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// @Summary Get server usage
+// @Description Return a server's usage sessions, oldest first. Each session is a period of uptime from a start to a stop or termination; billing totals are their sum.
+// @Tags servers
+// @Produce json
+// @Param id path string true "Server ID"
+// @Success 200 {array} UsageSessionResponse
+// @Failure 404 {object} errorResponse
+// @Router /servers/{id}/usage [get]
+func (h *serverHandler) GetServerUsage(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	id := chi.URLParam(r, "id")
+	log.Infow("GET /servers/{id}/usage - GetServerUsage called", "id", id)
+
+	sessions, err := h.Service.ListUsage(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrServerNotFound) {
+			respondError(w, http.StatusNotFound, "server not found")
+			return
+		}
+		log.Errorw("Failed to list usage", "id", id, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to list usage")
+		return
+	}
+	resp := make([]packets.UsageSessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		u := packets.UsageSessionResponse{
+			ID:            session.ID,
+			Type:          session.Type,
+			StartedAt:     session.StartedAt.Format(time.RFC3339),
+			LastBilledAt:  session.LastBilledAt.Format(time.RFC3339),
+			BilledSeconds: session.BilledSeconds,
+			Cost:          session.Cost.String(),
+			Currency:      session.Currency,
+		}
+		if session.EndedAt != nil {
+			ended := session.EndedAt.Format(time.RFC3339)
+			u.EndedAt = &ended
+		}
+		resp = append(resp, u)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
 // @Summary Get server logs
-// @Description Return last 100 lifecycle events (ring buffer)
+// @Description Return a server's lifecycle events, newest first
 // @Tags servers
 // @Produce json
 // @Param id path string true "Server ID"
+// @Param type query string false "Comma-separated event types"
+// @Param since query string false "RFC3339 timestamp, inclusive"
+// @Param until query string false "RFC3339 timestamp, exclusive"
+// @Param limit query int false "Page size" default(100)
+// @Param cursor query string false "X-Next-Cursor from the previous page"
 // @Success 200 {array} eventLogResponse
+// @Header 200 {string} X-Next-Cursor "Cursor for the next page; absent on the last page"
+// @Failure 400 {object} errorResponse
 // @Failure 404 {object} errorResponse
 // @Router /servers/{id}/logs [get]
 func (h *serverHandler) GetServerLogs(w http.ResponseWriter, r *http.Request) {
 	log := logging.S(r.Context())
 	id := chi.URLParam(r, "id")
-	println("id", id)
 	log.Infow("GET /servers/{id}/logs - GetServerLogs called", "id", id)
-	events, err := h.Service.GetEvents(r.Context(), id, 100)
+	q, err := parseEventQuery(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	q.ServerID = id
+	events, next, err := h.Service.QueryEvents(r.Context(), q)
 	if err != nil {
 		log.Errorw("Failed to fetch logs for server", "id", id, "error", err)
 		respondError(w, http.StatusInternalServerError, "failed to fetch logs")
 		return
 	}
 	if len(events) == 0 {
-		log.Warnw("No logs found for server", "id", id)
-		respondError(w, http.StatusNotFound, "no logs found")
+		// An empty page is fine; an unknown server is not
+		server, err := h.Service.GetServerByID(r.Context(), id)
+		if err != nil || server == nil {
+			log.Warnw("No logs for unknown server", "id", id, "error", err)
+			respondError(w, http.StatusNotFound, "server not found")
+			return
+		}
+	}
+	respondEvents(w, events, next)
+}
+
+// @Summary List events
+// @Description Query lifecycle events across the fleet, newest first
+// @Tags events
+// @Produce json
+// @Param region query string false "Filter by server region"
+// @Param server_id query string false "Filter by server"
+// @Param type query string false "Comma-separated event types"
+// @Param since query string false "RFC3339 timestamp, inclusive"
+// @Param until query string false "RFC3339 timestamp, exclusive"
+// @Param limit query int false "Page size" default(100)
+// @Param cursor query string false "X-Next-Cursor from the previous page"
+// @Success 200 {array} eventLogResponse
+// @Header 200 {string} X-Next-Cursor "Cursor for the next page; absent on the last page"
+// @Failure 400 {object} errorResponse
+// @Router /events [get]
+func (h *serverHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("GET /events - ListEvents called", "query", r.URL.RawQuery)
+	q, err := parseEventQuery(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	q.Region = r.URL.Query().Get("region")
+	q.ServerID = r.URL.Query().Get("server_id")
+	events, next, err := h.Service.QueryEvents(r.Context(), q)
+	if err != nil {
+		log.Errorw("Failed to query events", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to query events")
+		return
+	}
+	respondEvents(w, events, next)
+}
+
+// parseEventQuery reads the type, since, until, limit and cursor parameters shared by the event endpoints
+func parseEventQuery(r *http.Request) (persistence.EventQuery, error) {
+	params := r.URL.Query()
+	q := persistence.EventQuery{Limit: 100}
+	types, err := parseEventTypes(params.Get("type"))
+	if err != nil {
+		return q, err
+	}
+	q.Types = types
+	for name, dst := range map[string]**time.Time{"since": &q.Since, "until": &q.Until} {
+		if v := params.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return q, fmt.Errorf("invalid %s: must be an RFC3339 timestamp", name)
+			}
+			*dst = &t
+		}
+	}
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > 1000 {
+			return q, errors.New("invalid limit: must be between 1 and 1000")
+		}
+		q.Limit = limit
+	}
+	if v := params.Get("cursor"); v != "" {
+		cursor, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || cursor == 0 {
+			return q, errors.New("invalid cursor")
+		}
+		q.BeforeID = uint(cursor)
+	}
+	return q, nil
+}
+
+// parseEventTypes splits a comma-separated type filter, rejecting unknown event types
+func parseEventTypes(v string) ([]string, error) {
+	if v == "" {
+		return nil, nil
+	}
+	var types []string
+	for _, t := range strings.Split(v, ",") {
+		t = strings.TrimSpace(t)
+		if !domain.IsValidEventType(domain.EventType(t)) {
+			return nil, fmt.Errorf("invalid event type: %s", t)
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+func toEventLogResponse(e persistence.EventLog) *packets.EventLogResponse {
+	return &packets.EventLogResponse{
+		Sequence:  e.Sequence,
+		ServerID:  e.ServerID,
+		Timestamp: e.Timestamp.Format("2006-01-02T15:04:05Z07:00"),
+		Type:      e.Type,
+		Message:   e.Message,
+		FromState: e.FromState,
+		ToState:   e.ToState,
+		Action:    e.Action,
+		Cause:     e.Cause,
+		RequestID: e.RequestID,
+	}
+}
+
+func respondEvents(w http.ResponseWriter, events []persistence.EventLog, next uint) {
 	resp := make([]*packets.EventLogResponse, 0, len(events))
 	for _, e := range events {
-		resp = append(resp, &packets.EventLogResponse{Timestamp: e.Timestamp.Format("2006-01-02T15:04:05Z07:00"), Type: e.Type, Message: e.Message})
+		resp = append(resp, toEventLogResponse(e))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if next != 0 {
+		w.Header().Set("X-Next-Cursor", strconv.FormatUint(uint64(next), 10))
 	}
 	json.NewEncoder(w).Encode(resp)
 }
 
+// toLabelMap converts label rows to a map, or nil when there are none
+func toLabelMap(labels []*persistence.ServerLabel) map[string]string {
+	if len(labels) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(labels))
+	for _, l := range labels {
+		m[l.Key] = l.Value
+	}
+	return m
+}
+
+// etag renders a server version as a strong entity tag
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// parseIfMatch extracts the version from an If-Match header. An absent header or "*"
+// yields 0, meaning no precondition; ok is false if the header cannot be parsed.
+func parseIfMatch(header string) (version int64, ok bool) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	unquoted, err := strconv.Unquote(header) // weak tags never match
+	if err != nil {
+		return 0, false
+	}
+	version, err = strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+func joinActions(actions []domain.ServerAction) string {
+	names := make([]string, 0, len(actions))
+	for _, a := range actions {
+		names = append(names, string(a))
+	}
+	return strings.Join(names, ", ")
+}
+
 type errorResponse struct {
 	Error string `json:"error"`
 }
@@ -247,3 +630,18 @@ func respondError(w http.ResponseWriter, code int, msg string) {
 	w.WriteHeader(code)
 	json.NewEncoder(w).Encode(errorResponse{Error: msg})
 }
+
+// wantsCSV reports whether a report should be exported as CSV, requested with format=csv or
+// an Accept header of text/csv; JSON is the default
+func wantsCSV(r *http.Request) (bool, error) {
+	switch r.URL.Query().Get("format") {
+	case "csv":
+		return true, nil
+	case "json":
+		return false, nil
+	case "":
+		return strings.Contains(r.Header.Get("Accept"), "text/csv"), nil
+	default:
+		return false, errors.New("invalid format: must be json or csv")
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rhythin/sever-management/internal/domain"
@@ -37,9 +39,11 @@ func GenerateProvisionServerRequest(region string, body interface{}) *http.Reque
 
 func Test_serverHandler_ProvisionServer(t *testing.T) {
 	mockService := &mockService.ServerService{}
-	mockService.On("Provision", mock.Anything, "1", "type").Return("1", nil)
-	mockService.On("Provision", mock.Anything, "2", "type").Return("1", errors.New("service layer error"))
-	mockService.On("Provision", mock.Anything, "3", "type").Return("", errors.New("no available IPs"))
+	mockService.On("Provision", mock.Anything, "1", "type", (map[string]string)(nil)).Return(&persistence.Operation{ID: "op-1", ServerID: "1"}, nil)
+	mockService.On("Provision", mock.Anything, "2", "type", (map[string]string)(nil)).Return(nil, errors.New("service layer error"))
+	mockService.On("Provision", mock.Anything, "3", "type", (map[string]string)(nil)).Return(nil, errors.New("no available IPs"))
+	mockService.On("Provision", mock.Anything, "4", "bogus", (map[string]string)(nil)).Return(nil, service.ErrUnknownServerType)
+	mockService.On("Provision", mock.Anything, "5", "type", map[string]string{"Team": "x"}).Return(nil, fmt.Errorf("%w: key %q", domain.ErrInvalidLabel, "Team"))
 	type fields struct {
 		Service service.ServerService
 	}
@@ -92,6 +96,26 @@ func Test_serverHandler_ProvisionServer(t *testing.T) {
 				Service: mockService,
 			},
 		},
+		{
+			name: "ProvisionServer unknown type",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: GenerateProvisionServerRequest("region", packets.ProvisionRequest{Region: "4", Type: "bogus"}),
+			},
+			fields: fields{
+				Service: mockService,
+			},
+		},
+		{
+			name: "ProvisionServer invalid labels",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: GenerateProvisionServerRequest("region", packets.ProvisionRequest{Region: "5", Type: "type", Labels: map[string]string{"Team": "x"}}),
+			},
+			fields: fields{
+				Service: mockService,
+			},
+		},
 		{
 			name: "ProvisionServer no available IPs",
 			args: args{
@@ -133,12 +157,14 @@ func Test_serverHandler_GetServer(t *testing.T) {
 
 	mockService := &mockService.ServerService{}
 	mockService.On("GetServerByID", mock.Anything, "1").Return(&persistence.Server{
-		ID:     "1",
-		Region: "region",
-		Type:   "type",
-		State:  "state",
-		IP:     &persistence.IPAddress{Address: "127.0.0.1"},
+		ID:      "1",
+		Region:  "region",
+		Type:    "type",
+		State:   "state",
+		IP:      &persistence.IPAddress{Address: "127.0.0.1"},
+		Billing: &persistence.Billing{AccumulatedSeconds: 3600, TotalCost: 11600, Currency: "USD"},
 	}, nil)
+	mockService.On("GetServerByID", mock.Anything, "3").Return(nil, nil)
 	mockService.On("GetServerByID", mock.Anything, "2").Return(nil, errors.New("service layer error"))
 	type fields struct {
 		Service service.ServerService
@@ -173,6 +199,16 @@ func Test_serverHandler_GetServer(t *testing.T) {
 				Service: mockService,
 			},
 		},
+		{
+			name: "GetServer not found",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: GenerateGetServerRequest("3"),
+			},
+			fields: fields{
+				Service: mockService,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -208,10 +244,10 @@ func GenerateServerActionRequestGenerator(id string, body interface{}) *http.Req
 func Test_serverHandler_ServerAction(t *testing.T) {
 
 	mockService := &mockService.ServerService{}
-	mockService.On("Action", mock.Anything, "1", domain.ServerAction("start")).Return(nil)
-	mockService.On("Action", mock.Anything, "2", domain.ServerAction("start")).Return(errors.New("service layer error"))
-	mockService.On("Action", mock.Anything, "3", domain.ServerAction("start")).Return(errors.New("invalid state transition"))
-	mockService.On("Action", mock.Anything, "4", domain.ServerAction("start")).Return(nil)
+	mockService.On("Action", mock.Anything, "1", domain.ServerAction("start"), int64(0)).Return(&persistence.Server{ID: "1", State: "running", Version: 2}, nil)
+	mockService.On("Action", mock.Anything, "2", domain.ServerAction("start"), int64(0)).Return(nil, errors.New("service layer error"))
+	mockService.On("Action", mock.Anything, "3", domain.ServerAction("start"), int64(0)).Return(nil, errors.New("invalid state transition"))
+	mockService.On("Action", mock.Anything, "4", domain.ServerAction("start"), int64(0)).Return(&persistence.Server{ID: "4", State: "running", Version: 2}, nil)
 	type fields struct {
 		Service service.ServerService
 	}
@@ -296,6 +332,100 @@ func Test_serverHandler_ServerAction(t *testing.T) {
 	}
 }
 
+func Test_serverHandler_ServerAction_IfMatch(t *testing.T) {
+	svc := &mockService.ServerService{}
+	svc.On("Action", mock.Anything, "1", domain.ActionStop, int64(3)).Return(&persistence.Server{ID: "1", State: "stopped", Version: 4}, nil)
+	svc.On("Action", mock.Anything, "1", domain.ActionStop, int64(2)).Return(nil, service.ErrVersionMismatch)
+	svc.On("Action", mock.Anything, "1", domain.ActionStop, int64(0)).Return(nil, service.ErrConcurrentUpdate)
+
+	tests := []struct {
+		name     string
+		ifMatch  string
+		wantCode int
+		wantETag string
+	}{
+		{name: "matching version", ifMatch: `"3"`, wantCode: http.StatusOK, wantETag: `"4"`},
+		{name: "stale version", ifMatch: `"2"`, wantCode: http.StatusPreconditionFailed},
+		{name: "weak tag never matches", ifMatch: `W/"3"`, wantCode: http.StatusPreconditionFailed},
+		{name: "malformed tag", ifMatch: "3", wantCode: http.StatusPreconditionFailed},
+		{name: "lost race without precondition", wantCode: http.StatusConflict},
+		{name: "wildcard is no precondition", ifMatch: "*", wantCode: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := GenerateServerActionRequestGenerator("1", packets.ActionRequest{Action: "stop"})
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			(&serverHandler{Service: svc}).ServerAction(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("ServerAction() code = %d, want %d", w.Code, tt.wantCode)
+			}
+			if got := w.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("ServerAction() ETag = %q, want %q", got, tt.wantETag)
+			}
+		})
+	}
+}
+
+func Test_serverHandler_GetServer_ReapAt(t *testing.T) {
+	reapAt := time.Date(2026, 4, 2, 12, 0, 0, 0, time.UTC)
+	stopped := &persistence.Server{ID: "1", State: "stopped", Version: 2}
+	svc := &mockService.ServerService{}
+	svc.On("GetServerByID", mock.Anything, "1").Return(stopped, nil)
+	reaper := &mockService.ReaperService{}
+	reaper.On("ReapAt", mock.Anything, stopped).Return(&reapAt, nil)
+
+	w := httptest.NewRecorder()
+	(&serverHandler{Service: svc, Reaper: reaper}).GetServer(w, GenerateGetServerRequest("1"))
+
+	var resp packets.ServerResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("GetServer() body: %v", err)
+	}
+	if resp.ReapAt == nil || *resp.ReapAt != "2026-04-02T12:00:00Z" {
+		t.Errorf("GetServer() reap_at = %v, want 2026-04-02T12:00:00Z", resp.ReapAt)
+	}
+}
+
+func Test_serverHandler_PostponeReap(t *testing.T) {
+	reapAt := time.Date(2026, 4, 3, 12, 0, 0, 0, time.UTC)
+	reaper := &mockService.ReaperService{}
+	reaper.On("Postpone", mock.Anything, "1", 24*time.Hour).Return(reapAt, nil)
+	reaper.On("Postpone", mock.Anything, "1", 1000*time.Hour).Return(time.Time{}, fmt.Errorf("%w: too far", service.ErrInvalidPostponement))
+	reaper.On("Postpone", mock.Anything, "running", mock.Anything).Return(time.Time{}, service.ErrNotReapable)
+	reaper.On("Postpone", mock.Anything, "missing", mock.Anything).Return(time.Time{}, service.ErrServerNotFound)
+
+	tests := []struct {
+		name     string
+		id       string
+		body     string
+		wantCode int
+	}{
+		{name: "postponed", id: "1", body: `{"extend_by":"24h"}`, wantCode: http.StatusOK},
+		{name: "too far", id: "1", body: `{"extend_by":"1000h"}`, wantCode: http.StatusBadRequest},
+		{name: "invalid duration", id: "1", body: `{"extend_by":"a day"}`, wantCode: http.StatusBadRequest},
+		{name: "not reapable", id: "running", body: `{"extend_by":"1h"}`, wantCode: http.StatusConflict},
+		{name: "unknown server", id: "missing", body: `{"extend_by":"1h"}`, wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			r := httptest.NewRequest("POST", "/servers/"+tt.id+"/reap-postpone", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			(&serverHandler{Reaper: reaper}).PostponeReap(w, r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx)))
+			if w.Code != tt.wantCode {
+				t.Errorf("PostponeReap() code = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusOK && !strings.Contains(w.Body.String(), `"reap_at":"2026-04-03T12:00:00Z"`) {
+				t.Errorf("PostponeReap() body = %s", w.Body.String())
+			}
+		})
+	}
+}
+
 func GetServerRequestGenerator(region string, serverType string, status string, limit int, offset int) *http.Request {
 
 	return httptest.NewRequest("GET", "/servers?region="+region+"&type="+serverType+"&status="+status+"&limit="+strconv.Itoa(limit)+"&offset="+strconv.Itoa(offset), nil)
@@ -373,12 +503,16 @@ func Test_serverHandler_ListServers(t *testing.T) {
 	}
 }
 
-func GetLogsRequestGenerator(id string) *http.Request {
+func GetLogsRequestGenerator(id string, query ...string) *http.Request {
 
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", id)
 
-	req, err := http.NewRequest("GET", "/servers/"+id+"/logs", nil)
+	url := "/servers/" + id + "/logs"
+	if len(query) > 0 {
+		url += "?" + query[0]
+	}
+	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil
 	}
@@ -392,60 +526,166 @@ func GetLogsRequestGenerator(id string) *http.Request {
 func Test_serverHandler_GetServerLogs(t *testing.T) {
 
 	mockService := &mockService.ServerService{}
-	mockService.On("GetEvents", mock.Anything, "1", 100).Return([]persistence.EventLog{{}, {}}, nil)
-	mockService.On("GetEvents", mock.Anything, "2", 100).Return(nil, errors.New("service layer error"))
-	mockService.On("GetEvents", mock.Anything, "3", 100).Return([]persistence.EventLog{}, nil)
+	mockService.On("QueryEvents", mock.Anything, persistence.EventQuery{ServerID: "1", Limit: 100}).Return([]persistence.EventLog{{ID: 9}, {ID: 8}}, uint(8), nil)
+	mockService.On("QueryEvents", mock.Anything, persistence.EventQuery{ServerID: "2", Limit: 100}).Return(nil, uint(0), errors.New("service layer error"))
+	mockService.On("QueryEvents", mock.Anything, persistence.EventQuery{ServerID: "3", Limit: 100}).Return([]persistence.EventLog{}, uint(0), nil)
+	mockService.On("QueryEvents", mock.Anything, persistence.EventQuery{ServerID: "4", Limit: 100}).Return([]persistence.EventLog{}, uint(0), nil)
+	mockService.On("QueryEvents", mock.Anything, persistence.EventQuery{ServerID: "1", Types: []string{"stopped", "terminated"}, Limit: 5, BeforeID: 8}).Return([]persistence.EventLog{{ID: 7}}, uint(0), nil)
+	mockService.On("GetServerByID", mock.Anything, "3").Return(&persistence.Server{ID: "3"}, nil)
+	mockService.On("GetServerByID", mock.Anything, "4").Return(nil, nil)
 
-	type fields struct {
-		Service service.ServerService
+	tests := []struct {
+		name     string
+		r        *http.Request
+		wantCode int
+		wantNext string
+	}{
+		{name: "GetServerLogs success", r: GetLogsRequestGenerator("1"), wantCode: http.StatusOK, wantNext: "8"},
+		{name: "GetServerLogs error service Layer", r: GetLogsRequestGenerator("2"), wantCode: http.StatusInternalServerError},
+		{name: "GetServerLogs no logs yet", r: GetLogsRequestGenerator("3"), wantCode: http.StatusOK},
+		{name: "GetServerLogs unknown server", r: GetLogsRequestGenerator("4"), wantCode: http.StatusNotFound},
+		{name: "GetServerLogs filtered next page", r: GetLogsRequestGenerator("1", "type=stopped,terminated&limit=5&cursor=8"), wantCode: http.StatusOK},
+		{name: "GetServerLogs invalid type", r: GetLogsRequestGenerator("1", "type=exploded"), wantCode: http.StatusBadRequest},
+		{name: "GetServerLogs invalid since", r: GetLogsRequestGenerator("1", "since=yesterday"), wantCode: http.StatusBadRequest},
+		{name: "GetServerLogs invalid cursor", r: GetLogsRequestGenerator("1", "cursor=abc"), wantCode: http.StatusBadRequest},
 	}
-	type args struct {
-		w http.ResponseWriter
-		r *http.Request
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &serverHandler{
+				Service: mockService,
+			}
+			w := httptest.NewRecorder()
+			h.GetServerLogs(w, tt.r)
+			if w.Code != tt.wantCode {
+				t.Errorf("GetServerLogs() code = %d, want %d", w.Code, tt.wantCode)
+			}
+			if got := w.Header().Get("X-Next-Cursor"); got != tt.wantNext {
+				t.Errorf("GetServerLogs() X-Next-Cursor = %q, want %q", got, tt.wantNext)
+			}
+		})
 	}
+}
+
+func Test_serverHandler_ListEvents(t *testing.T) {
+	since := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	mockService := &mockService.ServerService{}
+	mockService.On("QueryEvents", mock.Anything, persistence.EventQuery{Region: "eu-west-1", Types: []string{"terminated"}, Since: &since, Limit: 100}).
+		Return([]persistence.EventLog{{ID: 3, ServerID: "srv-1", Type: "terminated"}}, uint(0), nil)
+	mockService.On("QueryEvents", mock.Anything, persistence.EventQuery{ServerID: "srv-2", Limit: 100}).Return(nil, uint(0), errors.New("service layer error"))
+
 	tests := []struct {
-		name   string
-		fields fields
-		args   args
+		name     string
+		query    string
+		wantCode int
+		wantLen  int
 	}{
-		// TODO: Add test cases.
-		{
-			name: "GetServerLogs success",
-			args: args{
-				w: httptest.NewRecorder(),
-				r: GetLogsRequestGenerator("1"),
-			},
-			fields: fields{
-				Service: mockService,
-			},
-		},
-		{
-			name: "GetServerLogs error service Layer",
-			args: args{
-				w: httptest.NewRecorder(),
-				r: GetLogsRequestGenerator("2"),
-			},
-			fields: fields{
-				Service: mockService,
-			},
-		},
-		{
-			name: "GetServerLogs no logs found",
-			args: args{
-				w: httptest.NewRecorder(),
-				r: GetLogsRequestGenerator("3"),
-			},
-			fields: fields{
-				Service: mockService,
-			},
-		},
+		{name: "terminations in a region since a time", query: "region=eu-west-1&type=terminated&since=2025-01-01T12:00:00Z", wantCode: http.StatusOK, wantLen: 1},
+		{name: "service layer error", query: "server_id=srv-2", wantCode: http.StatusInternalServerError},
+		{name: "limit out of range", query: "limit=5000", wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			(&serverHandler{Service: mockService}).ListEvents(w, httptest.NewRequest("GET", "/events?"+tt.query, nil))
+			if w.Code != tt.wantCode {
+				t.Errorf("ListEvents() code = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var resp []packets.EventLogResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || len(resp) != tt.wantLen {
+				t.Errorf("ListEvents() = %+v (err %v), want %d events", resp, err, tt.wantLen)
+			}
+		})
+	}
+}
+
+func GetOperationRequestGenerator(id string) *http.Request {
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+
+	req := httptest.NewRequest("GET", "/operations/"+id, nil)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func Test_serverHandler_GetOperation(t *testing.T) {
+
+	mockService := &mockService.ServerService{}
+	mockService.On("GetOperation", mock.Anything, "1").Return(&persistence.Operation{ID: "1", ServerID: "srv", Status: "failed", Error: "boom"}, nil)
+	mockService.On("GetOperation", mock.Anything, "2").Return(nil, errors.New("service layer error"))
+	mockService.On("GetOperation", mock.Anything, "3").Return(nil, nil)
+
+	tests := []struct {
+		name     string
+		id       string
+		wantCode int
+	}{
+		{name: "GetOperation success", id: "1", wantCode: http.StatusOK},
+		{name: "GetOperation error service Layer", id: "2", wantCode: http.StatusInternalServerError},
+		{name: "GetOperation not found", id: "3", wantCode: http.StatusNotFound},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			h := &serverHandler{
-				Service: tt.fields.Service,
+				Service: mockService,
+			}
+			w := httptest.NewRecorder()
+			h.GetOperation(w, GetOperationRequestGenerator(tt.id))
+			if w.Code != tt.wantCode {
+				t.Errorf("GetOperation() code = %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func Test_serverHandler_GetServerUsage(t *testing.T) {
+	started := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	ended := started.Add(time.Hour)
+	mockService := &mockService.ServerService{}
+	mockService.On("ListUsage", mock.Anything, "1").Return([]*persistence.UsageSession{
+		{ID: 1, Type: "t2.micro", StartedAt: started, EndedAt: &ended, LastBilledAt: ended, BilledSeconds: 3600, Cost: 11600, Currency: "USD"},
+		{ID: 2, Type: "t2.micro", StartedAt: ended.Add(time.Hour), LastBilledAt: ended.Add(2 * time.Hour), BilledSeconds: 3600, Cost: 11600, Currency: "USD"},
+	}, nil)
+	mockService.On("ListUsage", mock.Anything, "2").Return(nil, errors.New("service layer error"))
+	mockService.On("ListUsage", mock.Anything, "3").Return(nil, service.ErrServerNotFound)
+
+	tests := []struct {
+		name      string
+		id        string
+		wantCode  int
+		wantEnded []bool
+	}{
+		{name: "GetServerUsage success", id: "1", wantCode: http.StatusOK, wantEnded: []bool{true, false}},
+		{name: "GetServerUsage error service Layer", id: "2", wantCode: http.StatusInternalServerError},
+		{name: "GetServerUsage unknown server", id: "3", wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			r := httptest.NewRequest("GET", "/servers/"+tt.id+"/usage", nil)
+			w := httptest.NewRecorder()
+			(&serverHandler{Service: mockService}).GetServerUsage(w, r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx)))
+			if w.Code != tt.wantCode {
+				t.Errorf("GetServerUsage() code = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var resp []packets.UsageSessionResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || len(resp) != len(tt.wantEnded) {
+				t.Fatalf("GetServerUsage() = %+v (err %v), want %d sessions", resp, err, len(tt.wantEnded))
+			}
+			for i, u := range resp {
+				if u.Cost != "0.011600" || u.Currency != "USD" {
+					t.Errorf("GetServerUsage() session %d cost = %s %s, want 0.011600 USD", u.ID, u.Cost, u.Currency)
+				}
+				if (u.EndedAt != nil) != tt.wantEnded[i] {
+					t.Errorf("GetServerUsage() session %d ended_at = %v, want ended %v", u.ID, u.EndedAt, tt.wantEnded[i])
+				}
 			}
-			h.GetServerLogs(tt.args.w, tt.args.r)
 		})
 	}
 }
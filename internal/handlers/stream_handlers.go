@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/rhythin/sever-management/internal/service"
+)
+
+// replayPageSize bounds each query when replaying events missed since Last-Event-ID
+const replayPageSize = 500
+
+// streamHandler serves Server-Sent Events streams of lifecycle events
+type streamHandler struct {
+	Service   service.ServerService
+	KeepAlive time.Duration
+}
+
+// @Summary Stream server events
+// @Description Push a server's lifecycle events as Server-Sent Events. Send Last-Event-ID (or last_event_id) to resume.
+// @Tags servers
+// @Produce text/event-stream
+// @Param id path string true "Server ID"
+// @Param type query string false "Comma-separated event types"
+// @Param Last-Event-ID header string false "ID of the last event received"
+// @Success 200 {string} string "event stream"
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Router /servers/{id}/events/stream [get]
+func (h *streamHandler) StreamServerEvents(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	id := chi.URLParam(r, "id")
+	log.Infow("GET /servers/{id}/events/stream - StreamServerEvents called", "id", id)
+	types, err := parseEventTypes(r.URL.Query().Get("type"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	server, err := h.Service.GetServerByID(r.Context(), id)
+	if err != nil || server == nil {
+		respondError(w, http.StatusNotFound, "server not found")
+		return
+	}
+	h.stream(w, r, persistence.EventQuery{ServerID: id, Types: types})
+}
+
+// @Summary Stream events
+// @Description Push lifecycle events across the fleet as Server-Sent Events. Send Last-Event-ID (or last_event_id) to resume.
+// @Tags events
+// @Produce text/event-stream
+// @Param region query string false "Filter by server region"
+// @Param server_id query string false "Filter by server"
+// @Param type query string false "Comma-separated event types"
+// @Param Last-Event-ID header string false "ID of the last event received"
+// @Success 200 {string} string "event stream"
+// @Failure 400 {object} errorResponse
+// @Router /events/stream [get]
+func (h *streamHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("GET /events/stream - StreamEvents called", "query", r.URL.RawQuery)
+	types, err := parseEventTypes(r.URL.Query().Get("type"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.stream(w, r, persistence.EventQuery{
+		Region:   r.URL.Query().Get("region"),
+		ServerID: r.URL.Query().Get("server_id"),
+		Types:    types,
+	})
+}
+
+// stream subscribes before replaying missed events so that nothing committed in between is lost.
+// Live events that the replay already sent are skipped; every other live event is forwarded,
+// whatever its ID, because event IDs are taken before commit and a transaction that took a
+// lower ID can commit after one that took a higher ID.
+func (h *streamHandler) stream(w http.ResponseWriter, r *http.Request, q persistence.EventQuery) {
+	log := logging.S(r.Context())
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+	lastID, err := parseLastEventID(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sub := h.Service.SubscribeEvents(q)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	replayed := make(map[uint]struct{})
+	for after := lastID; after > 0; {
+		page := q
+		page.AfterID, page.Limit = after, replayPageSize
+		events, _, err := h.Service.QueryEvents(r.Context(), page)
+		if err != nil {
+			log.Errorw("Failed to replay events", "afterID", after, "error", err)
+			return
+		}
+		for _, e := range events {
+			if err := writeSSE(w, e); err != nil {
+				return
+			}
+			replayed[e.ID] = struct{}{}
+			lastID = e.ID
+		}
+		flusher.Flush()
+		if len(events) < replayPageSize {
+			break
+		}
+		after = lastID
+	}
+
+	keepAlive := time.NewTicker(h.keepAlive())
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				// Dropped for falling behind; the client reconnects with Last-Event-ID
+				log.Warnw("Event stream subscriber fell behind; closing stream", "lastEventID", lastID)
+				return
+			}
+			if _, ok := replayed[e.ID]; ok {
+				delete(replayed, e.ID)
+				continue
+			}
+			if err := writeSSE(w, e.EventLog); err != nil {
+				return
+			}
+			lastID = e.ID
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (h *streamHandler) keepAlive() time.Duration {
+	if h.KeepAlive > 0 {
+		return h.KeepAlive
+	}
+	return 15 * time.Second
+}
+
+// parseLastEventID reads the resume point from the Last-Event-ID header, or the
+// last_event_id query parameter for clients that cannot set headers
+func parseLastEventID(r *http.Request) (uint, error) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("last_event_id")
+	}
+	if v == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Last-Event-ID: %s", v)
+	}
+	return uint(id), nil
+}
+
+func writeSSE(w http.ResponseWriter, e persistence.EventLog) error {
+	data, err := json.Marshal(toEventLogResponse(e))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", e.ID, data)
+	return err
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/rhythin/sever-management/internal/service"
+	mockService "github.com/rhythin/sever-management/internal/service/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func newStreamServer(svc service.ServerService) *httptest.Server {
+	h := &streamHandler{Service: svc, KeepAlive: time.Minute}
+	r := chi.NewRouter()
+	r.Get("/events/stream", h.StreamEvents)
+	r.Get("/servers/{id}/events/stream", h.StreamServerEvents)
+	return httptest.NewServer(r)
+}
+
+// readEventIDs reads n SSE frames and returns their id fields
+func readEventIDs(t *testing.T, sc *bufio.Scanner, n int) []string {
+	t.Helper()
+	var ids []string
+	for len(ids) < n && sc.Scan() {
+		if id, ok := strings.CutPrefix(sc.Text(), "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) < n {
+		t.Fatalf("stream ended after %d events, want %d", len(ids), n)
+	}
+	return ids
+}
+
+func Test_streamHandler_StreamEvents(t *testing.T) {
+	bus := service.NewEventBus(&internal.Config{})
+	svc := &mockService.ServerService{}
+	subscribed := make(chan struct{})
+	svc.On("SubscribeEvents", persistence.EventQuery{Region: "eu-west-1"}).Return(func(q persistence.EventQuery) *service.EventSubscription {
+		defer close(subscribed)
+		return bus.Subscribe(q)
+	})
+	// Replay everything after event 5; event 7 then also arrives live and must not repeat
+	svc.On("QueryEvents", mock.Anything, persistence.EventQuery{Region: "eu-west-1", AfterID: 5, Limit: replayPageSize}).
+		Return([]persistence.EventLog{{ID: 6, Type: "stopped"}, {ID: 7, Type: "started"}}, uint(0), nil)
+
+	srv := newStreamServer(svc)
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL+"/events/stream?region=eu-west-1", nil)
+	req.Header.Set("Last-Event-ID", "5")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /events/stream error = %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /events/stream status = %d, want 200", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	<-subscribed
+	bus.Publish("eu-west-1", &persistence.EventLog{ID: 7, Type: "started"}, &persistence.EventLog{ID: 8, Type: "stopped"})
+	bus.Publish("us-east-1", &persistence.EventLog{ID: 9, Type: "stopped"})
+	bus.Publish("eu-west-1", &persistence.EventLog{ID: 10, Type: "rebooted"})
+	// Event 4 took its ID before 6 but committed after it was replayed; it is still delivered
+	bus.Publish("eu-west-1", &persistence.EventLog{ID: 4, Type: "stopped"})
+
+	got := readEventIDs(t, bufio.NewScanner(resp.Body), 5)
+	if want := []string{"6", "7", "8", "10", "4"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("stream ids = %v, want %v", got, want)
+	}
+}
+
+func Test_streamHandler_StreamServerEvents_Errors(t *testing.T) {
+	svc := &mockService.ServerService{}
+	svc.On("GetServerByID", mock.Anything, "missing").Return(nil, nil)
+
+	srv := newStreamServer(svc)
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		code   int
+	}{
+		{name: "unknown server", path: "/servers/missing/events/stream", code: http.StatusNotFound},
+		{name: "unknown type", path: "/servers/missing/events/stream?type=bogus", code: http.StatusBadRequest},
+		{name: "bad Last-Event-ID", path: "/events/stream", header: "abc", code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", srv.URL+tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Last-Event-ID", tt.header)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("GET %s error = %v", tt.path, err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.code {
+				t.Errorf("GET %s status = %d, want %d", tt.path, resp.StatusCode, tt.code)
+			}
+		})
+	}
+}
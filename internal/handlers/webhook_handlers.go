@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/rhythin/sever-management/internal/service"
+)
+
+// webhookHandler provides HTTP handlers for webhook subscriptions
+type webhookHandler struct {
+	Service service.WebhookService
+}
+
+// @Summary Register a webhook
+// @Description Register a URL to receive server events as signed JSON POSTs. The response carries the signing secret, which is not shown again.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body CreateWebhookRequest true "Webhook URL and event types"
+// @Success 201 {object} WebhookResponse
+// @Failure 400 {object} errorResponse
+// @Router /webhooks [post]
+func (h *webhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("POST /webhooks - CreateWebhook called")
+
+	var req packets.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warnw("Invalid request body", "error", err)
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	hook, err := h.Service.Register(r.Context(), req.URL, req.Types)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWebhook) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Errorw("Failed to register webhook", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to register webhook")
+		return
+	}
+	resp := toWebhookResponse(hook)
+	resp.Secret = hook.Secret
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary List webhooks
+// @Description List registered webhooks
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} WebhookResponse
+// @Failure 500 {object} errorResponse
+// @Router /webhooks [get]
+func (h *webhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	log.Infow("GET /webhooks - ListWebhooks called")
+
+	hooks, err := h.Service.List(r.Context())
+	if err != nil {
+		log.Errorw("Failed to list webhooks", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to list webhooks")
+		return
+	}
+	resp := make([]*packets.WebhookResponse, 0, len(hooks))
+	for _, hook := range hooks {
+		resp = append(resp, toWebhookResponse(hook))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary Get a webhook
+// @Description Get a webhook's URL and event types
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} WebhookResponse
+// @Failure 404 {object} errorResponse
+// @Router /webhooks/{id} [get]
+func (h *webhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	id := chi.URLParam(r, "id")
+	log.Infow("GET /webhooks/{id} - GetWebhook called", "id", id)
+
+	hook, err := h.Service.Get(r.Context(), id)
+	if err != nil {
+		respondWebhookError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toWebhookResponse(hook)); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+// @Summary Delete a webhook
+// @Description Delete a webhook and its delivery history; pending deliveries are dropped
+// @Tags webhooks
+// @Param id path string true "Webhook ID"
+// @Success 204
+// @Failure 404 {object} errorResponse
+// @Router /webhooks/{id} [delete]
+func (h *webhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	id := chi.URLParam(r, "id")
+	log.Infow("DELETE /webhooks/{id} - DeleteWebhook called", "id", id)
+
+	if err := h.Service.Delete(r.Context(), id); err != nil {
+		respondWebhookError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary List webhook deliveries
+// @Description List a webhook's most recent deliveries, newest first, with attempts, status and the last error
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param limit query int false "Page size" default(100)
+// @Success 200 {array} WebhookDeliveryResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Router /webhooks/{id}/deliveries [get]
+func (h *webhookHandler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	log := logging.S(r.Context())
+	id := chi.URLParam(r, "id")
+	log.Infow("GET /webhooks/{id}/deliveries - ListWebhookDeliveries called", "id", id)
+
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 1000 {
+			respondError(w, http.StatusBadRequest, "invalid limit: must be between 1 and 1000")
+			return
+		}
+		limit = n
+	}
+	deliveries, err := h.Service.ListDeliveries(r.Context(), id, limit)
+	if err != nil {
+		respondWebhookError(w, r, err)
+		return
+	}
+	resp := make([]*packets.WebhookDeliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		resp = append(resp, toWebhookDeliveryResponse(d))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorw("Failed to encode response", "error", err)
+	}
+}
+
+func respondWebhookError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		respondError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+	logging.S(r.Context()).Errorw("Webhook request failed", "error", err)
+	respondError(w, http.StatusInternalServerError, "internal error")
+}
+
+func toWebhookResponse(hook *persistence.Webhook) *packets.WebhookResponse {
+	types := service.WebhookEventTypes(hook)
+	if types == nil {
+		types = []string{}
+	}
+	return &packets.WebhookResponse{
+		ID:        hook.ID,
+		URL:       hook.URL,
+		Types:     types,
+		CreatedAt: hook.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func toWebhookDeliveryResponse(d *persistence.WebhookDelivery) *packets.WebhookDeliveryResponse {
+	resp := &packets.WebhookDeliveryResponse{
+		ID:             d.ID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+	}
+	if d.Status == string(domain.DeliveryPending) {
+		next := d.NextAttemptAt.Format(time.RFC3339)
+		resp.NextAttemptAt = &next
+	}
+	if d.DeliveredAt != nil {
+		delivered := d.DeliveredAt.Format(time.RFC3339)
+		resp.DeliveredAt = &delivered
+	}
+	return resp
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"github.com/rhythin/sever-management/internal/service"
+	mockService "github.com/rhythin/sever-management/internal/service/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_webhookHandler_CreateWebhook(t *testing.T) {
+	svc := &mockService.WebhookService{}
+	svc.On("Register", mock.Anything, "https://example.com/hook", []string{"stopped"}).
+		Return(&persistence.Webhook{ID: "hook-1", URL: "https://example.com/hook", EventTypes: "stopped", Secret: "abc"}, nil)
+	svc.On("Register", mock.Anything, "not a url", []string(nil)).
+		Return(nil, fmt.Errorf("%w: url must be an absolute http or https URL", service.ErrInvalidWebhook))
+
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{name: "created", body: `{"url":"https://example.com/hook","types":["stopped"]}`, code: http.StatusCreated},
+		{name: "invalid webhook", body: `{"url":"not a url"}`, code: http.StatusBadRequest},
+		{name: "invalid body", body: `{`, code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			(&webhookHandler{Service: svc}).CreateWebhook(w, httptest.NewRequest("POST", "/webhooks", strings.NewReader(tt.body)))
+			if w.Code != tt.code {
+				t.Fatalf("CreateWebhook() code = %d, want %d", w.Code, tt.code)
+			}
+			if tt.code != http.StatusCreated {
+				return
+			}
+			var resp packets.WebhookResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("CreateWebhook() returned invalid JSON: %v", err)
+			}
+			if resp.ID != "hook-1" || resp.Secret != "abc" || len(resp.Types) != 1 {
+				t.Errorf("CreateWebhook() = %+v", resp)
+			}
+		})
+	}
+}
+
+func Test_webhookHandler_ListWebhookDeliveries(t *testing.T) {
+	delivered := time.Now()
+	svc := &mockService.WebhookService{}
+	svc.On("ListDeliveries", mock.Anything, "hook-1", 100).Return([]*persistence.WebhookDelivery{
+		{ID: 2, EventID: 11, EventType: "stopped", Status: "pending", Attempts: 1, ResponseStatus: 500, LastError: "receiver responded with status 500", NextAttemptAt: delivered},
+		{ID: 1, EventID: 10, EventType: "started", Status: "succeeded", Attempts: 1, ResponseStatus: 200, DeliveredAt: &delivered},
+	}, nil)
+	svc.On("ListDeliveries", mock.Anything, "missing", 100).Return(nil, service.ErrWebhookNotFound)
+
+	tests := []struct {
+		name  string
+		id    string
+		query string
+		code  int
+		want  int
+	}{
+		{name: "history", id: "hook-1", code: http.StatusOK, want: 2},
+		{name: "unknown webhook", id: "missing", code: http.StatusNotFound},
+		{name: "invalid limit", id: "hook-1", query: "?limit=0", code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/webhooks/"+tt.id+"/deliveries"+tt.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			w := httptest.NewRecorder()
+			(&webhookHandler{Service: svc}).ListWebhookDeliveries(w, req)
+			if w.Code != tt.code {
+				t.Fatalf("ListWebhookDeliveries() code = %d, want %d", w.Code, tt.code)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			var resp []packets.WebhookDeliveryResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("ListWebhookDeliveries() returned invalid JSON: %v", err)
+			}
+			if len(resp) != tt.want || resp[0].NextAttemptAt == nil || resp[1].DeliveredAt == nil || resp[1].NextAttemptAt != nil {
+				t.Errorf("ListWebhookDeliveries() = %+v", resp)
+			}
+		})
+	}
+}
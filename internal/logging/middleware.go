@@ -30,6 +30,12 @@ func RequestIDFromContext(ctx context.Context) string {
 	return "unknown"
 }
 
+// WithRequestID returns a context carrying the given request ID, so that background
+// work started by a request can be attributed to it
+func WithRequestID(ctx context.Context, reqID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, reqID)
+}
+
 // S returns a sugared logger with request_id field if present in context
 func S(ctx context.Context) *zap.SugaredLogger {
 	reqID := RequestIDFromContext(ctx)
@@ -33,3 +33,10 @@ func TestRequestIDMiddleware(t *testing.T) {
         t.Error("X-Request-Id header not set")
     }
 }
+
+func TestWithRequestID(t *testing.T) {
+    ctx := WithRequestID(context.Background(), "req-1")
+    if id := RequestIDFromContext(ctx); id != "req-1" {
+        t.Errorf("Expected 'req-1', got %s", id)
+    }
+}
@@ -3,7 +3,10 @@ package metrics
 import (
 	"net/http"
 	"sync/atomic"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
@@ -17,6 +20,46 @@ func DecRunningServers()       { atomic.AddInt64(&runningServers, -1) }
 func GetTotalServers() int64   { return atomic.LoadInt64(&totalServers) }
 func GetRunningServers() int64 { return atomic.LoadInt64(&runningServers) }
 
+var (
+	billingLeaseHeld = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "billing_lease_held",
+		Help: "1 if this replica holds the billing lease and runs billing, 0 otherwise.",
+	})
+	billingLastSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "billing_last_success_timestamp_seconds",
+		Help: "Unix time at which this replica last billed every open usage session without error.",
+	})
+	jobRunDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "job_run_duration_seconds",
+		Help:    "Duration of background job runs.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 10), // 10ms to ~45m
+	}, []string{"job"})
+	jobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "job_runs_total",
+		Help: "Background job runs by result: success, failure or skipped.",
+	}, []string{"job", "result"})
+)
+
+// SetBillingLeaseHeld records whether this replica holds the billing lease
+func SetBillingLeaseHeld(held bool) {
+	if held {
+		billingLeaseHeld.Set(1)
+	} else {
+		billingLeaseHeld.Set(0)
+	}
+}
+
+// SetBillingLastSuccess records the time of the last complete billing run
+func SetBillingLastSuccess(t time.Time) {
+	billingLastSuccess.Set(float64(t.Unix()))
+}
+
+// ObserveJobRun records the duration and result of a background job run
+func ObserveJobRun(job, result string, d time.Duration) {
+	jobRunDuration.WithLabelValues(job).Observe(d.Seconds())
+	jobRuns.WithLabelValues(job, result).Inc()
+}
+
 // NewMetricsHandler returns a handler for Prometheus metrics
 func NewMetricsHandler() http.Handler {
 	return promhttp.Handler()
@@ -3,25 +3,51 @@ package packets
 import "github.com/rhythin/sever-management/internal/domain"
 
 type ProvisionRequest struct {
-	Region string `json:"region"`
-	Type   string `json:"type"`
+	Region string            `json:"region"`
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels,omitempty"` // e.g. {"team": "payments"}, for grouping costs
 }
 type ProvisionResponse struct {
-	ID string `json:"id"`
+	ID          string `json:"id"`
+	OperationID string `json:"operation_id"`
+}
+type OperationResponse struct {
+	ID          string  `json:"id"`
+	Type        string  `json:"type"`
+	ServerID    string  `json:"server_id"`
+	Status      string  `json:"status"`
+	Error       string  `json:"error,omitempty"`
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   string  `json:"updated_at"`
+	CompletedAt *string `json:"completed_at,omitempty"`
 }
 type ServerResponse struct {
-	ID        string           `json:"id"`
-	State     string           `json:"state"`
-	Region    string           `json:"region,omitempty"`
-	Type      string           `json:"type,omitempty"`
-	Billing   *BillingResponse `json:"billing,omitempty"`
-	IPAddress string           `json:"ip_address,omitempty"`
+	ID        string            `json:"id"`
+	State     string            `json:"state"`
+	Region    string            `json:"region,omitempty"`
+	Type      string            `json:"type,omitempty"`
+	Billing   *BillingResponse  `json:"billing,omitempty"`
+	IPAddress string            `json:"ip_address,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	ReapAt    *string           `json:"reap_at,omitempty"` // when the idle reaper terminates a stopped server
 }
 
 type BillingResponse struct {
 	AccumulatedSeconds int64   `json:"accumulated_seconds"`
 	LastBilledAt       *string `json:"last_billed_at,omitempty"`
-	TotalCost          float64 `json:"total_cost"`
+	TotalCost          string  `json:"total_cost"` // exact decimal, e.g. "0.011600"
+	Currency           string  `json:"currency"`
+}
+
+type UsageSessionResponse struct {
+	ID            uint    `json:"id"`
+	Type          string  `json:"type"`
+	StartedAt     string  `json:"started_at"`
+	EndedAt       *string `json:"ended_at,omitempty"` // absent while the session is open
+	LastBilledAt  string  `json:"last_billed_at"`
+	BilledSeconds int64   `json:"billed_seconds"`
+	Cost          string  `json:"cost"` // exact decimal
+	Currency      string  `json:"currency"`
 }
 
 type ActionRequest struct {
@@ -30,8 +56,286 @@ type ActionRequest struct {
 type ActionResponse struct {
 	Result string `json:"result"`
 }
+
+type ReapPostponeRequest struct {
+	ExtendBy string `json:"extend_by"` // added to the current reap_at, e.g. 24h
+}
+
+type ReapPostponeResponse struct {
+	ServerID string `json:"server_id"`
+	ReapAt   string `json:"reap_at"`
+}
 type EventLogResponse struct {
+	Sequence  int64  `json:"sequence"`
+	ServerID  string `json:"server_id"`
 	Timestamp string `json:"timestamp"`
 	Type      string `json:"type"`
 	Message   string `json:"message"`
+	FromState string `json:"from_state,omitempty"`
+	ToState   string `json:"to_state,omitempty"`
+	Action    string `json:"action,omitempty"`
+	Cause     string `json:"cause,omitempty"` // on terminated events: user or reaper
+	RequestID string `json:"request_id,omitempty"`
+}
+
+type ServerTypeResponse struct {
+	Name        string `json:"name"`
+	VCPU        int    `json:"vcpu"`
+	MemoryMiB   int    `json:"memory_mib"`
+	DiskGiB     int    `json:"disk_gib"`
+	HourlyPrice string `json:"hourly_price"` // exact decimal
+	BootTime    string `json:"boot_time"`
+}
+
+type RegionResponse struct {
+	Name          string `json:"name"`
+	Status        string `json:"status"`
+	MaxServers    int    `json:"max_servers"`
+	ActiveServers int64  `json:"active_servers"`
+	Available     int64  `json:"available"`
+}
+
+type UpdateRegionRequest struct {
+	Status     string `json:"status"` // one of enabled|draining|disabled
+	MaxServers int    `json:"max_servers"`
+}
+
+type FSMResponse struct {
+	InitialState   string               `json:"initial_state"`
+	States         []string             `json:"states"`
+	TerminalStates []string             `json:"terminal_states"`
+	Actions        []string             `json:"actions"`
+	Transitions    []TransitionResponse `json:"transitions"`
+}
+type TransitionResponse struct {
+	From     string   `json:"from"`
+	Action   string   `json:"action"`
+	To       string   `json:"to"`
+	Events   []string `json:"events"`
+	Sets     string   `json:"sets,omitempty"`
+	Internal bool     `json:"internal"`
+}
+
+type CreateWebhookRequest struct {
+	URL   string   `json:"url"`
+	Types []string `json:"types,omitempty"` // event types to deliver; empty delivers every type
+}
+type WebhookResponse struct {
+	ID        string   `json:"id"`
+	URL       string   `json:"url"`
+	Types     []string `json:"types"`
+	Secret    string   `json:"secret,omitempty"` // only returned when the webhook is created
+	CreatedAt string   `json:"created_at"`
+}
+type WebhookDeliveryResponse struct {
+	ID             uint    `json:"id"`
+	EventID        uint    `json:"event_id"`
+	EventType      string  `json:"event_type"`
+	Status         string  `json:"status"`
+	Attempts       int     `json:"attempts"`
+	ResponseStatus int     `json:"response_status,omitempty"`
+	LastError      string  `json:"last_error,omitempty"`
+	NextAttemptAt  *string `json:"next_attempt_at,omitempty"`
+	CreatedAt      string  `json:"created_at"`
+	DeliveredAt    *string `json:"delivered_at,omitempty"`
+}
+
+type CreatePriceRequest struct {
+	Type          string `json:"type"`
+	Region        string `json:"region,omitempty"` // empty prices the type in every region without a regional price
+	HourlyPrice   string `json:"hourly_price"`     // exact decimal, e.g. "0.0125"
+	EffectiveFrom string `json:"effective_from"`   // RFC3339, in the future
+}
+type PriceResponse struct {
+	ID            uint   `json:"id"`
+	Type          string `json:"type"`
+	Region        string `json:"region,omitempty"`
+	HourlyPrice   string `json:"hourly_price"`
+	Currency      string `json:"currency"`
+	EffectiveFrom string `json:"effective_from"`
+	CreatedAt     string `json:"created_at"`
+}
+
+type ReaperPolicyRequest struct {
+	Name        string            `json:"name"`
+	Region      string            `json:"region,omitempty"`   // empty for all regions
+	Type        string            `json:"type,omitempty"`     // empty for all types
+	Selector    map[string]string `json:"selector,omitempty"` // labels servers must all have
+	IdleTimeout string            `json:"idle_timeout"`       // Go duration, e.g. "72h"
+	DryRun      bool              `json:"dry_run,omitempty"`  // report matches without reaping them
+}
+type ReaperPolicyResponse struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Region      string            `json:"region,omitempty"`
+	Type        string            `json:"type,omitempty"`
+	Selector    map[string]string `json:"selector,omitempty"`
+	IdleTimeout string            `json:"idle_timeout"`
+	DryRun      bool              `json:"dry_run"`
+	CreatedAt   string            `json:"created_at"`
+	UpdatedAt   string            `json:"updated_at"`
+}
+type ReapCandidateResponse struct {
+	ServerID    string `json:"server_id"`
+	Region      string `json:"region"`
+	Type        string `json:"type"`
+	StoppedAt   string `json:"stopped_at"`
+	PolicyID    string `json:"policy_id,omitempty"` // empty for the IDLE_TIMEOUT default
+	Policy      string `json:"policy"`
+	IdleTimeout string `json:"idle_timeout"`
+	ReapAt      string `json:"reap_at"`
+	Due         bool   `json:"due"`     // reaped on the next run unless dry_run
+	DryRun      bool   `json:"dry_run"` // only reported, never reaped
+}
+
+type JobResponse struct {
+	Name      string          `json:"name"`
+	Interval  string          `json:"interval"`
+	Timeout   string          `json:"timeout"`
+	Running   bool            `json:"running"`
+	Failures  int             `json:"consecutive_failures"`
+	NextRunAt string          `json:"next_run_at,omitempty"`
+	LastRun   *JobRunResponse `json:"last_run,omitempty"`
+}
+
+type JobRunResponse struct {
+	ID         uint    `json:"id"`
+	Job        string  `json:"job"`
+	Trigger    string  `json:"trigger"` // schedule or manual
+	Status     string  `json:"status"`  // running, succeeded, failed or skipped
+	Error      string  `json:"error,omitempty"`
+	StartedAt  string  `json:"started_at"`
+	FinishedAt *string `json:"finished_at,omitempty"`
+	Duration   string  `json:"duration,omitempty"`
+}
+
+type CreateIPPoolRequest struct {
+	Name   string   `json:"name"`
+	Region string   `json:"region"`          // fixed for the pool's lifetime
+	CIDRs  []string `json:"cidrs,omitempty"` // IPv4, /16 or smaller, overlapping no other pool's
+}
+
+type AddIPPoolCIDRRequest struct {
+	CIDR string `json:"cidr"`
+}
+
+type IPPoolResponse struct {
+	ID        string                `json:"id"`
+	Name      string                `json:"name"`
+	Region    string                `json:"region"`
+	CIDRs     []*IPPoolCIDRResponse `json:"cidrs"`
+	Addresses int64                 `json:"addresses"` // usable addresses in active CIDRs
+	Allocated int64                 `json:"allocated"` // in use, including in retired CIDRs
+	Available int64                 `json:"available"` // unallocated in active CIDRs, quarantined ones included
+	CreatedAt string                `json:"created_at"`
+}
+
+type IPPoolCIDRResponse struct {
+	ID        uint    `json:"id"`
+	CIDR      string  `json:"cidr"`
+	Addresses int64   `json:"addresses"`
+	Allocated int64   `json:"allocated"`
+	RetiredAt *string `json:"retired_at,omitempty"` // no longer allocated from
+	CreatedAt string  `json:"created_at"`
+}
+
+type CreateBudgetRequest struct {
+	Name         string                   `json:"name"`
+	Region       string                   `json:"region,omitempty"`   // empty for all regions
+	Selector     map[string]string        `json:"selector,omitempty"` // labels servers must all have; empty for every server
+	MonthlyLimit string                   `json:"monthly_limit"`      // exact decimal in the billing currency
+	Thresholds   []BudgetThresholdRequest `json:"thresholds"`
+}
+type BudgetThresholdRequest struct {
+	Percent   int    `json:"percent"`
+	Action    string `json:"action"`               // event, webhook or stop
+	WebhookID string `json:"webhook_id,omitempty"` // for the webhook action
+}
+type BudgetResponse struct {
+	ID           string                    `json:"id"`
+	Name         string                    `json:"name"`
+	Region       string                    `json:"region,omitempty"`
+	Selector     map[string]string         `json:"selector,omitempty"`
+	MonthlyLimit string                    `json:"monthly_limit"`
+	Currency     string                    `json:"currency"`
+	Thresholds   []*BudgetThresholdRequest `json:"thresholds"`
+	CreatedAt    string                    `json:"created_at"`
+	// Only on GET /budgets/{id}: this month's spend and the thresholds it has fired
+	Period string                 `json:"period,omitempty"`
+	Spend  string                 `json:"spend,omitempty"`
+	Alerts []*BudgetAlertResponse `json:"alerts,omitempty"`
+}
+type BudgetAlertResponse struct {
+	Percent int    `json:"percent"`
+	Action  string `json:"action"`
+	Spend   string `json:"spend"` // when the threshold fired
+	FiredAt string `json:"fired_at"`
+}
+
+type BillingSummaryResponse struct {
+	From    string                 `json:"from"`
+	To      string                 `json:"to"`
+	GroupBy []string               `json:"group_by"`
+	Groups  []*BillingSummaryGroup `json:"groups"`
+}
+type BillingSummaryGroup struct {
+	Key           map[string]string `json:"key"` // value of each group_by dimension; "" for servers without a grouped label
+	Currency      string            `json:"currency"`
+	Servers       int64             `json:"servers"`
+	UptimeSeconds int64             `json:"uptime_seconds"`
+	Cost          string            `json:"cost"` // exact decimal
+}
+
+type BillingForecastResponse struct {
+	From    string                   `json:"from"` // now; servers' unbilled uptime before it is included
+	Until   string                   `json:"until"`
+	Totals  []*BillingForecastGroup  `json:"totals"` // one per currency
+	Regions []*BillingForecastGroup  `json:"regions"`
+	Types   []*BillingForecastGroup  `json:"types"`
+	Servers []*BillingForecastServer `json:"servers"`
+}
+type BillingForecastGroup struct {
+	Key           string `json:"key,omitempty"` // region or type
+	Currency      string `json:"currency"`
+	Servers       int64  `json:"servers"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+	Cost          string `json:"cost"` // exact decimal
+}
+type BillingForecastServer struct {
+	ServerID      string `json:"server_id"`
+	Type          string `json:"type"`
+	Region        string `json:"region"`
+	Currency      string `json:"currency"`
+	UnbilledFrom  string `json:"unbilled_from"` // the server's last billing
+	UptimeSeconds int64  `json:"uptime_seconds"`
+	Cost          string `json:"cost"` // exact decimal
+}
+
+type InvoiceResponse struct {
+	ID          uint                   `json:"id"`
+	Period      string                 `json:"period"` // YYYY-MM, a calendar month in UTC
+	PeriodStart string                 `json:"period_start"`
+	PeriodEnd   string                 `json:"period_end"`
+	Status      string                 `json:"status"` // draft or closed
+	Currency    string                 `json:"currency"`
+	Total       string                 `json:"total"` // exact decimal
+	ClosedAt    *string                `json:"closed_at,omitempty"`
+	Lines       []*InvoiceLineResponse `json:"lines,omitempty"` // only on GET /invoices/{id}
+}
+type InvoiceLineResponse struct {
+	ServerID      string `json:"server_id"`
+	Type          string `json:"type"`
+	Region        string `json:"region"`
+	Seconds       int64  `json:"seconds"`
+	HourlyRate    string `json:"hourly_rate"`
+	Amount        string `json:"amount"`
+	AdjustsPeriod string `json:"adjusts_period,omitempty"` // set on corrections to an earlier, closed period
+}
+
+// EventEnvelope is a committed event as published to sinks: the JSON body POSTed to
+// webhooks and each line of the NDJSON sink
+type EventEnvelope struct {
+	EventID uint   `json:"event_id"`
+	Region  string `json:"region,omitempty"`
+	EventLogResponse
 }
@@ -0,0 +1,108 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/rhythin/sever-management/internal/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BudgetRepo handles budgets, their thresholds and the alerts they have fired
+
+type budgetRepo struct {
+	db *gorm.DB
+}
+
+func NewBudgetRepo(db *gorm.DB) BudgetRepo {
+	return &budgetRepo{db: db}
+}
+
+// Create stores a budget together with its thresholds
+func (r *budgetRepo) Create(ctx context.Context, budget *Budget) error {
+	log := logging.S(ctx)
+
+	budget.ID = uuid.New().String()
+	log.Infow("BudgetRepo.Create called", "id", budget.ID, "name", budget.Name, "region", budget.Region, "selector", budget.Selector)
+	err := r.db.WithContext(ctx).Create(budget).Error
+	if err != nil {
+		log.Errorw("BudgetRepo.Create failed", "id", budget.ID, "error", err)
+	}
+	return err
+}
+
+func (r *budgetRepo) GetByID(ctx context.Context, id string) (*Budget, error) {
+	log := logging.S(ctx)
+	log.Debugw("BudgetRepo.GetByID called", "id", id)
+	var budget Budget
+	err := r.db.WithContext(ctx).Preload("Thresholds", orderThresholds).First(&budget, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Errorw("BudgetRepo.GetByID failed", "id", id, "error", err)
+		return nil, err
+	}
+	return &budget, nil
+}
+
+func (r *budgetRepo) List(ctx context.Context) ([]*Budget, error) {
+	log := logging.S(ctx)
+	log.Debugw("BudgetRepo.List called")
+	var budgets []*Budget
+	err := r.db.WithContext(ctx).Preload("Thresholds", orderThresholds).Order("created_at ASC").Find(&budgets).Error
+	if err != nil {
+		log.Errorw("BudgetRepo.List failed", "error", err)
+	}
+	return budgets, err
+}
+
+// Delete removes a budget together with its thresholds and alert history
+func (r *budgetRepo) Delete(ctx context.Context, id string) error {
+	log := logging.S(ctx)
+	log.Infow("BudgetRepo.Delete called", "id", id)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("budget_id = ?", id).Delete(&BudgetAlert{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("budget_id = ?", id).Delete(&BudgetThreshold{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Budget{}, "id = ?", id).Error
+	})
+	if err != nil {
+		log.Errorw("BudgetRepo.Delete failed", "id", id, "error", err)
+	}
+	return err
+}
+
+// RecordAlert records that a threshold fired in a period. It reports false, recording
+// nothing, if the threshold already fired in that period.
+func (r *budgetRepo) RecordAlert(ctx context.Context, alert *BudgetAlert) (bool, error) {
+	log := logging.S(ctx)
+	log.Infow("BudgetRepo.RecordAlert called", "budgetID", alert.BudgetID, "thresholdID", alert.ThresholdID, "period", alert.Period)
+	res := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(alert)
+	if res.Error != nil {
+		log.Errorw("BudgetRepo.RecordAlert failed", "budgetID", alert.BudgetID, "error", res.Error)
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// ListAlerts returns the alerts a budget fired in a period, in the order they fired
+func (r *budgetRepo) ListAlerts(ctx context.Context, budgetID, period string) ([]*BudgetAlert, error) {
+	log := logging.S(ctx)
+	log.Debugw("BudgetRepo.ListAlerts called", "budgetID", budgetID, "period", period)
+	var alerts []*BudgetAlert
+	err := r.db.WithContext(ctx).Where("budget_id = ? AND period = ?", budgetID, period).Order("fired_at ASC, id ASC").Find(&alerts).Error
+	if err != nil {
+		log.Errorw("BudgetRepo.ListAlerts failed", "budgetID", budgetID, "error", err)
+	}
+	return alerts, err
+}
+
+func orderThresholds(db *gorm.DB) *gorm.DB {
+	return db.Order("percent ASC, id ASC")
+}
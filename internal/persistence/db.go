@@ -3,12 +3,17 @@ package persistence
 import (
 	"context"
 	"fmt"
+	"maps"
 	"net"
+	"slices"
+	"time"
 
 	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
 	"github.com/rhythin/sever-management/internal/logging"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -35,41 +40,252 @@ func NewDB(cfg *internal.Config) (*gorm.DB, error) {
 func MigrateDB(ctx context.Context, db *gorm.DB, cfg *internal.Config) error {
 	log := logging.S(ctx)
 	log.Infow("Running DB automigration")
-	if err := db.AutoMigrate(&Server{}, &IPAddress{}, &Billing{}, &EventLog{}); err != nil {
+	if cfg.IPCIDR != "" {
+		// One fleet-wide CIDR cannot be split into regional pools without knowing which region
+		// each range belongs to, so it is not seeded; its addresses already stored are kept
+		log.Warnw("IP_CIDR is deprecated and ignored; configure each region's pool with IP_POOLS", "cidr", cfg.IPCIDR)
+	}
+	if err := backfillEventSequences(ctx, db); err != nil {
+		log.Errorw("Failed backfilling event sequences", "error", err)
+		return err
+	}
+	if err := migrateEventStreams(ctx, db); err != nil {
+		log.Errorw("Failed migrating event streams", "error", err)
+		return err
+	}
+	for _, col := range []struct {
+		model    interface{}
+		from, to string
+	}{{&Billing{}, "total_cost", "total_cost_micros"}, {&UsageSession{}, "cost", "cost_micros"}} {
+		if err := migrateToMicros(ctx, db, col.model, col.from, col.to); err != nil {
+			log.Errorw("Failed converting amounts to micro-units", "column", col.from, "error", err)
+			return err
+		}
+	}
+	// Fleet-level events such as budget alerts are not tied to a server
+	if db.WithContext(ctx).Migrator().HasConstraint(&EventLog{}, "fk_servers_events") {
+		if err := db.WithContext(ctx).Migrator().DropConstraint(&EventLog{}, "fk_servers_events"); err != nil {
+			log.Errorw("Failed dropping the event server foreign key", "error", err)
+			return err
+		}
+	}
+	hadUsage := db.WithContext(ctx).Migrator().HasTable(&UsageSession{})
+	if err := db.AutoMigrate(&Server{}, &ServerLabel{}, &IPAddress{}, &Billing{}, &EventLog{}, &Operation{}, &Region{}, &Webhook{}, &WebhookDelivery{}, &OutboxEntry{}, &UsageSession{}, &Price{}, &UsageCharge{}, &Invoice{}, &InvoiceLine{}, &Budget{}, &BudgetThreshold{}, &BudgetAlert{}, &ReaperPolicy{}, &JobRun{}, &IPPool{}, &IPPoolCIDR{}); err != nil {
 		log.Errorw("DB automigration failed", "error", err)
 		return err
 	}
 	log.Infow("DB automigration complete")
-	// Seed IP addresses based on CIDR if none exist
-	var count int64
-	if err := db.WithContext(ctx).Model(&IPAddress{}).Count(&count).Error; err == nil && count == 0 {
-		log.Infow("Seeding IP pool from CIDR")
-		_, ipnet, perr := net.ParseCIDR(cfg.IPCIDR)
-		if perr != nil {
-			log.Errorw("Invalid IP CIDR in config; skipping seed", "cidr", cfg.IPCIDR, "error", perr)
-		} else {
-			// iterate IPs in CIDR, skip network and broadcast for IPv4, cap to a sane limit (e.g., 2048)
-			seed := make([]IPAddress, 0, 1024)
-			ip := ipnet.IP.Mask(ipnet.Mask)
-			// advance to first usable
-			for i := 0; i < 1<<uint(0); i++ { // no-op for clarity
-			}
-			max := 2048
-			for ip := nextIP(ip); ipnet.Contains(ip) && len(seed) < max; ip = nextIP(ip) {
-				seed = append(seed, IPAddress{Address: ip.String(), Allocated: false})
-			}
-			if len(seed) > 0 {
-				if err := db.WithContext(ctx).Create(&seed).Error; err != nil {
-					log.Errorw("Failed seeding IP pool", "error", err)
-				} else {
-					log.Infow("Seeded IP pool", "count", len(seed))
-				}
-			}
+	if !hadUsage {
+		if err := backfillUsageSessions(ctx, db); err != nil {
+			log.Errorw("Failed backfilling usage sessions", "error", err)
+			return err
+		}
+	}
+	if err := backfillUsageRegions(ctx, db); err != nil {
+		log.Errorw("Failed backfilling usage session regions", "error", err)
+		return err
+	}
+	if err := backfillCurrency(ctx, db, cfg.BillingCurrency); err != nil {
+		log.Errorw("Failed backfilling billing currency", "error", err)
+		return err
+	}
+	if err := seedRegions(ctx, db, cfg); err != nil {
+		log.Errorw("Failed seeding regions", "error", err)
+		return err
+	}
+	if err := seedPrices(ctx, db, cfg); err != nil {
+		log.Errorw("Failed seeding prices", "error", err)
+		return err
+	}
+	if err := seedIPPools(ctx, db, cfg); err != nil {
+		log.Errorw("Failed seeding IP pools", "error", err)
+		return err
+	}
+	return nil
+}
+
+// backfillEventSequences numbers events written before sequences existed, in timestamp
+// order per server, so that the (server_id, sequence) unique index can be created
+func backfillEventSequences(ctx context.Context, db *gorm.DB) error {
+	m := db.WithContext(ctx).Migrator()
+	if !m.HasTable(&EventLog{}) || m.HasColumn(&EventLog{}, "Sequence") {
+		return nil
+	}
+	if err := m.AddColumn(&EventLog{}, "Sequence"); err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Exec(`UPDATE event_logs e SET sequence = n.seq
+		FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY server_id ORDER BY timestamp, id) AS seq FROM event_logs) n
+		WHERE e.id = n.id`).Error
+}
+
+// migrateEventStreams moves event sequences from per-server to per-stream numbering. Existing
+// events keep their sequence in the stream of their server ID; the old (server_id, sequence)
+// index is dropped so that fleet-level streams no longer share the empty server ID.
+func migrateEventStreams(ctx context.Context, db *gorm.DB) error {
+	m := db.WithContext(ctx).Migrator()
+	if !m.HasTable(&EventLog{}) || m.HasColumn(&EventLog{}, "Stream") {
+		return nil
+	}
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		m := tx.Migrator()
+		if err := m.AddColumn(&EventLog{}, "Stream"); err != nil {
+			return err
+		}
+		if err := tx.Exec(`UPDATE event_logs SET stream = server_id`).Error; err != nil {
+			return err
+		}
+		if m.HasIndex(&EventLog{}, "idx_event_logs_server_sequence") {
+			return m.DropIndex(&EventLog{}, "idx_event_logs_server_sequence")
+		}
+		return nil
+	})
+}
+
+// backfillUsageSessions carries billing from before the usage ledger into it: running and
+// rebooting servers get an open session continuing from their last billing, and other
+// servers with billed uptime get a closed session holding it, so that totals derived
+// from the ledger match what was billed
+func backfillUsageSessions(ctx context.Context, db *gorm.DB) error {
+	return db.WithContext(ctx).Exec(`INSERT INTO usage_sessions
+		(server_id, type, region, started_at, ended_at, last_billed_at, billed_seconds, cost_micros, created_at, updated_at)
+		SELECT s.id, s.type, s.region, COALESCE(s.started_at, s.created_at),
+			CASE WHEN s.state IN ('running', 'rebooting') THEN NULL ELSE COALESCE(b.last_billed_at, s.updated_at) END,
+			COALESCE(b.last_billed_at, s.started_at, s.created_at),
+			COALESCE(b.accumulated_seconds, 0), COALESCE(b.total_cost_micros, 0), NOW(), NOW()
+		FROM servers s LEFT JOIN billing b ON b.server_id = s.id
+		WHERE (s.state IN ('running', 'rebooting') AND s.started_at IS NOT NULL) OR b.accumulated_seconds > 0`).Error
+}
+
+// migrateToMicros replaces a floating-point amount column with an integer micro-unit column,
+// rounding existing amounts to the nearest micro-unit
+func migrateToMicros(ctx context.Context, db *gorm.DB, model interface{}, from, to string) error {
+	m := db.WithContext(ctx).Migrator()
+	if !m.HasTable(model) || !m.HasColumn(model, from) || m.HasColumn(model, to) {
+		return nil
+	}
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		stmt := &gorm.Statement{DB: tx}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+		table := stmt.Schema.Table
+		if err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s BIGINT NOT NULL DEFAULT 0", table, to)).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s = ROUND(%s * 1000000)", table, to, from)).Error; err != nil {
+			return err
+		}
+		return tx.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, from)).Error
+	})
+}
+
+// backfillUsageRegions assigns sessions recorded before they carried a region the region of their server
+func backfillUsageRegions(ctx context.Context, db *gorm.DB) error {
+	return db.WithContext(ctx).Exec(`UPDATE usage_sessions u SET region = s.region
+		FROM servers s WHERE s.id = u.server_id AND (u.region IS NULL OR u.region = '')`).Error
+}
+
+// backfillCurrency assigns the billing currency to amounts recorded before currencies were
+func backfillCurrency(ctx context.Context, db *gorm.DB, currency string) error {
+	for _, model := range []interface{}{&Billing{}, &UsageSession{}} {
+		if err := db.WithContext(ctx).Model(model).Where("currency IS NULL OR currency = ''").Update("currency", currency).Error; err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// seedRegions registers configured regions that are not yet in the registry.
+// Existing rows are left alone so that runtime status and capacity changes survive restarts.
+func seedRegions(ctx context.Context, db *gorm.DB, cfg *internal.Config) error {
+	if len(cfg.Regions) == 0 {
+		return nil
+	}
+	regions := make([]Region, 0, len(cfg.Regions))
+	for name, max := range cfg.Regions {
+		regions = append(regions, Region{Name: name, Status: string(domain.RegionEnabled), MaxServers: max})
+	}
+	return db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&regions).Error
+}
+
+// seedPrices gives each catalog type without a price book entry its catalog price in all
+// regions, in effect since the epoch. Later price changes are made through the price book.
+func seedPrices(ctx context.Context, db *gorm.DB, cfg *internal.Config) error {
+	if len(cfg.ServerTypes) == 0 {
+		return nil
+	}
+	var priced []string
+	if err := db.WithContext(ctx).Model(&Price{}).Where("region = ''").Distinct().Pluck("server_type", &priced).Error; err != nil {
+		return err
+	}
+	var prices []Price
+	for _, spec := range cfg.ServerTypes {
+		if !slices.Contains(priced, spec.Name) {
+			prices = append(prices, Price{ServerType: spec.Name, HourlyPrice: spec.HourlyPrice, Currency: cfg.BillingCurrency, EffectiveFrom: time.Unix(0, 0).UTC()})
+		}
+	}
+	if len(prices) == 0 {
+		return nil
+	}
+	return db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&prices).Error
+}
+
+// seedIPPools gives each configured region without an IP pool a pool named after it holding
+// the configured CIDR. Addresses seeded before pools existed join the pool whose CIDR covers
+// them unless a server in another region holds them; the rest are never allocated again.
+// CIDRs that are invalid or overlap an existing pool's are logged and skipped.
+func seedIPPools(ctx context.Context, db *gorm.DB, cfg *internal.Config) error {
+	log := logging.S(ctx)
+	regions := slices.Sorted(maps.Keys(cfg.IPPools))
+	for _, region := range regions {
+		var pools int64
+		if err := db.WithContext(ctx).Model(&IPPool{}).Where("region = ?", region).Count(&pools).Error; err != nil {
+			return err
+		}
+		if pools > 0 {
+			continue
+		}
+		ipnet, err := domain.ParsePoolCIDR(cfg.IPPools[region])
+		if err != nil {
+			log.Errorw("Invalid IP pool CIDR in config; skipping seed", "region", region, "error", err)
+			continue
+		}
+		var existing []string
+		if err := db.WithContext(ctx).Model(&IPPoolCIDR{}).Pluck("cidr", &existing).Error; err != nil {
+			return err
+		}
+		if slices.ContainsFunc(existing, func(s string) bool {
+			_, other, err := net.ParseCIDR(s)
+			return err == nil && domain.CIDRsOverlap(ipnet, other)
+		}) {
+			log.Errorw("IP pool CIDR in config overlaps an existing pool; skipping seed", "region", region, "cidr", ipnet.String())
+			continue
+		}
+		pool := &IPPool{Name: region, Region: region, CIDRs: []*IPPoolCIDR{{CIDR: ipnet.String()}}}
+		if err := NewIPPoolRepo(db).Create(ctx, pool); err != nil {
+			return err
+		}
+		log.Infow("Seeded IP pool", "region", region, "cidr", ipnet.String(), "count", pool.CIDRs[0].Addresses)
+	}
+	return nil
+}
+
+// usableAddresses lists an IPv4 CIDR's addresses in order, leaving out the network and
+// broadcast addresses of blocks larger than /31
+func usableAddresses(ipnet *net.IPNet) []string {
+	ones, bits := ipnet.Mask.Size()
+	size := 1 << uint(bits-ones)
+	addresses := make([]string, 0, size)
+	for ip := ipnet.IP.Mask(ipnet.Mask); ipnet.Contains(ip); ip = nextIP(ip) {
+		addresses = append(addresses, ip.String())
+	}
+	if size > 2 {
+		addresses = addresses[1 : size-1]
+	}
+	return addresses
+}
+
 // nextIP returns the next IPv4 address
 func nextIP(ip net.IP) net.IP {
 	nip := make(net.IP, len(ip))
@@ -2,6 +2,7 @@ package persistence
 
 import (
     "net"
+    "slices"
     "testing"
 )
 
@@ -20,3 +21,25 @@ func TestNextIP(t *testing.T) {
         t.Errorf("nextIP(192.168.0.255) = %v; want %v", next, want)
     }
 }
+
+func TestUsableAddresses(t *testing.T) {
+    tests := []struct {
+        cidr string
+        want []string
+    }{
+        {cidr: "10.1.0.0/30", want: []string{"10.1.0.1", "10.1.0.2"}},
+        {cidr: "10.1.0.254/31", want: []string{"10.1.0.254", "10.1.0.255"}},
+        {cidr: "10.1.0.7/32", want: []string{"10.1.0.7"}},
+        {cidr: "10.1.0.248/29", want: []string{"10.1.0.249", "10.1.0.250", "10.1.0.251", "10.1.0.252", "10.1.0.253", "10.1.0.254"}},
+    }
+    for _, tt := range tests {
+        _, ipnet, _ := net.ParseCIDR(tt.cidr)
+        if got := usableAddresses(ipnet); !slices.Equal(got, tt.want) {
+            t.Errorf("usableAddresses(%s) = %v; want %v", tt.cidr, got, tt.want)
+        }
+    }
+    _, ipnet, _ := net.ParseCIDR("10.1.0.0/16")
+    if got := len(usableAddresses(ipnet)); got != 65534 {
+        t.Errorf("len(usableAddresses(10.1.0.0/16)) = %d; want 65534", got)
+    }
+}
@@ -17,12 +17,30 @@ func NewEventRepo(db *gorm.DB) EventRepo {
 	return &eventRepo{db: db}
 }
 
+// Append stores an event, assigning the next sequence number in its stream when none is set.
+// The stream defaults to the server ID. Callers append inside the unit of work that changed
+// the server, or recorded the fleet-level alert, so that row lock serializes sequence
+// assignment; the (stream, sequence) unique index catches the rest.
 func (r *eventRepo) Append(ctx context.Context, event *EventLog) error {
 	log := logging.S(ctx)
-	log.Debugw("EventRepo.Append called", "serverID", event.ServerID, "type", event.Type)
+	log.Debugw("EventRepo.Append called", "serverID", event.ServerID, "stream", event.Stream, "type", event.Type)
+	if event.Stream == "" {
+		event.Stream = event.ServerID
+	}
+	if event.Sequence == 0 {
+		var last int64
+		if err := r.db.WithContext(ctx).Model(&EventLog{}).
+			Where("stream = ?", event.Stream).
+			Select("COALESCE(MAX(sequence), 0)").
+			Scan(&last).Error; err != nil {
+			log.Errorw("EventRepo.Append failed to read sequence", "stream", event.Stream, "error", err)
+			return err
+		}
+		event.Sequence = last + 1
+	}
 	err := r.db.WithContext(ctx).Create(event).Error
 	if err != nil {
-		log.Errorw("EventRepo.Append failed", "serverID", event.ServerID, "error", err)
+		log.Errorw("EventRepo.Append failed", "serverID", event.ServerID, "stream", event.Stream, "error", err)
 	}
 	return err
 }
@@ -33,7 +51,7 @@ func (r *eventRepo) LastN(ctx context.Context, serverID string, n int) ([]EventL
 	var events []EventLog
 	err := r.db.WithContext(ctx).
 		Where("server_id = ?", serverID).
-		Order("timestamp DESC").
+		Order("sequence DESC").
 		Limit(n).
 		Find(&events).Error
 	if err != nil {
@@ -42,14 +60,53 @@ func (r *eventRepo) LastN(ctx context.Context, serverID string, n int) ([]EventL
 	return events, err
 }
 
-// GetEvents returns all events for a server, ordered by timestamp (newest first)
+// Query returns events matching q, newest first. Region filters join the servers table.
+func (r *eventRepo) Query(ctx context.Context, q EventQuery) ([]EventLog, error) {
+	log := logging.S(ctx)
+	log.Debugw("EventRepo.Query called", "serverID", q.ServerID, "region", q.Region, "types", q.Types, "since", q.Since, "until", q.Until, "beforeID", q.BeforeID, "afterID", q.AfterID, "limit", q.Limit)
+	var events []EventLog
+	db := r.db.WithContext(ctx).Model(&EventLog{})
+	if q.ServerID != "" {
+		db = db.Where("event_logs.server_id = ?", q.ServerID)
+	}
+	if q.Region != "" {
+		db = db.Joins("JOIN servers ON servers.id = event_logs.server_id").Where("servers.region = ?", q.Region)
+	}
+	if len(q.Types) > 0 {
+		db = db.Where("event_logs.type IN ?", q.Types)
+	}
+	if q.Since != nil {
+		db = db.Where("event_logs.timestamp >= ?", *q.Since)
+	}
+	if q.Until != nil {
+		db = db.Where("event_logs.timestamp < ?", *q.Until)
+	}
+	if q.BeforeID > 0 {
+		db = db.Where("event_logs.id < ?", q.BeforeID)
+	}
+	order := "event_logs.id DESC"
+	if q.AfterID > 0 {
+		db = db.Where("event_logs.id > ?", q.AfterID)
+		order = "event_logs.id ASC"
+	}
+	if q.Limit > 0 {
+		db = db.Limit(q.Limit)
+	}
+	err := db.Select("event_logs.*").Order(order).Find(&events).Error
+	if err != nil {
+		log.Errorw("EventRepo.Query failed", "error", err)
+	}
+	return events, err
+}
+
+// GetEvents returns all events for a server, ordered by sequence (newest first)
 func (r *eventRepo) GetEvents(ctx context.Context, serverID string) ([]EventLog, error) {
 	log := logging.S(ctx)
 	log.Debugw("EventRepo.GetEvents called", "serverID", serverID)
 	var events []EventLog
 	err := r.db.WithContext(ctx).
 		Where("server_id = ?", serverID).
-		Order("timestamp DESC").
+		Order("sequence DESC").
 		Find(&events).Error
 	if err != nil {
 		log.Errorw("EventRepo.GetEvents failed", "serverID", serverID, "error", err)
@@ -3,28 +3,198 @@ package persistence
 import (
 	"context"
 	"time"
+
+	"github.com/rhythin/sever-management/internal/domain"
 )
 
 // ServerRepo defines the interface for server repository operations
 type ServerRepo interface {
 	GetByID(ctx context.Context, id string) (*Server, error)
 	Create(ctx context.Context, server *Server) error
-	UpdateState(ctx context.Context, id string, state string) error
+	UpdateState(ctx context.Context, id string, version int64, state string) error
 	UpdateTimestamps(ctx context.Context, id string, started, stopped, terminated *time.Time) error
 	UpdateServer(ctx context.Context, id string, updates *Server) error
-	UpdateBilling(ctx context.Context, id string, accumulatedSeconds int64, totalCost float64) error
 	List(ctx context.Context, region, status, typ string, limit, offset int) ([]*Server, error)
+	ListMatching(ctx context.Context, region, state string, labels map[string]string) ([]*Server, error)
+	DetachIP(ctx context.Context, id string) error
+	UpdateReapSchedule(ctx context.Context, id string, version int64, reapAfter, reapWarnedFor *time.Time) error
+	ClearReapSchedule(ctx context.Context, id string) error
 }
 
 // IPRepo defines the interface for IP repository operations
 type IPRepo interface {
-	AllocateIP(ctx context.Context) (*IPAddress, error)
+	AllocateIP(ctx context.Context, region string) (*IPAddress, error)
 	ReleaseIP(ctx context.Context, id uint) error
+	QuarantineIP(ctx context.Context, id uint, until time.Time) error
 	AssignIPToServer(ctx context.Context, ipID uint, serverID string) error
 }
 
+// IPPoolRepo defines the interface for IP pools and their CIDRs
+type IPPoolRepo interface {
+	Create(ctx context.Context, pool *IPPool) error
+	GetByID(ctx context.Context, id string) (*IPPool, error)
+	List(ctx context.Context, region string) ([]*IPPool, error)
+	AddCIDR(ctx context.Context, cidr *IPPoolCIDR) error
+	RetireCIDR(ctx context.Context, id uint, at time.Time) error
+	Delete(ctx context.Context, id string) error
+}
+
 // EventRepo defines the interface for event repository operations
 type EventRepo interface {
 	Append(ctx context.Context, event *EventLog) error
 	LastN(ctx context.Context, serverID string, n int) ([]EventLog, error)
+	Query(ctx context.Context, q EventQuery) ([]EventLog, error)
+}
+
+// EventQuery filters events across the fleet; zero values match everything.
+// Results are newest first, and BeforeID continues from the last event of a previous page.
+// AfterID instead returns events after it, oldest first, for resuming streams.
+type EventQuery struct {
+	ServerID string
+	Region   string
+	Types    []string
+	Since    *time.Time // inclusive
+	Until    *time.Time // exclusive
+	BeforeID uint
+	AfterID  uint
+	Limit    int
+}
+
+// OperationRepo defines the interface for asynchronous operation persistence
+type OperationRepo interface {
+	Create(ctx context.Context, op *Operation) error
+	GetByID(ctx context.Context, id string) (*Operation, error)
+	ListPending(ctx context.Context, limit int) ([]*Operation, error)
+	Complete(ctx context.Context, id string, status string, errMsg string) error
+}
+
+// RegionRepo defines the interface for region registry operations
+type RegionRepo interface {
+	List(ctx context.Context) ([]*Region, error)
+	GetByName(ctx context.Context, name string) (*Region, error)
+	Update(ctx context.Context, name string, status string, maxServers int) error
+	CountActiveServers(ctx context.Context) (map[string]int64, error)
+	LockByName(ctx context.Context, name string) (*Region, error)
+	CountActiveInRegion(ctx context.Context, name string) (int64, error)
+}
+
+// WebhookRepo defines the interface for webhook subscriptions and their deliveries
+type WebhookRepo interface {
+	Create(ctx context.Context, hook *Webhook) error
+	GetByID(ctx context.Context, id string) (*Webhook, error)
+	List(ctx context.Context) ([]*Webhook, error)
+	Delete(ctx context.Context, id string) error
+	CreateDeliveries(ctx context.Context, deliveries []*WebhookDelivery) error
+	ClaimDueDeliveries(ctx context.Context, now, until time.Time, limit int) ([]*WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *WebhookDelivery) error
+	ListDeliveries(ctx context.Context, webhookID string, limit int) ([]*WebhookDelivery, error)
+}
+
+// OutboxRepo defines the interface for the transactional event outbox
+type OutboxRepo interface {
+	Add(ctx context.Context, entry *OutboxEntry) error
+	ListPending(ctx context.Context, limit int) ([]*OutboxEntry, error)
+	ListSince(ctx context.Context, since time.Time, afterID uint, limit int) ([]*OutboxEntry, error)
+	MarkDispatched(ctx context.Context, id uint) error
+	RecordFailure(ctx context.Context, id uint, errMsg string) error
+	PurgeDispatched(ctx context.Context, before time.Time) (int64, error)
+}
+
+// UsageRepo defines the interface for the usage-session ledger
+type UsageRepo interface {
+	Open(ctx context.Context, session *UsageSession) error
+	GetOpen(ctx context.Context, serverID string) (*UsageSession, error)
+	ListOpen(ctx context.Context, afterID uint, limit int) ([]*UsageSession, error)
+	Bill(ctx context.Context, session *UsageSession, charges []*UsageCharge, billedTo time.Time, end *time.Time) error
+	ListByServer(ctx context.Context, serverID string) ([]*UsageSession, error)
+	Summarize(ctx context.Context, q UsageSummaryQuery) ([]*UsageSummaryRow, error)
+}
+
+// UsageSummaryQuery aggregates the usage charges that started in [From, To), grouped by GroupBy.
+// A non-empty Region or Labels restricts it to that region or to servers with all of the labels.
+type UsageSummaryQuery struct {
+	GroupBy []domain.SummaryGroup
+	From    time.Time
+	To      time.Time
+	Region  string
+	Labels  map[string]string
+}
+
+// UsageSummaryRow is one group of a usage summary. Groups holds its values in GroupBy order;
+// amounts in different currencies are never summed together.
+type UsageSummaryRow struct {
+	Groups   []string
+	Currency string
+	Servers  int64 // distinct servers with uptime in the group
+	Seconds  int64
+	Cost     domain.Micros
+}
+
+// LeaseRepo defines the interface for leases that elect one replica to run a job
+type LeaseRepo interface {
+	TryAcquire(ctx context.Context, name string) (bool, error)
+	Release(ctx context.Context, name string) error
+}
+
+// BudgetRepo defines the interface for budgets and their alerts
+type BudgetRepo interface {
+	Create(ctx context.Context, budget *Budget) error
+	GetByID(ctx context.Context, id string) (*Budget, error)
+	List(ctx context.Context) ([]*Budget, error)
+	Delete(ctx context.Context, id string) error
+	RecordAlert(ctx context.Context, alert *BudgetAlert) (bool, error)
+	ListAlerts(ctx context.Context, budgetID, period string) ([]*BudgetAlert, error)
+}
+
+// ReaperPolicyRepo defines the interface for idle reaper policies
+type ReaperPolicyRepo interface {
+	Create(ctx context.Context, policy *ReaperPolicy) error
+	GetByID(ctx context.Context, id string) (*ReaperPolicy, error)
+	List(ctx context.Context) ([]*ReaperPolicy, error)
+	Update(ctx context.Context, policy *ReaperPolicy) error
+	Delete(ctx context.Context, id string) error
+}
+
+// JobRunRepo defines the interface for background job run history
+type JobRunRepo interface {
+	Create(ctx context.Context, run *JobRun) error
+	Finish(ctx context.Context, run *JobRun) error
+	List(ctx context.Context, job string, limit int) ([]*JobRun, error)
+	Prune(ctx context.Context, job string, keep int) error
+}
+
+// PriceRepo defines the interface for the price book
+type PriceRepo interface {
+	Create(ctx context.Context, price *Price) error
+	List(ctx context.Context, serverType, region string) ([]*Price, error)
+	ListEffective(ctx context.Context, serverType, region string, before time.Time) ([]*Price, error)
+}
+
+// InvoiceRepo defines the interface for invoices
+type InvoiceRepo interface {
+	EnsureDraft(ctx context.Context, invoice *Invoice) (*Invoice, error)
+	GetByID(ctx context.Context, id uint) (*Invoice, error)
+	List(ctx context.Context, status string) ([]*Invoice, error)
+	ListUninvoicedPeriods(ctx context.Context) ([]string, error)
+	Refresh(ctx context.Context, id uint, close bool) error
+}
+
+// Repos groups the repositories that can take part in a unit of work
+type Repos struct {
+	Servers    ServerRepo
+	IPs        IPRepo
+	Events     EventRepo
+	Operations OperationRepo
+	Outbox     OutboxRepo
+	Usage      UsageRepo
+	Regions    RegionRepo
+	Budgets    BudgetRepo
+	Webhooks   WebhookRepo
+}
+
+// UnitOfWork runs fn with repositories that share one transaction, so that state
+// changes, timestamps, IP changes, event appends and their outbox entries commit or
+// roll back together, and row locks taken through them are held until then
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(tx Repos) error) error
 }
@@ -0,0 +1,148 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvoiceClosed is returned when regenerating an invoice that has been closed
+var ErrInvoiceClosed = errors.New("invoice is closed")
+
+// uninvoicedCharges selects the charges an invoice for @period takes: the period's own
+// charges, and charges for earlier periods that arrived after their invoice was closed
+const uninvoicedCharges = `c.invoice_id IS NULL AND (c.period = @period OR (c.period < @period AND EXISTS
+	(SELECT 1 FROM invoices ci WHERE ci.period = c.period AND ci.status = 'closed')))`
+
+// InvoiceRepo handles invoices and their lines
+
+type invoiceRepo struct {
+	db *gorm.DB
+}
+
+func NewInvoiceRepo(db *gorm.DB) InvoiceRepo {
+	return &invoiceRepo{db: db}
+}
+
+// EnsureDraft creates a draft invoice for the invoice's period unless one exists, and returns
+// the period's invoice
+func (r *invoiceRepo) EnsureDraft(ctx context.Context, invoice *Invoice) (*Invoice, error) {
+	log := logging.S(ctx)
+	log.Debugw("InvoiceRepo.EnsureDraft called", "period", invoice.Period)
+	invoice.Status = string(domain.InvoiceDraft)
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(invoice).Error; err != nil {
+		log.Errorw("InvoiceRepo.EnsureDraft failed", "period", invoice.Period, "error", err)
+		return nil, err
+	}
+	var existing Invoice
+	if err := r.db.WithContext(ctx).First(&existing, "period = ?", invoice.Period).Error; err != nil {
+		log.Errorw("InvoiceRepo.EnsureDraft failed", "period", invoice.Period, "error", err)
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// GetByID returns an invoice with its lines, adjustments last
+func (r *invoiceRepo) GetByID(ctx context.Context, id uint) (*Invoice, error) {
+	log := logging.S(ctx)
+	log.Debugw("InvoiceRepo.GetByID called", "id", id)
+	var invoice Invoice
+	err := r.db.WithContext(ctx).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB {
+			return db.Order("adjusts_period ASC, server_id ASC, rate_micros ASC")
+		}).
+		First(&invoice, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Errorw("InvoiceRepo.GetByID failed", "id", id, "error", err)
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// List returns invoices without their lines, newest period first, optionally with one status
+func (r *invoiceRepo) List(ctx context.Context, status string) ([]*Invoice, error) {
+	log := logging.S(ctx)
+	log.Debugw("InvoiceRepo.List called", "status", status)
+	q := r.db.WithContext(ctx)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var invoices []*Invoice
+	err := q.Order("period DESC").Find(&invoices).Error
+	if err != nil {
+		log.Errorw("InvoiceRepo.List failed", "error", err)
+	}
+	return invoices, err
+}
+
+// ListUninvoicedPeriods returns the periods that have charges no closed invoice has taken, oldest first
+func (r *invoiceRepo) ListUninvoicedPeriods(ctx context.Context) ([]string, error) {
+	log := logging.S(ctx)
+	log.Debugw("InvoiceRepo.ListUninvoicedPeriods called")
+	var periods []string
+	err := r.db.WithContext(ctx).Model(&UsageCharge{}).
+		Where("invoice_id IS NULL").
+		Distinct().Order("period ASC").
+		Pluck("period", &periods).Error
+	if err != nil {
+		log.Errorw("InvoiceRepo.ListUninvoicedPeriods failed", "error", err)
+	}
+	return periods, err
+}
+
+// Refresh regenerates a draft invoice's lines and total from the charges it takes. When
+// close is set the invoice takes the charges for good and is frozen. It returns
+// ErrInvoiceClosed if the invoice was already closed.
+func (r *invoiceRepo) Refresh(ctx context.Context, id uint, close bool) error {
+	log := logging.S(ctx)
+	log.Debugw("InvoiceRepo.Refresh called", "id", id, "close", close)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var invoice Invoice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&invoice, id).Error; err != nil {
+			return err
+		}
+		if invoice.Status == string(domain.InvoiceClosed) {
+			return ErrInvoiceClosed
+		}
+		args := map[string]interface{}{"id": id, "period": invoice.Period}
+		// Charges are taken before lines are built from them, so that a charge committed
+		// meanwhile is either on this invoice or left for a later one
+		source := uninvoicedCharges
+		if close {
+			if err := tx.Exec(`UPDATE usage_charges c SET invoice_id = @id WHERE `+uninvoicedCharges, args).Error; err != nil {
+				return err
+			}
+			source = "c.invoice_id = @id"
+		}
+		if err := tx.Where("invoice_id = ?", id).Delete(&InvoiceLine{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`INSERT INTO invoice_lines (invoice_id, server_id, type, region, seconds, rate_micros, amount_micros, adjusts_period)
+			SELECT @id, c.server_id, c.type, c.region, SUM(c.seconds), c.rate_micros, SUM(c.amount_micros),
+				CASE WHEN c.period = @period THEN '' ELSE c.period END
+			FROM usage_charges c WHERE `+source+`
+			GROUP BY c.server_id, c.type, c.region, c.rate_micros, c.period`, args).Error; err != nil {
+			return err
+		}
+		updates := map[string]interface{}{
+			"total_micros": gorm.Expr("(SELECT COALESCE(SUM(amount_micros), 0) FROM invoice_lines WHERE invoice_id = ?)", id),
+		}
+		if close {
+			updates["status"] = string(domain.InvoiceClosed)
+			updates["closed_at"] = time.Now()
+		}
+		return tx.Model(&Invoice{}).Where("id = ?", id).Updates(updates).Error
+	})
+	if err != nil && !errors.Is(err, ErrInvoiceClosed) {
+		log.Errorw("InvoiceRepo.Refresh failed", "id", id, "error", err)
+	}
+	return err
+}
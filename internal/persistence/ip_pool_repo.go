@@ -0,0 +1,253 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrIPPoolExists is returned when a pool name or a CIDR is already taken
+	ErrIPPoolExists = errors.New("IP pool name or CIDR is taken")
+	// ErrIPPoolAllocated is returned when deleting a pool one of whose addresses is allocated
+	// or held by a server that is not terminated
+	ErrIPPoolAllocated = errors.New("IP pool has addresses in use")
+)
+
+// IPPoolRepo handles IP pools, their CIDRs and the addresses in them
+
+type ipPoolRepo struct {
+	db *gorm.DB
+}
+
+func NewIPPoolRepo(db *gorm.DB) IPPoolRepo {
+	return &ipPoolRepo{db: db}
+}
+
+// Create stores a pool with its CIDRs and their usable addresses in one transaction. It
+// returns ErrIPPoolExists if the name or one of the CIDRs is taken.
+func (r *ipPoolRepo) Create(ctx context.Context, pool *IPPool) error {
+	log := logging.S(ctx)
+
+	pool.ID = uuid.New().String()
+	log.Infow("IPPoolRepo.Create called", "id", pool.ID, "name", pool.Name, "region", pool.Region, "cidrs", len(pool.CIDRs))
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Omit("CIDRs").Clauses(clause.OnConflict{DoNothing: true}).Create(pool)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrIPPoolExists
+		}
+		for _, cidr := range pool.CIDRs {
+			cidr.PoolID = pool.ID
+			if err := addCIDR(tx, pool.Region, cidr); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorw("IPPoolRepo.Create failed", "id", pool.ID, "error", err)
+	}
+	return err
+}
+
+func (r *ipPoolRepo) GetByID(ctx context.Context, id string) (*IPPool, error) {
+	log := logging.S(ctx)
+	log.Debugw("IPPoolRepo.GetByID called", "id", id)
+	var pool IPPool
+	err := r.db.WithContext(ctx).Preload("CIDRs", orderCIDRs).First(&pool, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Errorw("IPPoolRepo.GetByID failed", "id", id, "error", err)
+		return nil, err
+	}
+	if err := r.countAddresses(ctx, &pool); err != nil {
+		log.Errorw("IPPoolRepo.GetByID failed counting addresses", "id", id, "error", err)
+		return nil, err
+	}
+	return &pool, nil
+}
+
+// List returns the pools in a region, or in every region if region is empty, oldest first
+func (r *ipPoolRepo) List(ctx context.Context, region string) ([]*IPPool, error) {
+	log := logging.S(ctx)
+	log.Debugw("IPPoolRepo.List called", "region", region)
+	q := r.db.WithContext(ctx).Preload("CIDRs", orderCIDRs).Order("created_at ASC, name ASC")
+	if region != "" {
+		q = q.Where("region = ?", region)
+	}
+	var pools []*IPPool
+	if err := q.Find(&pools).Error; err != nil {
+		log.Errorw("IPPoolRepo.List failed", "error", err)
+		return nil, err
+	}
+	if err := r.countAddresses(ctx, pools...); err != nil {
+		log.Errorw("IPPoolRepo.List failed counting addresses", "error", err)
+		return nil, err
+	}
+	return pools, nil
+}
+
+// AddCIDR adds a CIDR and its usable addresses to an existing pool. It returns
+// ErrIPPoolExists if the CIDR is taken.
+func (r *ipPoolRepo) AddCIDR(ctx context.Context, cidr *IPPoolCIDR) error {
+	log := logging.S(ctx)
+	log.Infow("IPPoolRepo.AddCIDR called", "poolID", cidr.PoolID, "cidr", cidr.CIDR)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Share-lock the pool so that it cannot be deleted before the CIDR is added
+		var pool IPPool
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Select("region").First(&pool, "id = ?", cidr.PoolID).Error; err != nil {
+			return err
+		}
+		return addCIDR(tx, pool.Region, cidr)
+	})
+	if err != nil {
+		log.Errorw("IPPoolRepo.AddCIDR failed", "poolID", cidr.PoolID, "cidr", cidr.CIDR, "error", err)
+	}
+	return err
+}
+
+// RetireCIDR stops a CIDR's addresses from being allocated; retiring it again keeps the
+// original time
+func (r *ipPoolRepo) RetireCIDR(ctx context.Context, id uint, at time.Time) error {
+	log := logging.S(ctx)
+	log.Infow("IPPoolRepo.RetireCIDR called", "id", id, "at", at)
+	err := r.db.WithContext(ctx).Model(&IPPoolCIDR{}).Where("id = ? AND retired_at IS NULL", id).Update("retired_at", at).Error
+	if err != nil {
+		log.Errorw("IPPoolRepo.RetireCIDR failed", "id", id, "error", err)
+	}
+	return err
+}
+
+// Delete removes a pool, its CIDRs and their addresses. It locks the pool and its addresses
+// first, waiting for allocations in flight, and returns ErrIPPoolAllocated if any address is
+// allocated or still held by a server that is not terminated. Terminated servers holding one
+// of the addresses lose the reference.
+func (r *ipPoolRepo) Delete(ctx context.Context, id string) error {
+	log := logging.S(ctx)
+	log.Infow("IPPoolRepo.Delete called", "id", id)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var pools []IPPool
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", id).Find(&pools).Error; err != nil || len(pools) == 0 {
+			return err // a pool deleted concurrently is already gone
+		}
+		cidrs := tx.Model(&IPPoolCIDR{}).Select("id").Where("pool_id = ?", id)
+		var addresses []IPAddress
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "allocated").Where("cidr_id IN (?)", cidrs).Find(&addresses).Error; err != nil {
+			return err
+		}
+		ids := make([]uint, 0, len(addresses))
+		for _, a := range addresses {
+			if a.Allocated {
+				return ErrIPPoolAllocated
+			}
+			ids = append(ids, a.ID)
+		}
+		if len(ids) > 0 {
+			var held int64
+			if err := tx.Model(&Server{}).Where("ip_id IN ? AND state <> ?", ids, string(domain.ServerTerminated)).Count(&held).Error; err != nil {
+				return err
+			}
+			if held > 0 {
+				return ErrIPPoolAllocated
+			}
+			if err := tx.Model(&Server{}).Where("ip_id IN ?", ids).Update("ip_id", nil).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("id IN ?", ids).Delete(&IPAddress{}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("pool_id = ?", id).Delete(&IPPoolCIDR{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&IPPool{}, "id = ?", id).Error
+	})
+	if err != nil {
+		log.Errorw("IPPoolRepo.Delete failed", "id", id, "error", err)
+	}
+	return err
+}
+
+// countAddresses fills in the address and allocation counts of the pools' CIDRs
+func (r *ipPoolRepo) countAddresses(ctx context.Context, pools ...*IPPool) error {
+	byID := make(map[uint]*IPPoolCIDR)
+	for _, pool := range pools {
+		for _, cidr := range pool.CIDRs {
+			byID[cidr.ID] = cidr
+		}
+	}
+	if len(byID) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	var counts []struct {
+		CIDRID    uint
+		Addresses int64
+		Allocated int64
+	}
+	err := r.db.WithContext(ctx).Model(&IPAddress{}).
+		Select("cidr_id, COUNT(*) AS addresses, SUM(CASE WHEN allocated THEN 1 ELSE 0 END) AS allocated").
+		Where("cidr_id IN ?", ids).Group("cidr_id").Scan(&counts).Error
+	if err != nil {
+		return err
+	}
+	for _, c := range counts {
+		byID[c.CIDRID].Addresses, byID[c.CIDRID].Allocated = c.Addresses, c.Allocated
+	}
+	return nil
+}
+
+// addCIDR stores a CIDR of a pool in region and its usable addresses. Addresses already stored
+// outside any pool, such as those seeded before pools existed, join the CIDR rather than being
+// duplicated, unless a server in another region holds them: those stay outside every pool, so
+// that they are never allocated again.
+func addCIDR(tx *gorm.DB, region string, cidr *IPPoolCIDR) error {
+	ipnet, err := domain.ParsePoolCIDR(cidr.CIDR)
+	if err != nil {
+		return err
+	}
+	cidr.CIDR = ipnet.String()
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(cidr)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrIPPoolExists
+	}
+	usable := usableAddresses(ipnet)
+	addresses := make([]IPAddress, 0, len(usable))
+	for _, address := range usable {
+		addresses = append(addresses, IPAddress{Address: address, CIDRID: &cidr.ID})
+	}
+	res = tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "address"}},
+		DoUpdates: clause.AssignmentColumns([]string{"cidr_id"}),
+		Where: clause.Where{Exprs: []clause.Expression{clause.Expr{
+			SQL:  "ip_addresses.cidr_id IS NULL AND (ip_addresses.server_id IS NULL OR ip_addresses.server_id IN (SELECT id FROM servers WHERE region = ?))",
+			Vars: []interface{}{region},
+		}}},
+	}).CreateInBatches(addresses, 1000)
+	if res.Error != nil {
+		return res.Error
+	}
+	cidr.Addresses = res.RowsAffected
+	return nil
+}
+
+func orderCIDRs(db *gorm.DB) *gorm.DB {
+	return db.Order("id ASC")
+}
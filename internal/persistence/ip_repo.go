@@ -3,7 +3,7 @@ package persistence
 import (
 	"context"
 	"errors"
-	"sync"
+	"time"
 
 	"github.com/rhythin/sever-management/internal/logging"
 	"gorm.io/gorm"
@@ -14,26 +14,28 @@ import (
 
 type ipRepo struct {
 	db *gorm.DB
-	mu sync.Mutex // serialize allocation attempts for extra safety
 }
 
 func NewIPRepo(db *gorm.DB) IPRepo {
 	return &ipRepo{db: db}
 }
 
-// AllocateIP atomically allocates an available IP and marks it as allocated
-// Uses GORM transaction with row-level locking and a mutex for extra thread safety
-func (r *ipRepo) AllocateIP(ctx context.Context) (*IPAddress, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+// AllocateIP atomically allocates an available IP from the region's pools and marks it as allocated
+// Uses GORM transaction with row-level locking; rows locked by concurrent allocations
+// (possibly held until an enclosing unit of work commits), quarantined addresses and
+// addresses in retired CIDRs are skipped
+func (r *ipRepo) AllocateIP(ctx context.Context, region string) (*IPAddress, error) {
 	log := logging.S(ctx)
-	log.Infow("IPRepo.AllocateIP called")
+	log.Infow("IPRepo.AllocateIP called", "region", region)
 	var ip IPAddress
 	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("allocated = ?", false).First(&ip).Error; err != nil {
+		cidrs := tx.Model(&IPPoolCIDR{}).Select("ip_pool_cidrs.id").
+			Joins("JOIN ip_pools ON ip_pools.id = ip_pool_cidrs.pool_id").
+			Where("ip_pools.region = ? AND ip_pool_cidrs.retired_at IS NULL", region)
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).Where("allocated = ? AND (quarantined_until IS NULL OR quarantined_until <= ?) AND cidr_id IN (?)", false, time.Now(), cidrs).First(&ip).Error; err != nil {
 			// Treat no rows as a normal condition (no available IPs)
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				log.Warnw("IPRepo.AllocateIP no available IP")
+				log.Warnw("IPRepo.AllocateIP no available IP", "region", region)
 			} else {
 				log.Errorw("IPRepo.AllocateIP query failed", "error", err)
 			}
@@ -57,8 +59,9 @@ func (r *ipRepo) ReleaseIP(ctx context.Context, ipID uint) error {
 	log := logging.S(ctx)
 	log.Infow("IPRepo.ReleaseIP called", "ipID", ipID)
 	err := r.db.WithContext(ctx).Model(&IPAddress{}).Where("id = ?", ipID).Updates(map[string]interface{}{
-		"allocated": false,
-		"server_id": nil,
+		"allocated":         false,
+		"server_id":         nil,
+		"quarantined_until": nil,
 	}).Error
 	if err != nil {
 		log.Errorw("IPRepo.ReleaseIP failed", "ipID", ipID, "error", err)
@@ -66,6 +69,22 @@ func (r *ipRepo) ReleaseIP(ctx context.Context, ipID uint) error {
 	return err
 }
 
+// QuarantineIP releases an IP but keeps it from being reallocated until the given time, so
+// that traffic still addressed to its previous server does not reach a new one
+func (r *ipRepo) QuarantineIP(ctx context.Context, ipID uint, until time.Time) error {
+	log := logging.S(ctx)
+	log.Infow("IPRepo.QuarantineIP called", "ipID", ipID, "until", until)
+	err := r.db.WithContext(ctx).Model(&IPAddress{}).Where("id = ?", ipID).Updates(map[string]interface{}{
+		"allocated":         false,
+		"server_id":         nil,
+		"quarantined_until": until,
+	}).Error
+	if err != nil {
+		log.Errorw("IPRepo.QuarantineIP failed", "ipID", ipID, "error", err)
+	}
+	return err
+}
+
 // AssignIPToServer links an IP to a server record
 func (r *ipRepo) AssignIPToServer(ctx context.Context, ipID uint, serverID string) error {
 	log := logging.S(ctx)
@@ -3,7 +3,6 @@ package persistence
 import (
 	"context"
 	"reflect"
-	"sync"
 	"testing"
 
 	"gorm.io/gorm"
@@ -12,10 +11,10 @@ import (
 func Test_ipRepo_AllocateIP(t *testing.T) {
 	type fields struct {
 		db *gorm.DB
-		mu sync.Mutex
 	}
 	type args struct {
-		ctx context.Context
+		ctx    context.Context
+		region string
 	}
 	tests := []struct {
 		name    string
@@ -30,9 +29,8 @@ func Test_ipRepo_AllocateIP(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := &ipRepo{
 				db: tt.fields.db,
-				mu: tt.fields.mu,
 			}
-			got, err := r.AllocateIP(tt.args.ctx)
+			got, err := r.AllocateIP(tt.args.ctx, tt.args.region)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ipRepo.AllocateIP() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -47,7 +45,6 @@ func Test_ipRepo_AllocateIP(t *testing.T) {
 func Test_ipRepo_ReleaseIP(t *testing.T) {
 	type fields struct {
 		db *gorm.DB
-		mu sync.Mutex
 	}
 	type args struct {
 		ctx  context.Context
@@ -65,7 +62,6 @@ func Test_ipRepo_ReleaseIP(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := &ipRepo{
 				db: tt.fields.db,
-				mu: tt.fields.mu,
 			}
 			if err := r.ReleaseIP(tt.args.ctx, tt.args.ipID); (err != nil) != tt.wantErr {
 				t.Errorf("ipRepo.ReleaseIP() error = %v, wantErr %v", err, tt.wantErr)
@@ -77,7 +73,6 @@ func Test_ipRepo_ReleaseIP(t *testing.T) {
 func Test_ipRepo_AssignIPToServer(t *testing.T) {
 	type fields struct {
 		db *gorm.DB
-		mu sync.Mutex
 	}
 	type args struct {
 		ctx      context.Context
@@ -96,7 +91,6 @@ func Test_ipRepo_AssignIPToServer(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := &ipRepo{
 				db: tt.fields.db,
-				mu: tt.fields.mu,
 			}
 			if err := r.AssignIPToServer(tt.args.ctx, tt.args.ipID, tt.args.serverID); (err != nil) != tt.wantErr {
 				t.Errorf("ipRepo.AssignIPToServer() error = %v, wantErr %v", err, tt.wantErr)
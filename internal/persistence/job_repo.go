@@ -0,0 +1,63 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/rhythin/sever-management/internal/logging"
+	"gorm.io/gorm"
+)
+
+// JobRunRepo records the history of background job runs
+
+type jobRunRepo struct {
+	db *gorm.DB
+}
+
+func NewJobRunRepo(db *gorm.DB) JobRunRepo {
+	return &jobRunRepo{db: db}
+}
+
+func (r *jobRunRepo) Create(ctx context.Context, run *JobRun) error {
+	log := logging.S(ctx)
+	log.Debugw("JobRunRepo.Create called", "job", run.Job, "trigger", run.Trigger)
+	err := r.db.WithContext(ctx).Create(run).Error
+	if err != nil {
+		log.Errorw("JobRunRepo.Create failed", "job", run.Job, "error", err)
+	}
+	return err
+}
+
+// Finish records a run's outcome
+func (r *jobRunRepo) Finish(ctx context.Context, run *JobRun) error {
+	log := logging.S(ctx)
+	log.Debugw("JobRunRepo.Finish called", "id", run.ID, "job", run.Job, "status", run.Status)
+	err := r.db.WithContext(ctx).Model(run).Select("Status", "Error", "FinishedAt").Updates(run).Error
+	if err != nil {
+		log.Errorw("JobRunRepo.Finish failed", "id", run.ID, "error", err)
+	}
+	return err
+}
+
+// List returns a job's most recent runs, newest first
+func (r *jobRunRepo) List(ctx context.Context, job string, limit int) ([]*JobRun, error) {
+	log := logging.S(ctx)
+	log.Debugw("JobRunRepo.List called", "job", job, "limit", limit)
+	var runs []*JobRun
+	err := r.db.WithContext(ctx).Where("job = ?", job).Order("id DESC").Limit(limit).Find(&runs).Error
+	if err != nil {
+		log.Errorw("JobRunRepo.List failed", "job", job, "error", err)
+	}
+	return runs, err
+}
+
+// Prune deletes all but a job's most recent keep runs
+func (r *jobRunRepo) Prune(ctx context.Context, job string, keep int) error {
+	log := logging.S(ctx)
+	log.Debugw("JobRunRepo.Prune called", "job", job, "keep", keep)
+	kept := r.db.Model(&JobRun{}).Select("id").Where("job = ?", job).Order("id DESC").Limit(keep)
+	err := r.db.WithContext(ctx).Where("job = ? AND id NOT IN (?)", job, kept).Delete(&JobRun{}).Error
+	if err != nil {
+		log.Errorw("JobRunRepo.Prune failed", "job", job, "error", err)
+	}
+	return err
+}
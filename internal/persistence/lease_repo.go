@@ -0,0 +1,89 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"sync"
+
+	"github.com/rhythin/sever-management/internal/logging"
+	"gorm.io/gorm"
+)
+
+// LeaseRepo hands out named leases backed by Postgres session-level advisory locks, so that
+// at most one replica runs a singleton job at a time. A lease is held on a dedicated
+// connection and lapses when that connection closes, including when the process dies.
+
+type leaseRepo struct {
+	db    *gorm.DB
+	mu    sync.Mutex
+	conns map[string]*sql.Conn // connections holding this process's leases, by name
+}
+
+func NewLeaseRepo(db *gorm.DB) LeaseRepo {
+	return &leaseRepo{db: db, conns: make(map[string]*sql.Conn)}
+}
+
+// TryAcquire takes the named lease, or confirms that this process still holds it. It
+// reports false without waiting when another replica holds the lease.
+func (r *leaseRepo) TryAcquire(ctx context.Context, name string) (bool, error) {
+	log := logging.S(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if conn, ok := r.conns[name]; ok {
+		if err := conn.PingContext(ctx); err == nil {
+			return true, nil
+		}
+		// The connection broke, and the lock went with it
+		log.Warnw("LeaseRepo lost lease", "name", name)
+		conn.Close()
+		delete(r.conns, name)
+	}
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		log.Errorw("LeaseRepo.TryAcquire failed to get a connection", "name", name, "error", err)
+		return false, err
+	}
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", leaseKey(name)).Scan(&acquired); err != nil {
+		conn.Close()
+		log.Errorw("LeaseRepo.TryAcquire failed", "name", name, "error", err)
+		return false, err
+	}
+	if !acquired {
+		conn.Close()
+		return false, nil
+	}
+	log.Infow("LeaseRepo acquired lease", "name", name)
+	r.conns[name] = conn
+	return true, nil
+}
+
+// Release gives up the named lease if this process holds it
+func (r *leaseRepo) Release(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	conn, ok := r.conns[name]
+	if !ok {
+		return nil
+	}
+	delete(r.conns, name)
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", leaseKey(name)); err != nil {
+		logging.S(ctx).Errorw("LeaseRepo.Release failed", "name", name, "error", err)
+		return err
+	}
+	logging.S(ctx).Infow("LeaseRepo released lease", "name", name)
+	return nil
+}
+
+// leaseKey maps a lease name to an advisory lock key
+func leaseKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
@@ -62,6 +62,36 @@ func (_m *EventRepo) LastN(ctx context.Context, serverID string, n int) ([]persi
 	return r0, r1
 }
 
+// Query provides a mock function with given fields: ctx, q
+func (_m *EventRepo) Query(ctx context.Context, q persistence.EventQuery) ([]persistence.EventLog, error) {
+	ret := _m.Called(ctx, q)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Query")
+	}
+
+	var r0 []persistence.EventLog
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, persistence.EventQuery) ([]persistence.EventLog, error)); ok {
+		return rf(ctx, q)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, persistence.EventQuery) []persistence.EventLog); ok {
+		r0 = rf(ctx, q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]persistence.EventLog)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, persistence.EventQuery) error); ok {
+		r1 = rf(ctx, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewEventRepo creates a new instance of EventRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewEventRepo(t interface {
@@ -4,6 +4,7 @@ package mocks
 
 import (
 	context "context"
+	time "time"
 
 	persistence "github.com/rhythin/sever-management/internal/persistence"
 	mock "github.com/stretchr/testify/mock"
@@ -14,9 +15,9 @@ type IPRepoInterface struct {
 	mock.Mock
 }
 
-// AllocateIP provides a mock function with given fields: ctx
-func (_m *IPRepoInterface) AllocateIP(ctx context.Context) (*persistence.IPAddress, error) {
-	ret := _m.Called(ctx)
+// AllocateIP provides a mock function with given fields: ctx, region
+func (_m *IPRepoInterface) AllocateIP(ctx context.Context, region string) (*persistence.IPAddress, error) {
+	ret := _m.Called(ctx, region)
 
 	if len(ret) == 0 {
 		panic("no return value specified for AllocateIP")
@@ -24,19 +25,19 @@ func (_m *IPRepoInterface) AllocateIP(ctx context.Context) (*persistence.IPAddre
 
 	var r0 *persistence.IPAddress
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) (*persistence.IPAddress, error)); ok {
-		return rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*persistence.IPAddress, error)); ok {
+		return rf(ctx, region)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) *persistence.IPAddress); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *persistence.IPAddress); ok {
+		r0 = rf(ctx, region)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*persistence.IPAddress)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, region)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -62,6 +63,24 @@ func (_m *IPRepoInterface) AssignIPToServer(ctx context.Context, ipID uint, serv
 	return r0
 }
 
+// QuarantineIP provides a mock function with given fields: ctx, id, until
+func (_m *IPRepoInterface) QuarantineIP(ctx context.Context, id uint, until time.Time) error {
+	ret := _m.Called(ctx, id, until)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QuarantineIP")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, time.Time) error); ok {
+		r0 = rf(ctx, id, until)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ReleaseIP provides a mock function with given fields: ctx, id
 func (_m *IPRepoInterface) ReleaseIP(ctx context.Context, id uint) error {
 	ret := _m.Called(ctx, id)
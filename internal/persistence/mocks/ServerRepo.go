@@ -4,11 +4,10 @@ package mocks
 
 import (
 	context "context"
+	time "time"
 
 	persistence "github.com/rhythin/sever-management/internal/persistence"
 	mock "github.com/stretchr/testify/mock"
-
-	time "time"
 )
 
 // ServerRepo is an autogenerated mock type for the ServerRepo type
@@ -16,6 +15,24 @@ type ServerRepo struct {
 	mock.Mock
 }
 
+// ClearReapSchedule provides a mock function with given fields: ctx, id
+func (_m *ServerRepo) ClearReapSchedule(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClearReapSchedule")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Create provides a mock function with given fields: ctx, server
 func (_m *ServerRepo) Create(ctx context.Context, server *persistence.Server) error {
 	ret := _m.Called(ctx, server)
@@ -34,6 +51,24 @@ func (_m *ServerRepo) Create(ctx context.Context, server *persistence.Server) er
 	return r0
 }
 
+// DetachIP provides a mock function with given fields: ctx, id
+func (_m *ServerRepo) DetachIP(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DetachIP")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetByID provides a mock function with given fields: ctx, id
 func (_m *ServerRepo) GetByID(ctx context.Context, id string) (*persistence.Server, error) {
 	ret := _m.Called(ctx, id)
@@ -94,17 +129,47 @@ func (_m *ServerRepo) List(ctx context.Context, region string, status string, ty
 	return r0, r1
 }
 
-// UpdateBilling provides a mock function with given fields: ctx, id, accumulatedSeconds, totalCost
-func (_m *ServerRepo) UpdateBilling(ctx context.Context, id string, accumulatedSeconds int64, totalCost float64) error {
-	ret := _m.Called(ctx, id, accumulatedSeconds, totalCost)
+// ListMatching provides a mock function with given fields: ctx, region, state, labels
+func (_m *ServerRepo) ListMatching(ctx context.Context, region string, state string, labels map[string]string) ([]*persistence.Server, error) {
+	ret := _m.Called(ctx, region, state, labels)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListMatching")
+	}
+
+	var r0 []*persistence.Server
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, map[string]string) ([]*persistence.Server, error)); ok {
+		return rf(ctx, region, state, labels)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, map[string]string) []*persistence.Server); ok {
+		r0 = rf(ctx, region, state, labels)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*persistence.Server)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, map[string]string) error); ok {
+		r1 = rf(ctx, region, state, labels)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateReapSchedule provides a mock function with given fields: ctx, id, version, reapAfter, reapWarnedFor
+func (_m *ServerRepo) UpdateReapSchedule(ctx context.Context, id string, version int64, reapAfter *time.Time, reapWarnedFor *time.Time) error {
+	ret := _m.Called(ctx, id, version, reapAfter, reapWarnedFor)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdateBilling")
+		panic("no return value specified for UpdateReapSchedule")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, int64, float64) error); ok {
-		r0 = rf(ctx, id, accumulatedSeconds, totalCost)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, *time.Time, *time.Time) error); ok {
+		r0 = rf(ctx, id, version, reapAfter, reapWarnedFor)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -130,17 +195,17 @@ func (_m *ServerRepo) UpdateServer(ctx context.Context, id string, updates *pers
 	return r0
 }
 
-// UpdateState provides a mock function with given fields: ctx, id, state
-func (_m *ServerRepo) UpdateState(ctx context.Context, id string, state string) error {
-	ret := _m.Called(ctx, id, state)
+// UpdateState provides a mock function with given fields: ctx, id, version, state
+func (_m *ServerRepo) UpdateState(ctx context.Context, id string, version int64, state string) error {
+	ret := _m.Called(ctx, id, version, state)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpdateState")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
-		r0 = rf(ctx, id, state)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, string) error); ok {
+		r0 = rf(ctx, id, version, state)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -0,0 +1,25 @@
+package mocks
+
+import (
+	"context"
+
+	persistence "github.com/rhythin/sever-management/internal/persistence"
+)
+
+// UnitOfWork is an in-memory persistence.UnitOfWork that hands its repositories
+// straight to the callback. Nothing is rolled back on error.
+type UnitOfWork struct {
+	Repos persistence.Repos
+}
+
+// NewUnitOfWork returns a UnitOfWork over the given (usually mocked) repositories
+func NewUnitOfWork(repos persistence.Repos) *UnitOfWork {
+	return &UnitOfWork{Repos: repos}
+}
+
+// Do provides a mock function with given fields: ctx, fn
+func (u *UnitOfWork) Do(ctx context.Context, fn func(tx persistence.Repos) error) error {
+	return fn(u.Repos)
+}
+
+var _ persistence.UnitOfWork = (*UnitOfWork)(nil)
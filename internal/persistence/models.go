@@ -2,24 +2,30 @@ package persistence
 
 import (
 	"time"
+
+	"github.com/rhythin/sever-management/internal/domain"
 )
 
 // Server represents a virtual server instance in the DB
 
 type Server struct {
-	ID           string `gorm:"primaryKey;type:text"`
-	Region       string
-	Type         string
-	IPID         *uint // Foreign key to IPAddress
-	IP           *IPAddress
-	State        string
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	StartedAt    *time.Time
-	StoppedAt    *time.Time
-	TerminatedAt *time.Time
-	Billing      *Billing    `gorm:"foreignKey:ServerID"`
-	Events       []*EventLog `gorm:"foreignKey:ServerID"`
+	ID            string `gorm:"primaryKey;type:text"`
+	Region        string
+	Type          string
+	IPID          *uint // Foreign key to IPAddress
+	IP            *IPAddress
+	State         string
+	Version       int64 `gorm:"not null;default:1"` // bumped on every state change, for optimistic concurrency
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	StartedAt     *time.Time
+	StoppedAt     *time.Time
+	TerminatedAt  *time.Time
+	ReapAfter     *time.Time     // set by postponement; the idle reaper leaves the server until then
+	ReapWarnedFor *time.Time     // the reap time the last reap warning announced
+	Billing       *Billing       `gorm:"foreignKey:ServerID"`
+	Events        []*EventLog    `gorm:"foreignKey:ServerID;constraint:-"` // fleet-level events have no server
+	Labels        []*ServerLabel `gorm:"foreignKey:ServerID"`
 }
 
 // TableName specifies the table name for Server
@@ -27,13 +33,29 @@ func (Server) TableName() string {
 	return "servers"
 }
 
+// ServerLabel is a key/value label on a server, such as team=payments
+
+type ServerLabel struct {
+	ServerID string `gorm:"primaryKey;type:text"`
+	Key      string `gorm:"primaryKey"`
+	Value    string
+}
+
+// TableName specifies the table name for ServerLabel
+func (ServerLabel) TableName() string {
+	return "server_labels"
+}
+
 // IPAddress tracks allocated IPs
 
 type IPAddress struct {
 	ID        uint   `gorm:"primaryKey;autoIncrement"`
 	Address   string `gorm:"uniqueIndex"`
+	CIDRID    *uint  `gorm:"column:cidr_id;index"` // the pool CIDR it belongs to; nil for addresses no pool covers
 	Allocated bool
 	ServerID  *string // Nullable, FK to Server
+	// QuarantinedUntil keeps a released address from being reallocated until then
+	QuarantinedUntil *time.Time
 }
 
 // TableName specifies the table name for IPAddress
@@ -41,6 +63,39 @@ func (IPAddress) TableName() string {
 	return "ip_addresses"
 }
 
+// IPPool is a named set of CIDRs whose addresses are allocated to servers in its region only
+
+type IPPool struct {
+	ID        string        `gorm:"primaryKey;type:text"`
+	Name      string        `gorm:"uniqueIndex"`
+	Region    string        `gorm:"index"`
+	CIDRs     []*IPPoolCIDR `gorm:"foreignKey:PoolID"`
+	CreatedAt time.Time
+}
+
+// TableName specifies the table name for IPPool
+func (IPPool) TableName() string {
+	return "ip_pools"
+}
+
+// IPPoolCIDR is a CIDR block in a pool. Its usable addresses are created with it; once
+// retired they are no longer allocated, and addresses in use keep their servers.
+
+type IPPoolCIDR struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	PoolID    string `gorm:"index"`
+	CIDR      string `gorm:"column:cidr;uniqueIndex"`
+	CreatedAt time.Time
+	RetiredAt *time.Time
+	Addresses int64 `gorm:"-"` // usable addresses, filled in by IPPoolRepo reads
+	Allocated int64 `gorm:"-"` // addresses in use
+}
+
+// TableName specifies the table name for IPPoolCIDR
+func (IPPoolCIDR) TableName() string {
+	return "ip_pool_cidrs"
+}
+
 // Billing tracks server cost and uptime
 
 type Billing struct {
@@ -48,7 +103,8 @@ type Billing struct {
 	ServerID           string `gorm:"uniqueIndex"`
 	AccumulatedSeconds int64
 	LastBilledAt       *time.Time
-	TotalCost          float64
+	TotalCost          domain.Micros `gorm:"column:total_cost_micros"`
+	Currency           string
 }
 
 // TableName specifies the table name for Billing
@@ -59,14 +115,311 @@ func (Billing) TableName() string {
 // EventLog stores server lifecycle events
 
 type EventLog struct {
-	ID        uint   `gorm:"primaryKey;autoIncrement"`
-	ServerID  string `gorm:"index"`
-	Timestamp time.Time
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	ServerID  string    `gorm:"index"`
+	Stream    string    `gorm:"not null;default:'';uniqueIndex:idx_event_logs_stream_sequence,priority:1"` // sequence scope: the server ID, or budget:<id> for budget events
+	Sequence  int64     `gorm:"not null;default:0;uniqueIndex:idx_event_logs_stream_sequence,priority:2"`  // per-stream, assigned on append
+	Timestamp time.Time `gorm:"index"`
 	Type      string
 	Message   string
+	FromState string
+	ToState   string
+	Action    string
+	Cause     string // why a server was terminated: user or reaper
+	RequestID string
 }
 
 // TableName specifies the table name for EventLog
 func (EventLog) TableName() string {
 	return "event_logs"
 }
+
+// Operation tracks an asynchronous request such as provisioning
+
+type Operation struct {
+	ID          string `gorm:"primaryKey;type:text"`
+	Type        string
+	ServerID    string `gorm:"index"`
+	Status      string `gorm:"index"`
+	Error       string
+	RequestID   string    // request that started the operation, carried onto its events
+	DueAt       time.Time // when the worker should complete the operation
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	CompletedAt *time.Time
+}
+
+// TableName specifies the table name for Operation
+func (Operation) TableName() string {
+	return "operations"
+}
+
+// Region is a managed region with a status and server capacity
+
+type Region struct {
+	Name       string `gorm:"primaryKey;type:text"`
+	Status     string
+	MaxServers int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// TableName specifies the table name for Region
+func (Region) TableName() string {
+	return "regions"
+}
+
+// Webhook is a registered receiver of server events
+
+type Webhook struct {
+	ID         string `gorm:"primaryKey;type:text"`
+	URL        string
+	EventTypes string // comma-separated; empty subscribes to every type
+	Secret     string // HMAC key for signing deliveries
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// TableName specifies the table name for Webhook
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// WebhookDelivery is one event queued for, or delivered to, a webhook
+
+type WebhookDelivery struct {
+	ID             uint   `gorm:"primaryKey;autoIncrement"`
+	WebhookID      string `gorm:"type:text;uniqueIndex:idx_webhook_deliveries_event,priority:1"`
+	Webhook        *Webhook
+	EventID        uint `gorm:"uniqueIndex:idx_webhook_deliveries_event,priority:2"` // an event is delivered to a webhook once
+	EventType      string
+	Payload        string
+	Status         string `gorm:"index:idx_webhook_deliveries_due,priority:1"`
+	Attempts       int
+	NextAttemptAt  time.Time `gorm:"index:idx_webhook_deliveries_due,priority:2"`
+	ResponseStatus int       // HTTP status of the last attempt, 0 if it got no response
+	LastError      string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+// TableName specifies the table name for WebhookDelivery
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// OutboxEntry queues a committed event for publication to downstream sinks. It is written
+// in the same transaction as the event, so an event is published if and only if it commits.
+
+type OutboxEntry struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement"`
+	EventID      uint      `gorm:"not null"`
+	Event        *EventLog `gorm:"foreignKey:EventID"`
+	Region       string    // region of the event's server, for sink-side filtering
+	Attempts     int       // failed dispatch attempts
+	LastError    string
+	CreatedAt    time.Time  `gorm:"index"` // every replica tails recent entries for its stream bus
+	DispatchedAt *time.Time `gorm:"index"` // nil until every sink has accepted the event
+}
+
+// TableName specifies the table name for OutboxEntry
+func (OutboxEntry) TableName() string {
+	return "outbox"
+}
+
+// UsageSession is one continuous period of billable uptime, opened when a server starts
+// and closed when it stops or terminates. Billing totals are the sum of a server's sessions.
+
+type UsageSession struct {
+	ID            uint   `gorm:"primaryKey;autoIncrement"`
+	ServerID      string `gorm:"index;uniqueIndex:idx_usage_sessions_open,where:ended_at IS NULL"` // at most one open session per server
+	Type          string // server type and region, which set the rate
+	Region        string
+	StartedAt     time.Time
+	EndedAt       *time.Time // nil while the server is up
+	LastBilledAt  time.Time  // uptime is billed incrementally from here
+	BilledSeconds int64
+	Cost          domain.Micros `gorm:"column:cost_micros"`
+	Currency      string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// TableName specifies the table name for UsageSession
+func (UsageSession) TableName() string {
+	return "usage_sessions"
+}
+
+// Price is an hourly price for a server type, in one region or in all regions when Region is
+// empty, from EffectiveFrom until the next price for the same type and region takes effect
+
+type Price struct {
+	ID            uint          `gorm:"primaryKey;autoIncrement"`
+	ServerType    string        `gorm:"uniqueIndex:idx_prices_key"`
+	Region        string        `gorm:"uniqueIndex:idx_prices_key"`
+	HourlyPrice   domain.Micros `gorm:"column:hourly_price_micros"`
+	Currency      string
+	EffectiveFrom time.Time `gorm:"uniqueIndex:idx_prices_key"`
+	CreatedAt     time.Time
+}
+
+// TableName specifies the table name for Price
+func (Price) TableName() string {
+	return "prices"
+}
+
+// UsageCharge is a span of a usage session's billed uptime at one rate within one billing
+// period. Each billing increment is recorded as one or more charges, which sum to it.
+
+type UsageCharge struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	SessionID uint   `gorm:"index"`
+	ServerID  string `gorm:"index"`
+	Type      string
+	Region    string
+	Period    string `gorm:"index:idx_usage_charges_uninvoiced,where:invoice_id IS NULL"` // YYYY-MM, the month the uptime ran in
+	StartedAt time.Time
+	Seconds   int64
+	Rate      domain.Micros `gorm:"column:rate_micros"` // hourly
+	Amount    domain.Micros `gorm:"column:amount_micros"`
+	Currency  string
+	InvoiceID *uint `gorm:"index"` // set when a closed invoice takes the charge
+	CreatedAt time.Time
+}
+
+// TableName specifies the table name for UsageCharge
+func (UsageCharge) TableName() string {
+	return "usage_charges"
+}
+
+// Invoice bills the usage charges of one period
+
+type Invoice struct {
+	ID          uint   `gorm:"primaryKey;autoIncrement"`
+	Period      string `gorm:"uniqueIndex"` // YYYY-MM
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Status      string
+	Currency    string
+	Total       domain.Micros  `gorm:"column:total_micros"`
+	Lines       []*InvoiceLine `gorm:"foreignKey:InvoiceID"`
+	ClosedAt    *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// TableName specifies the table name for Invoice
+func (Invoice) TableName() string {
+	return "invoices"
+}
+
+// InvoiceLine is a server's uptime at one rate on an invoice. Lines with AdjustsPeriod set
+// carry charges for an earlier period that arrived after its invoice was closed.
+
+type InvoiceLine struct {
+	ID            uint `gorm:"primaryKey;autoIncrement"`
+	InvoiceID     uint `gorm:"index"`
+	ServerID      string
+	Type          string
+	Region        string
+	Seconds       int64
+	Rate          domain.Micros `gorm:"column:rate_micros"`
+	Amount        domain.Micros `gorm:"column:amount_micros"`
+	AdjustsPeriod string
+}
+
+// TableName specifies the table name for InvoiceLine
+func (InvoiceLine) TableName() string {
+	return "invoice_lines"
+}
+
+// Budget caps the monthly spend of the servers in a region, or every region, that carry all
+// of the selector's labels
+
+type Budget struct {
+	ID         string `gorm:"primaryKey;type:text"`
+	Name       string
+	Region     string            // empty for all regions
+	Selector   map[string]string `gorm:"serializer:json"`     // empty matches every server
+	Limit      domain.Micros     `gorm:"column:limit_micros"` // per calendar month
+	Currency   string
+	Thresholds []*BudgetThreshold `gorm:"foreignKey:BudgetID"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// TableName specifies the table name for Budget
+func (Budget) TableName() string {
+	return "budgets"
+}
+
+// BudgetThreshold is a percentage of a budget's limit and the action taken when spend reaches it
+
+type BudgetThreshold struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	BudgetID  string `gorm:"type:text;index"`
+	Percent   int
+	Action    string
+	WebhookID string // for the webhook action
+}
+
+// TableName specifies the table name for BudgetThreshold
+func (BudgetThreshold) TableName() string {
+	return "budget_thresholds"
+}
+
+// BudgetAlert records a threshold firing; each threshold fires at most once per period
+
+type BudgetAlert struct {
+	ID          uint   `gorm:"primaryKey;autoIncrement"`
+	BudgetID    string `gorm:"type:text;index"`
+	ThresholdID uint   `gorm:"uniqueIndex:idx_budget_alerts_threshold_period,priority:1"`
+	Period      string `gorm:"uniqueIndex:idx_budget_alerts_threshold_period,priority:2"` // YYYY-MM
+	Percent     int
+	Action      string
+	Spend       domain.Micros `gorm:"column:spend_micros"` // when the threshold fired
+	FiredAt     time.Time
+}
+
+// TableName specifies the table name for BudgetAlert
+func (BudgetAlert) TableName() string {
+	return "budget_alerts"
+}
+
+// ReaperPolicy sets the idle timeout after which stopped servers in its scope are reaped.
+// Empty scope fields match every server; among matching policies the most specific applies.
+
+type ReaperPolicy struct {
+	ID          string `gorm:"primaryKey;type:text"`
+	Name        string
+	Region      string            // empty for all regions
+	Type        string            // empty for all types
+	Selector    map[string]string `gorm:"serializer:json"` // labels servers must all have
+	IdleTimeout time.Duration     // stored in nanoseconds
+	DryRun      bool              // log and report matches without reaping them
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// TableName specifies the table name for ReaperPolicy
+func (ReaperPolicy) TableName() string {
+	return "reaper_policies"
+}
+
+// JobRun records one run of a background job, such as billing or the idle reaper
+
+type JobRun struct {
+	ID         uint   `gorm:"primaryKey;autoIncrement"`
+	Job        string `gorm:"index"`
+	Trigger    string // schedule or manual
+	Status     string // running, succeeded, failed or skipped
+	Error      string
+	StartedAt  time.Time
+	FinishedAt *time.Time // nil while running, or if the process died mid-run
+}
+
+// TableName specifies the table name for JobRun
+func (JobRun) TableName() string {
+	return "job_runs"
+}
@@ -0,0 +1,82 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"gorm.io/gorm"
+)
+
+// OperationRepo handles asynchronous operation persistence
+
+type operationRepo struct {
+	db *gorm.DB
+}
+
+func NewOperationRepo(db *gorm.DB) OperationRepo {
+	return &operationRepo{db: db}
+}
+
+func (r *operationRepo) Create(ctx context.Context, op *Operation) error {
+	log := logging.S(ctx)
+
+	op.ID = uuid.New().String()
+	log.Infow("OperationRepo.Create called", "id", op.ID, "type", op.Type, "serverID", op.ServerID)
+	err := r.db.WithContext(ctx).Create(op).Error
+	if err != nil {
+		log.Errorw("OperationRepo.Create failed", "id", op.ID, "error", err)
+	}
+	return err
+}
+
+func (r *operationRepo) GetByID(ctx context.Context, id string) (*Operation, error) {
+	log := logging.S(ctx)
+	log.Debugw("OperationRepo.GetByID called", "id", id)
+	var op Operation
+	err := r.db.WithContext(ctx).First(&op, "id = ?", id).Error
+	if err != nil {
+		log.Warnw("OperationRepo.GetByID not found or error", "id", id, "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &op, nil
+}
+
+// ListPending returns pending operations, oldest first
+func (r *operationRepo) ListPending(ctx context.Context, limit int) ([]*Operation, error) {
+	log := logging.S(ctx)
+	log.Debugw("OperationRepo.ListPending called", "limit", limit)
+	var ops []*Operation
+	err := r.db.WithContext(ctx).
+		Where("status = ?", string(domain.OperationPending)).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&ops).Error
+	if err != nil {
+		log.Errorw("OperationRepo.ListPending failed", "error", err)
+	}
+	return ops, err
+}
+
+// Complete records the terminal status of a pending operation
+func (r *operationRepo) Complete(ctx context.Context, id string, status string, errMsg string) error {
+	log := logging.S(ctx)
+	log.Infow("OperationRepo.Complete called", "id", id, "status", status)
+	err := r.db.WithContext(ctx).Model(&Operation{}).
+		Where("id = ? AND status = ?", id, string(domain.OperationPending)).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"error":        errMsg,
+			"completed_at": time.Now(),
+		}).Error
+	if err != nil {
+		log.Errorw("OperationRepo.Complete failed", "id", id, "error", err)
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"github.com/rhythin/sever-management/internal/logging"
+	"gorm.io/gorm"
+)
+
+// OutboxRepo handles the transactional event outbox
+
+type outboxRepo struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepo(db *gorm.DB) OutboxRepo {
+	return &outboxRepo{db: db}
+}
+
+// Add queues an event for publication; callers add it in the transaction that appended the event
+func (r *outboxRepo) Add(ctx context.Context, entry *OutboxEntry) error {
+	log := logging.S(ctx)
+	log.Debugw("OutboxRepo.Add called", "eventID", entry.EventID, "region", entry.Region)
+	err := r.db.WithContext(ctx).Omit("Event").Create(entry).Error
+	if err != nil {
+		log.Errorw("OutboxRepo.Add failed", "eventID", entry.EventID, "error", err)
+	}
+	return err
+}
+
+// ListPending returns undispatched entries in ID order with their events loaded. IDs are taken
+// before commit, so an entry that commits late can sort before entries already dispatched.
+func (r *outboxRepo) ListPending(ctx context.Context, limit int) ([]*OutboxEntry, error) {
+	log := logging.S(ctx)
+	log.Debugw("OutboxRepo.ListPending called", "limit", limit)
+	var entries []*OutboxEntry
+	err := r.db.WithContext(ctx).Preload("Event").
+		Where("dispatched_at IS NULL").
+		Order("id ASC").
+		Limit(limit).
+		Find(&entries).Error
+	if err != nil {
+		log.Errorw("OutboxRepo.ListPending failed", "error", err)
+	}
+	return entries, err
+}
+
+// ListSince returns the entries created at or after since with IDs above afterID, dispatched
+// or not, in ID order with their events loaded
+func (r *outboxRepo) ListSince(ctx context.Context, since time.Time, afterID uint, limit int) ([]*OutboxEntry, error) {
+	log := logging.S(ctx)
+	log.Debugw("OutboxRepo.ListSince called", "since", since, "afterID", afterID, "limit", limit)
+	var entries []*OutboxEntry
+	err := r.db.WithContext(ctx).Preload("Event").
+		Where("created_at >= ? AND id > ?", since, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&entries).Error
+	if err != nil {
+		log.Errorw("OutboxRepo.ListSince failed", "error", err)
+	}
+	return entries, err
+}
+
+func (r *outboxRepo) MarkDispatched(ctx context.Context, id uint) error {
+	log := logging.S(ctx)
+	log.Debugw("OutboxRepo.MarkDispatched called", "id", id)
+	err := r.db.WithContext(ctx).Model(&OutboxEntry{}).Where("id = ?", id).Update("dispatched_at", time.Now()).Error
+	if err != nil {
+		log.Errorw("OutboxRepo.MarkDispatched failed", "id", id, "error", err)
+	}
+	return err
+}
+
+// RecordFailure counts a failed dispatch attempt; the entry stays pending
+func (r *outboxRepo) RecordFailure(ctx context.Context, id uint, errMsg string) error {
+	log := logging.S(ctx)
+	log.Debugw("OutboxRepo.RecordFailure called", "id", id)
+	err := r.db.WithContext(ctx).Model(&OutboxEntry{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": errMsg,
+	}).Error
+	if err != nil {
+		log.Errorw("OutboxRepo.RecordFailure failed", "id", id, "error", err)
+	}
+	return err
+}
+
+// PurgeDispatched deletes entries dispatched before the given time and returns how many were removed
+func (r *outboxRepo) PurgeDispatched(ctx context.Context, before time.Time) (int64, error) {
+	log := logging.S(ctx)
+	log.Debugw("OutboxRepo.PurgeDispatched called", "before", before)
+	res := r.db.WithContext(ctx).Where("dispatched_at < ?", before).Delete(&OutboxEntry{})
+	if res.Error != nil {
+		log.Errorw("OutboxRepo.PurgeDispatched failed", "error", res.Error)
+	}
+	return res.RowsAffected, res.Error
+}
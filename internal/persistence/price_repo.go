@@ -0,0 +1,74 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rhythin/sever-management/internal/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrPriceExists is returned when a type and region already have a price taking effect at that time
+var ErrPriceExists = errors.New("a price already takes effect at that time")
+
+// PriceRepo handles the price book
+
+type priceRepo struct {
+	db *gorm.DB
+}
+
+func NewPriceRepo(db *gorm.DB) PriceRepo {
+	return &priceRepo{db: db}
+}
+
+func (r *priceRepo) Create(ctx context.Context, price *Price) error {
+	log := logging.S(ctx)
+	log.Infow("PriceRepo.Create called", "type", price.ServerType, "region", price.Region, "effectiveFrom", price.EffectiveFrom)
+	res := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(price)
+	if res.Error != nil {
+		log.Errorw("PriceRepo.Create failed", "type", price.ServerType, "region", price.Region, "error", res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrPriceExists
+	}
+	return nil
+}
+
+// List returns price history, optionally for one type and region, ordered by type, region
+// and effective time
+func (r *priceRepo) List(ctx context.Context, serverType, region string) ([]*Price, error) {
+	log := logging.S(ctx)
+	log.Debugw("PriceRepo.List called", "type", serverType, "region", region)
+	q := r.db.WithContext(ctx)
+	if serverType != "" {
+		q = q.Where("server_type = ?", serverType)
+	}
+	if region != "" {
+		q = q.Where("region = ?", region)
+	}
+	var prices []*Price
+	err := q.Order("server_type ASC, region ASC, effective_from ASC").Find(&prices).Error
+	if err != nil {
+		log.Errorw("PriceRepo.List failed", "error", err)
+	}
+	return prices, err
+}
+
+// ListEffective returns a type's prices for the region and for all regions that take effect
+// before the given time, oldest first
+func (r *priceRepo) ListEffective(ctx context.Context, serverType, region string, before time.Time) ([]*Price, error) {
+	log := logging.S(ctx)
+	log.Debugw("PriceRepo.ListEffective called", "type", serverType, "region", region, "before", before)
+	var prices []*Price
+	err := r.db.WithContext(ctx).
+		Where("server_type = ? AND region IN (?, '') AND effective_from < ?", serverType, region, before).
+		Order("effective_from ASC").
+		Find(&prices).Error
+	if err != nil {
+		log.Errorw("PriceRepo.ListEffective failed", "type", serverType, "region", region, "error", err)
+	}
+	return prices, err
+}
@@ -0,0 +1,79 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/rhythin/sever-management/internal/logging"
+	"gorm.io/gorm"
+)
+
+// ReaperPolicyRepo handles idle reaper policies
+
+type reaperPolicyRepo struct {
+	db *gorm.DB
+}
+
+func NewReaperPolicyRepo(db *gorm.DB) ReaperPolicyRepo {
+	return &reaperPolicyRepo{db: db}
+}
+
+func (r *reaperPolicyRepo) Create(ctx context.Context, policy *ReaperPolicy) error {
+	log := logging.S(ctx)
+
+	policy.ID = uuid.New().String()
+	log.Infow("ReaperPolicyRepo.Create called", "id", policy.ID, "name", policy.Name, "region", policy.Region, "type", policy.Type, "selector", policy.Selector)
+	err := r.db.WithContext(ctx).Create(policy).Error
+	if err != nil {
+		log.Errorw("ReaperPolicyRepo.Create failed", "id", policy.ID, "error", err)
+	}
+	return err
+}
+
+func (r *reaperPolicyRepo) GetByID(ctx context.Context, id string) (*ReaperPolicy, error) {
+	log := logging.S(ctx)
+	log.Debugw("ReaperPolicyRepo.GetByID called", "id", id)
+	var policy ReaperPolicy
+	err := r.db.WithContext(ctx).First(&policy, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Errorw("ReaperPolicyRepo.GetByID failed", "id", id, "error", err)
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *reaperPolicyRepo) List(ctx context.Context) ([]*ReaperPolicy, error) {
+	log := logging.S(ctx)
+	log.Debugw("ReaperPolicyRepo.List called")
+	var policies []*ReaperPolicy
+	err := r.db.WithContext(ctx).Order("created_at ASC").Find(&policies).Error
+	if err != nil {
+		log.Errorw("ReaperPolicyRepo.List failed", "error", err)
+	}
+	return policies, err
+}
+
+// Update replaces a policy's scope, timeout and mode
+func (r *reaperPolicyRepo) Update(ctx context.Context, policy *ReaperPolicy) error {
+	log := logging.S(ctx)
+	log.Infow("ReaperPolicyRepo.Update called", "id", policy.ID)
+	err := r.db.WithContext(ctx).Model(policy).Select("Name", "Region", "Type", "Selector", "IdleTimeout", "DryRun", "UpdatedAt").Updates(policy).Error
+	if err != nil {
+		log.Errorw("ReaperPolicyRepo.Update failed", "id", policy.ID, "error", err)
+	}
+	return err
+}
+
+func (r *reaperPolicyRepo) Delete(ctx context.Context, id string) error {
+	log := logging.S(ctx)
+	log.Infow("ReaperPolicyRepo.Delete called", "id", id)
+	err := r.db.WithContext(ctx).Delete(&ReaperPolicy{}, "id = ?", id).Error
+	if err != nil {
+		log.Errorw("ReaperPolicyRepo.Delete failed", "id", id, "error", err)
+	}
+	return err
+}
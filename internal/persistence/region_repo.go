@@ -0,0 +1,117 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RegionRepo handles the managed region registry
+
+type regionRepo struct {
+	db *gorm.DB
+}
+
+func NewRegionRepo(db *gorm.DB) RegionRepo {
+	return &regionRepo{db: db}
+}
+
+func (r *regionRepo) List(ctx context.Context) ([]*Region, error) {
+	log := logging.S(ctx)
+	log.Debugw("RegionRepo.List called")
+	var regions []*Region
+	err := r.db.WithContext(ctx).Order("name ASC").Find(&regions).Error
+	if err != nil {
+		log.Errorw("RegionRepo.List failed", "error", err)
+	}
+	return regions, err
+}
+
+func (r *regionRepo) GetByName(ctx context.Context, name string) (*Region, error) {
+	log := logging.S(ctx)
+	log.Debugw("RegionRepo.GetByName called", "name", name)
+	var region Region
+	err := r.db.WithContext(ctx).First(&region, "name = ?", name).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Errorw("RegionRepo.GetByName failed", "name", name, "error", err)
+		return nil, err
+	}
+	return &region, nil
+}
+
+// Update creates or replaces a region's status and capacity
+func (r *regionRepo) Update(ctx context.Context, name string, status string, maxServers int) error {
+	log := logging.S(ctx)
+	log.Infow("RegionRepo.Update called", "name", name, "status", status, "maxServers", maxServers)
+	region := Region{Name: name, Status: status, MaxServers: maxServers}
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "max_servers", "updated_at"}),
+	}).Create(&region).Error
+	if err != nil {
+		log.Errorw("RegionRepo.Update failed", "name", name, "error", err)
+	}
+	return err
+}
+
+// LockByName reads a region and locks its row until the enclosing transaction ends, so that
+// admissions to the same region are serialized. It returns nil if the region is not registered.
+func (r *regionRepo) LockByName(ctx context.Context, name string) (*Region, error) {
+	log := logging.S(ctx)
+	log.Debugw("RegionRepo.LockByName called", "name", name)
+	var region Region
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&region, "name = ?", name).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Errorw("RegionRepo.LockByName failed", "name", name, "error", err)
+		return nil, err
+	}
+	return &region, nil
+}
+
+// CountActiveInRegion returns the number of non-terminated servers in one region
+func (r *regionRepo) CountActiveInRegion(ctx context.Context, name string) (int64, error) {
+	log := logging.S(ctx)
+	log.Debugw("RegionRepo.CountActiveInRegion called", "name", name)
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Server{}).
+		Where("region = ? AND state <> ?", name, string(domain.ServerTerminated)).
+		Count(&count).Error
+	if err != nil {
+		log.Errorw("RegionRepo.CountActiveInRegion failed", "name", name, "error", err)
+	}
+	return count, err
+}
+
+// CountActiveServers returns the number of non-terminated servers per region
+func (r *regionRepo) CountActiveServers(ctx context.Context) (map[string]int64, error) {
+	log := logging.S(ctx)
+	log.Debugw("RegionRepo.CountActiveServers called")
+	var rows []struct {
+		Region string
+		Count  int64
+	}
+	err := r.db.WithContext(ctx).Model(&Server{}).
+		Select("region, COUNT(*) AS count").
+		Where("state <> ?", string(domain.ServerTerminated)).
+		Group("region").
+		Scan(&rows).Error
+	if err != nil {
+		log.Errorw("RegionRepo.CountActiveServers failed", "error", err)
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Region] = row.Count
+	}
+	return counts, nil
+}
@@ -10,6 +10,10 @@ import (
 	"gorm.io/gorm"
 )
 
+// ErrVersionConflict is returned when a compare-and-swap update finds the row
+// at a different version than the caller read
+var ErrVersionConflict = errors.New("server was modified concurrently")
+
 // ServerRepo handles server persistence
 
 type serverRepo struct {
@@ -36,7 +40,7 @@ func (r *serverRepo) GetByID(ctx context.Context, id string) (*Server, error) {
 	log := logging.S(ctx)
 	log.Debugw("ServerRepo.GetByID called", "id", id)
 	var s Server
-	err := r.db.WithContext(ctx).Preload("IP").Preload("Billing").Preload("Events").First(&s, "id = ?", id).Error
+	err := r.db.WithContext(ctx).Preload("IP").Preload("Billing").Preload("Events").Preload("Labels").First(&s, "id = ?", id).Error
 	if err != nil {
 		log.Warnw("ServerRepo.GetByID not found or error", "id", id, "error", err)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -47,21 +51,30 @@ func (r *serverRepo) GetByID(ctx context.Context, id string) (*Server, error) {
 	return &s, nil
 }
 
-func (r *serverRepo) UpdateState(ctx context.Context, id string, newState string) error {
+// UpdateState sets the state only if the server is still at version, bumping the version
+func (r *serverRepo) UpdateState(ctx context.Context, id string, version int64, newState string) error {
 	log := logging.S(ctx)
-	log.Infow("ServerRepo.UpdateState called", "id", id, "state", newState)
-	err := r.db.WithContext(ctx).Model(&Server{}).Where("id = ?", id).Update("state", newState).Error
-	if err != nil {
-		log.Errorw("ServerRepo.UpdateState failed", "id", id, "error", err)
+	log.Infow("ServerRepo.UpdateState called", "id", id, "version", version, "state", newState)
+	res := r.db.WithContext(ctx).Model(&Server{}).Where("id = ? AND version = ?", id, version).Updates(map[string]interface{}{
+		"state":   newState,
+		"version": gorm.Expr("version + 1"),
+	})
+	if res.Error != nil {
+		log.Errorw("ServerRepo.UpdateState failed", "id", id, "error", res.Error)
+		return res.Error
 	}
-	return err
+	if res.RowsAffected == 0 {
+		log.Warnw("ServerRepo.UpdateState version conflict", "id", id, "version", version)
+		return ErrVersionConflict
+	}
+	return nil
 }
 
 func (r *serverRepo) List(ctx context.Context, region, status, typ string, limit, offset int) ([]*Server, error) {
 	log := logging.S(ctx)
 	log.Debugw("ServerRepo.List called", "region", region, "status", status, "type", typ, "limit", limit, "offset", offset)
 	var servers []*Server
-	q := r.db.WithContext(ctx).Model(&Server{}).Preload("IP").Preload("Billing").Preload("Events")
+	q := r.db.WithContext(ctx).Model(&Server{}).Preload("IP").Preload("Billing").Preload("Events").Preload("Labels")
 	if region != "" {
 		q = q.Where("region = ?", region)
 	}
@@ -79,6 +92,23 @@ func (r *serverRepo) List(ctx context.Context, region, status, typ string, limit
 	return servers, err
 }
 
+// ListMatching returns the servers in a state, in region unless it is empty, that have all of
+// labels, with their labels
+func (r *serverRepo) ListMatching(ctx context.Context, region, state string, labels map[string]string) ([]*Server, error) {
+	log := logging.S(ctx)
+	log.Debugw("ServerRepo.ListMatching called", "region", region, "state", state, "labels", labels)
+	var servers []*Server
+	q := r.db.WithContext(ctx).Preload("Labels").Where("state = ?", state).Scopes(hasLabels("servers.id", labels))
+	if region != "" {
+		q = q.Where("region = ?", region)
+	}
+	err := q.Order("created_at ASC").Find(&servers).Error
+	if err != nil {
+		log.Errorw("ServerRepo.ListMatching failed", "error", err)
+	}
+	return servers, err
+}
+
 func (r *serverRepo) UpdateTimestamps(ctx context.Context, id string, started, stopped, terminated *time.Time) error {
 	log := logging.S(ctx)
 	log.Debugw("ServerRepo.UpdateTimestamps called", "id", id, "started", started, "stopped", stopped, "terminated", terminated)
@@ -102,16 +132,51 @@ func (r *serverRepo) UpdateTimestamps(ctx context.Context, id string, started, s
 	return err
 }
 
-func (r *serverRepo) UpdateBilling(ctx context.Context, id string, accumulatedSeconds int64, totalCost float64) error {
+// DetachIP clears a server's IP once the address has been released
+func (r *serverRepo) DetachIP(ctx context.Context, id string) error {
+	log := logging.S(ctx)
+	log.Debugw("ServerRepo.DetachIP called", "id", id)
+	err := r.db.WithContext(ctx).Model(&Server{}).Where("id = ?", id).Update("ip_id", nil).Error
+	if err != nil {
+		log.Errorw("ServerRepo.DetachIP failed", "id", id, "error", err)
+	}
+	return err
+}
+
+// UpdateReapSchedule sets whichever of a server's reap postponement and reap warning are
+// given, bumping its version. It returns ErrVersionConflict if the server has changed.
+func (r *serverRepo) UpdateReapSchedule(ctx context.Context, id string, version int64, reapAfter, reapWarnedFor *time.Time) error {
+	log := logging.S(ctx)
+	log.Debugw("ServerRepo.UpdateReapSchedule called", "id", id, "version", version, "reapAfter", reapAfter, "reapWarnedFor", reapWarnedFor)
+	updates := map[string]interface{}{"version": gorm.Expr("version + 1")}
+	if reapAfter != nil {
+		updates["reap_after"] = *reapAfter
+	}
+	if reapWarnedFor != nil {
+		updates["reap_warned_for"] = *reapWarnedFor
+	}
+	res := r.db.WithContext(ctx).Model(&Server{}).Where("id = ? AND version = ?", id, version).Updates(updates)
+	if res.Error != nil {
+		log.Errorw("ServerRepo.UpdateReapSchedule failed", "id", id, "error", res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		log.Warnw("ServerRepo.UpdateReapSchedule version conflict", "id", id, "version", version)
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// ClearReapSchedule drops a server's reap postponement and warning once it is no longer idle
+func (r *serverRepo) ClearReapSchedule(ctx context.Context, id string) error {
 	log := logging.S(ctx)
-	log.Debugw("ServerRepo.UpdateBilling called", "id", id, "accumulatedSeconds", accumulatedSeconds, "totalCost", totalCost)
-	err := r.db.WithContext(ctx).Model(&Billing{}).Where("server_id = ?", id).UpdateColumns(map[string]interface{}{
-		"accumulated_seconds": accumulatedSeconds,
-		"total_cost":          totalCost,
-		"last_billed_at":      time.Now(),
+	log.Debugw("ServerRepo.ClearReapSchedule called", "id", id)
+	err := r.db.WithContext(ctx).Model(&Server{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"reap_after":      nil,
+		"reap_warned_for": nil,
 	}).Error
 	if err != nil {
-		log.Errorw("ServerRepo.UpdateBilling failed", "id", id, "error", err)
+		log.Errorw("ServerRepo.ClearReapSchedule failed", "id", id, "error", err)
 	}
 	return err
 }
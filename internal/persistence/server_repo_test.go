@@ -78,6 +78,7 @@ func Test_serverRepo_UpdateState(t *testing.T) {
 	type args struct {
 		ctx      context.Context
 		id       string
+		version  int64
 		newState string
 	}
 	tests := []struct {
@@ -93,7 +94,7 @@ func Test_serverRepo_UpdateState(t *testing.T) {
 			r := &serverRepo{
 				db: tt.fields.db,
 			}
-			if err := r.UpdateState(tt.args.ctx, tt.args.id, tt.args.newState); (err != nil) != tt.wantErr {
+			if err := r.UpdateState(tt.args.ctx, tt.args.id, tt.args.version, tt.args.newState); (err != nil) != tt.wantErr {
 				t.Errorf("serverRepo.UpdateState() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -169,36 +170,6 @@ func Test_serverRepo_UpdateTimestamps(t *testing.T) {
 	}
 }
 
-func Test_serverRepo_UpdateBilling(t *testing.T) {
-	type fields struct {
-		db *gorm.DB
-	}
-	type args struct {
-		ctx                context.Context
-		id                 string
-		accumulatedSeconds int64
-		totalCost          float64
-	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		wantErr bool
-	}{
-		// TODO: Add test cases.
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			r := &serverRepo{
-				db: tt.fields.db,
-			}
-			if err := r.UpdateBilling(tt.args.ctx, tt.args.id, tt.args.accumulatedSeconds, tt.args.totalCost); (err != nil) != tt.wantErr {
-				t.Errorf("serverRepo.UpdateBilling() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
-	}
-}
-
 func Test_serverRepo_UpdateServer(t *testing.T) {
 	type fields struct {
 		db *gorm.DB
@@ -0,0 +1,41 @@
+package persistence
+
+import (
+	"context"
+
+	"github.com/rhythin/sever-management/internal/logging"
+	"gorm.io/gorm"
+)
+
+// unitOfWork runs a function against repositories bound to a single database transaction
+
+type unitOfWork struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return &unitOfWork{db: db}
+}
+
+// Do commits if fn returns nil and rolls back otherwise
+func (u *unitOfWork) Do(ctx context.Context, fn func(tx Repos) error) error {
+	log := logging.S(ctx)
+	log.Debugw("UnitOfWork.Do called")
+	err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(Repos{
+			Servers:    NewServerRepo(tx),
+			IPs:        NewIPRepo(tx),
+			Events:     NewEventRepo(tx),
+			Operations: NewOperationRepo(tx),
+			Outbox:     NewOutboxRepo(tx),
+			Usage:      NewUsageRepo(tx),
+			Regions:    NewRegionRepo(tx),
+			Budgets:    NewBudgetRepo(tx),
+			Webhooks:   NewWebhookRepo(tx),
+		})
+	})
+	if err != nil {
+		log.Debugw("UnitOfWork.Do rolled back", "error", err)
+	}
+	return err
+}
@@ -0,0 +1,218 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrUsageConflict is returned when a session was billed or closed since the caller read it
+var ErrUsageConflict = errors.New("usage session was billed concurrently")
+
+// UsageRepo handles the usage-session ledger
+
+type usageRepo struct {
+	db *gorm.DB
+}
+
+func NewUsageRepo(db *gorm.DB) UsageRepo {
+	return &usageRepo{db: db}
+}
+
+func (r *usageRepo) Open(ctx context.Context, session *UsageSession) error {
+	log := logging.S(ctx)
+	log.Infow("UsageRepo.Open called", "serverID", session.ServerID, "startedAt", session.StartedAt)
+	err := r.db.WithContext(ctx).Create(session).Error
+	if err != nil {
+		log.Errorw("UsageRepo.Open failed", "serverID", session.ServerID, "error", err)
+	}
+	return err
+}
+
+// GetOpen returns a server's open session, locking it for the rest of the transaction
+func (r *usageRepo) GetOpen(ctx context.Context, serverID string) (*UsageSession, error) {
+	log := logging.S(ctx)
+	log.Debugw("UsageRepo.GetOpen called", "serverID", serverID)
+	var session UsageSession
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("server_id = ? AND ended_at IS NULL", serverID).
+		First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Errorw("UsageRepo.GetOpen failed", "serverID", serverID, "error", err)
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListOpen returns a page of open sessions in ID order, starting after afterID
+func (r *usageRepo) ListOpen(ctx context.Context, afterID uint, limit int) ([]*UsageSession, error) {
+	log := logging.S(ctx)
+	log.Debugw("UsageRepo.ListOpen called", "afterID", afterID, "limit", limit)
+	var sessions []*UsageSession
+	err := r.db.WithContext(ctx).
+		Where("ended_at IS NULL AND id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&sessions).Error
+	if err != nil {
+		log.Errorw("UsageRepo.ListOpen failed", "error", err)
+	}
+	return sessions, err
+}
+
+// Bill records charges against a session billed up to billedTo, closing it at end when set,
+// and refreshes the server's billing totals from its sessions. It returns ErrUsageConflict
+// if the session was billed or closed since it was read.
+func (r *usageRepo) Bill(ctx context.Context, session *UsageSession, charges []*UsageCharge, billedTo time.Time, end *time.Time) error {
+	var seconds int64
+	var cost domain.Micros
+	for _, c := range charges {
+		seconds += c.Seconds
+		cost += c.Amount
+	}
+	log := logging.S(ctx)
+	log.Debugw("UsageRepo.Bill called", "id", session.ID, "serverID", session.ServerID, "seconds", seconds, "cost", cost)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&UsageSession{}).
+			Where("id = ? AND last_billed_at = ? AND ended_at IS NULL", session.ID, session.LastBilledAt).
+			Updates(map[string]interface{}{
+				"billed_seconds": gorm.Expr("billed_seconds + ?", seconds),
+				"cost_micros":    gorm.Expr("cost_micros + ?", cost),
+				"last_billed_at": billedTo,
+				"ended_at":       end,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrUsageConflict
+		}
+		if len(charges) > 0 {
+			if err := tx.Create(charges).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Exec(`UPDATE billing SET
+			accumulated_seconds = (SELECT COALESCE(SUM(billed_seconds), 0) FROM usage_sessions WHERE server_id = ?),
+			total_cost_micros = (SELECT COALESCE(SUM(cost_micros), 0) FROM usage_sessions WHERE server_id = ?),
+			currency = ?,
+			last_billed_at = ?
+			WHERE server_id = ?`, session.ServerID, session.ServerID, session.Currency, billedTo, session.ServerID).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrUsageConflict) {
+			log.Warnw("UsageRepo.Bill conflict", "id", session.ID)
+		} else {
+			log.Errorw("UsageRepo.Bill failed", "id", session.ID, "error", err)
+		}
+	}
+	return err
+}
+
+// ListByServer returns a server's sessions, oldest first
+func (r *usageRepo) ListByServer(ctx context.Context, serverID string) ([]*UsageSession, error) {
+	log := logging.S(ctx)
+	log.Debugw("UsageRepo.ListByServer called", "serverID", serverID)
+	var sessions []*UsageSession
+	err := r.db.WithContext(ctx).Where("server_id = ?", serverID).Order("started_at ASC, id ASC").Find(&sessions).Error
+	if err != nil {
+		log.Errorw("UsageRepo.ListByServer failed", "serverID", serverID, "error", err)
+	}
+	return sessions, err
+}
+
+// Summarize aggregates usage charges in SQL. Labels and states are joined per group, so a
+// server's current state and labels apply to all of its uptime in the range.
+func (r *usageRepo) Summarize(ctx context.Context, q UsageSummaryQuery) ([]*UsageSummaryRow, error) {
+	log := logging.S(ctx)
+	log.Debugw("UsageRepo.Summarize called", "groupBy", q.GroupBy, "from", q.From, "to", q.To)
+	db := r.db.WithContext(ctx).Table("usage_charges AS c")
+	var cols, keys []string
+	joinedServers := false
+	for i, g := range q.GroupBy {
+		alias := fmt.Sprintf("g%d", i)
+		switch g {
+		case domain.GroupRegion:
+			cols = append(cols, "COALESCE(c.region, '') AS "+alias)
+		case domain.GroupType:
+			cols = append(cols, "c.type AS "+alias)
+		case domain.GroupState:
+			if !joinedServers {
+				db = db.Joins("LEFT JOIN servers s ON s.id = c.server_id")
+				joinedServers = true
+			}
+			cols = append(cols, "COALESCE(s.state, '') AS "+alias)
+		default:
+			key, ok := g.LabelKey()
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", domain.ErrInvalidGroupBy, g)
+			}
+			label := fmt.Sprintf("l%d", i)
+			db = db.Joins(fmt.Sprintf("LEFT JOIN server_labels %[1]s ON %[1]s.server_id = c.server_id AND %[1]s.key = ?", label), key)
+			cols = append(cols, fmt.Sprintf("COALESCE(%s.value, '') AS %s", label, alias))
+		}
+		keys = append(keys, alias)
+	}
+	keys = append(keys, "c.currency")
+	group := strings.Join(keys, ", ")
+	rows, err := db.
+		Select(strings.Join(append(cols, "c.currency", "COUNT(DISTINCT c.server_id)", "SUM(c.seconds)::bigint", "SUM(c.amount_micros)::bigint"), ", ")).
+		Where("c.started_at >= ? AND c.started_at < ?", q.From, q.To).
+		Scopes(chargesMatching(q.Region, q.Labels)).
+		Group(group).
+		Order(group).
+		Rows()
+	if err != nil {
+		log.Errorw("UsageRepo.Summarize failed", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+	var summary []*UsageSummaryRow
+	for rows.Next() {
+		row := &UsageSummaryRow{Groups: make([]string, len(q.GroupBy))}
+		dest := make([]interface{}, 0, len(q.GroupBy)+4)
+		for i := range row.Groups {
+			dest = append(dest, &row.Groups[i])
+		}
+		dest = append(dest, &row.Currency, &row.Servers, &row.Seconds, &row.Cost)
+		if err := rows.Scan(dest...); err != nil {
+			log.Errorw("UsageRepo.Summarize failed", "error", err)
+			return nil, err
+		}
+		summary = append(summary, row)
+	}
+	return summary, rows.Err()
+}
+
+// chargesMatching restricts usage charges aliased c to a region and to servers with all of labels
+func chargesMatching(region string, labels map[string]string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if region != "" {
+			db = db.Where("c.region = ?", region)
+		}
+		return db.Scopes(hasLabels("c.server_id", labels))
+	}
+}
+
+// hasLabels restricts a query to rows whose server, identified by the serverID column, has
+// all of labels
+func hasLabels(serverID string, labels map[string]string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, k := range slices.Sorted(maps.Keys(labels)) {
+			db = db.Where("EXISTS (SELECT 1 FROM server_labels sl WHERE sl.server_id = "+serverID+" AND sl.key = ? AND sl.value = ?)", k, labels[k])
+		}
+		return db
+	}
+}
@@ -0,0 +1,158 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookRepo handles webhook subscriptions and their delivery records
+
+type webhookRepo struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepo(db *gorm.DB) WebhookRepo {
+	return &webhookRepo{db: db}
+}
+
+func (r *webhookRepo) Create(ctx context.Context, hook *Webhook) error {
+	log := logging.S(ctx)
+
+	hook.ID = uuid.New().String()
+	log.Infow("WebhookRepo.Create called", "id", hook.ID, "url", hook.URL, "types", hook.EventTypes)
+	err := r.db.WithContext(ctx).Create(hook).Error
+	if err != nil {
+		log.Errorw("WebhookRepo.Create failed", "id", hook.ID, "error", err)
+	}
+	return err
+}
+
+func (r *webhookRepo) GetByID(ctx context.Context, id string) (*Webhook, error) {
+	log := logging.S(ctx)
+	log.Debugw("WebhookRepo.GetByID called", "id", id)
+	var hook Webhook
+	err := r.db.WithContext(ctx).First(&hook, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Errorw("WebhookRepo.GetByID failed", "id", id, "error", err)
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (r *webhookRepo) List(ctx context.Context) ([]*Webhook, error) {
+	log := logging.S(ctx)
+	log.Debugw("WebhookRepo.List called")
+	var hooks []*Webhook
+	err := r.db.WithContext(ctx).Order("created_at ASC").Find(&hooks).Error
+	if err != nil {
+		log.Errorw("WebhookRepo.List failed", "error", err)
+	}
+	return hooks, err
+}
+
+// Delete removes a webhook together with its delivery history
+func (r *webhookRepo) Delete(ctx context.Context, id string) error {
+	log := logging.S(ctx)
+	log.Infow("WebhookRepo.Delete called", "id", id)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Webhook{}, "id = ?", id).Error
+	})
+	if err != nil {
+		log.Errorw("WebhookRepo.Delete failed", "id", id, "error", err)
+	}
+	return err
+}
+
+// CreateDeliveries queues deliveries, skipping any event already queued for the same webhook
+func (r *webhookRepo) CreateDeliveries(ctx context.Context, deliveries []*WebhookDelivery) error {
+	log := logging.S(ctx)
+	log.Debugw("WebhookRepo.CreateDeliveries called", "count", len(deliveries))
+	if len(deliveries) == 0 {
+		return nil
+	}
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+	if err != nil {
+		log.Errorw("WebhookRepo.CreateDeliveries failed", "error", err)
+	}
+	return err
+}
+
+// ClaimDueDeliveries claims up to limit pending deliveries whose next attempt is due, oldest
+// first, and returns them with their webhook loaded. Claimed deliveries are not due again
+// before until, so other replicas skip them while this one sends; rows another replica is
+// claiming are skipped rather than waited for.
+func (r *webhookRepo) ClaimDueDeliveries(ctx context.Context, now, until time.Time, limit int) ([]*WebhookDelivery, error) {
+	log := logging.S(ctx)
+	log.Debugw("WebhookRepo.ClaimDueDeliveries called", "until", until, "limit", limit)
+	var deliveries []*WebhookDelivery
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Model(&WebhookDelivery{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", string(domain.DeliveryPending), now).
+			Order("next_attempt_at ASC, id ASC").
+			Limit(limit).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := tx.Model(&WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", until).Error; err != nil {
+			return err
+		}
+		return tx.Preload("Webhook").Where("id IN ?", ids).Order("id ASC").Find(&deliveries).Error
+	})
+	if err != nil {
+		log.Errorw("WebhookRepo.ClaimDueDeliveries failed", "error", err)
+	}
+	return deliveries, err
+}
+
+// UpdateDelivery records the outcome of a delivery attempt
+func (r *webhookRepo) UpdateDelivery(ctx context.Context, d *WebhookDelivery) error {
+	log := logging.S(ctx)
+	log.Debugw("WebhookRepo.UpdateDelivery called", "id", d.ID, "status", d.Status, "attempts", d.Attempts)
+	err := r.db.WithContext(ctx).Model(&WebhookDelivery{}).Where("id = ?", d.ID).Updates(map[string]interface{}{
+		"status":          d.Status,
+		"attempts":        d.Attempts,
+		"next_attempt_at": d.NextAttemptAt,
+		"response_status": d.ResponseStatus,
+		"last_error":      d.LastError,
+		"delivered_at":    d.DeliveredAt,
+		"updated_at":      time.Now(),
+	}).Error
+	if err != nil {
+		log.Errorw("WebhookRepo.UpdateDelivery failed", "id", d.ID, "error", err)
+	}
+	return err
+}
+
+// ListDeliveries returns a webhook's most recent deliveries, newest first
+func (r *webhookRepo) ListDeliveries(ctx context.Context, webhookID string, limit int) ([]*WebhookDelivery, error) {
+	log := logging.S(ctx)
+	log.Debugw("WebhookRepo.ListDeliveries called", "webhookID", webhookID, "limit", limit)
+	var deliveries []*WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("webhook_id = ?", webhookID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		log.Errorw("WebhookRepo.ListDeliveries failed", "webhookID", webhookID, "error", err)
+	}
+	return deliveries, err
+}
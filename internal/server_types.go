@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rhythin/sever-management/internal/domain"
+)
+
+// ServerTypeSpec describes the resources, price and boot time of a server type
+
+type ServerTypeSpec struct {
+	Name        string
+	VCPU        int
+	MemoryMiB   int
+	DiskGiB     int
+	HourlyPrice domain.Micros // per hour, in the billing currency
+	BootTime    time.Duration
+}
+
+// ServerTypeCatalog is the set of server types that may be provisioned.
+// It is decoded from a comma-separated list of
+// name:vcpu:memoryMiB:diskGiB:hourlyPrice:bootTime entries.
+
+type ServerTypeCatalog []ServerTypeSpec
+
+// Decode implements envconfig.Decoder
+func (c *ServerTypeCatalog) Decode(value string) error {
+	var catalog ServerTypeCatalog
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		spec, err := parseServerTypeSpec(entry)
+		if err != nil {
+			return err
+		}
+		if seen[spec.Name] {
+			return fmt.Errorf("duplicate server type %q", spec.Name)
+		}
+		seen[spec.Name] = true
+		catalog = append(catalog, spec)
+	}
+	*c = catalog
+	return nil
+}
+
+func parseServerTypeSpec(entry string) (ServerTypeSpec, error) {
+	parts := strings.Split(entry, ":")
+	if len(parts) != 6 {
+		return ServerTypeSpec{}, fmt.Errorf("invalid server type %q: want name:vcpu:memoryMiB:diskGiB:hourlyPrice:bootTime", entry)
+	}
+	var spec ServerTypeSpec
+	var err error
+	spec.Name = parts[0]
+	if spec.VCPU, err = strconv.Atoi(parts[1]); err != nil {
+		return spec, fmt.Errorf("invalid vcpu for server type %q: %w", spec.Name, err)
+	}
+	if spec.MemoryMiB, err = strconv.Atoi(parts[2]); err != nil {
+		return spec, fmt.Errorf("invalid memory for server type %q: %w", spec.Name, err)
+	}
+	if spec.DiskGiB, err = strconv.Atoi(parts[3]); err != nil {
+		return spec, fmt.Errorf("invalid disk for server type %q: %w", spec.Name, err)
+	}
+	if spec.HourlyPrice, err = domain.ParseMicros(parts[4]); err != nil {
+		return spec, fmt.Errorf("invalid hourly price for server type %q: %w", spec.Name, err)
+	}
+	if spec.BootTime, err = time.ParseDuration(parts[5]); err != nil {
+		return spec, fmt.Errorf("invalid boot time for server type %q: %w", spec.Name, err)
+	}
+	return spec, nil
+}
+
+// Lookup finds a server type by name
+func (c ServerTypeCatalog) Lookup(name string) (ServerTypeSpec, bool) {
+	for _, spec := range c {
+		if spec.Name == name {
+			return spec, true
+		}
+	}
+	return ServerTypeSpec{}, false
+}
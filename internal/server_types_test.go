@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestServerTypeCatalog_Decode(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    ServerTypeCatalog
+		wantErr bool
+	}{
+		{
+			name:  "valid catalog",
+			value: "t2.micro:1:1024:8:0.0116:1s, t2.small:1:2048:20:0.023:2s",
+			want: ServerTypeCatalog{
+				{Name: "t2.micro", VCPU: 1, MemoryMiB: 1024, DiskGiB: 8, HourlyPrice: 11600, BootTime: time.Second},
+				{Name: "t2.small", VCPU: 1, MemoryMiB: 2048, DiskGiB: 20, HourlyPrice: 23000, BootTime: 2 * time.Second},
+			},
+		},
+		{name: "missing fields", value: "t2.micro:1:1024", wantErr: true},
+		{name: "bad price", value: "t2.micro:1:1024:8:cheap:1s", wantErr: true},
+		{name: "duplicate type", value: "t2.micro:1:1024:8:0.01:1s,t2.micro:1:1024:8:0.01:1s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got ServerTypeCatalog
+			err := got.Decode(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerTypeCatalog_Lookup(t *testing.T) {
+	c := ServerTypeCatalog{{Name: "t2.micro"}}
+	if _, ok := c.Lookup("t2.micro"); !ok {
+		t.Error("Lookup(t2.micro) not found")
+	}
+	if _, ok := c.Lookup("t2.nano"); ok {
+		t.Error("Lookup(t2.nano) found unexpectedly")
+	}
+}
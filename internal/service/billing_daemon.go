@@ -2,93 +2,134 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/rhythin/sever-management/internal"
-	"github.com/rhythin/sever-management/internal/domain"
 	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/metrics"
 	"github.com/rhythin/sever-management/internal/persistence"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
-	"gorm.io/gorm"
 )
 
-// BillingDaemon periodically updates server billing based on uptime
+// billingLease names the lease that elects the one replica that bills
+const billingLease = "billing"
+
+// billingPageSize bounds the open sessions read and billed at a time
+const billingPageSize = 500
+
+// BillingDaemon periodically bills the uptime of open usage sessions since they were last
+// billed. Billing resumes from each session's last_billed_at, so the first run after downtime
+// catches up on the whole gap, and a compare-and-swap on it makes every increment idempotent.
+// Only the replica holding the billing lease bills, and it evaluates budgets after each run.
 
 type BillingDaemon struct {
-	servers persistence.ServerRepo
+	usage   persistence.UsageRepo
+	leases  persistence.LeaseRepo
+	prices  PriceService
+	budgets BudgetService
 	cfg     *internal.Config
-	db      *gorm.DB
 }
 
-func NewBillingDaemon(servers persistence.ServerRepo, db *gorm.DB, cfg *internal.Config) *BillingDaemon {
-	return &BillingDaemon{servers: servers, db: db, cfg: cfg}
+func NewBillingDaemon(usage persistence.UsageRepo, leases persistence.LeaseRepo, prices PriceService, budgets BudgetService, cfg *internal.Config) *BillingDaemon {
+	return &BillingDaemon{usage: usage, leases: leases, prices: prices, budgets: budgets, cfg: cfg}
+}
+
+// Job runs billing on the JobRunner every BILLING_INTERVAL, catching up at startup rather
+// than a full interval later. The billing lease is released when the runner stops.
+func (b *BillingDaemon) Job() Job {
+	return Job{Name: billingLease, Interval: b.cfg.BillingInterval, RunAtStart: true, Run: b.tick, Stop: b.stop}
 }
 
-func (b *BillingDaemon) Run(ctx context.Context) {
-	interval := b.cfg.BillingInterval
-	if interval <= 0 {
-		interval = time.Minute
+func (b *BillingDaemon) stop(ctx context.Context) {
+	b.leases.Release(ctx, billingLease)
+	metrics.SetBillingLeaseHeld(false)
+}
+
+// tick bills every open session and then evaluates budgets, if this replica holds, or can
+// take, the billing lease; it is skipped otherwise. Budgets are evaluated even if some
+// sessions failed to bill.
+func (b *BillingDaemon) tick(ctx context.Context) error {
+	held, err := b.leases.TryAcquire(ctx, billingLease)
+	metrics.SetBillingLeaseHeld(held && err == nil)
+	if err != nil {
+		return fmt.Errorf("failed to acquire billing lease: %w", err)
 	}
-	zap.S().Infow("BillingDaemon started")
-	ticker := time.NewTicker(interval)
-	for {
-		select {
-		case <-ctx.Done():
-			ticker.Stop()
-			zap.S().Infow("BillingDaemon stopped")
-			return
-		case <-ticker.C:
-			func() {
-				defer func() {
-					if r := recover(); r != nil {
-						zap.S().Errorw("BillingDaemon panicked; will restart loop", "recover", r)
-					}
-				}()
-				ctx, cancel := context.WithTimeout(ctx, b.cfg.RequestTimeout)
-				defer cancel()
-				b.billAll(ctx)
-			}()
-		}
+	if !held {
+		zap.S().Debugw("BillingDaemon standing by; another replica holds the billing lease")
+		return ErrJobSkipped
+	}
+	now := time.Now()
+	billErr := b.billAll(ctx, now)
+	if billErr != nil {
+		billErr = fmt.Errorf("failed to bill servers: %w", billErr)
+	} else {
+		metrics.SetBillingLastSuccess(now)
 	}
+	budgetCtx, cancel := context.WithTimeout(ctx, b.cfg.RequestTimeout)
+	defer cancel()
+	if err := b.budgets.Evaluate(budgetCtx, now); err != nil {
+		return errors.Join(billErr, fmt.Errorf("failed to evaluate budgets: %w", err))
+	}
+	return billErr
 }
 
-func (b *BillingDaemon) billAll(ctx context.Context) {
+// billAll bills every open session up to now, a page at a time in ID order. Failures on one
+// page do not stop later pages; the first error is returned once all pages are billed.
+func (b *BillingDaemon) billAll(ctx context.Context, now time.Time) error {
 	log := logging.S(ctx)
 	log.Debugw("BillingDaemon running billAll")
-	servers, err := b.servers.List(ctx, "", string(domain.ServerRunning), "", 1000, 0)
-	if err != nil {
-		log.Errorw("BillingDaemon failed to list servers", "error", err)
-		return
+	var afterID uint
+	var billErr error
+	for {
+		pageCtx, cancel := context.WithTimeout(ctx, b.cfg.RequestTimeout)
+		sessions, err := b.usage.ListOpen(pageCtx, afterID, billingPageSize)
+		if err != nil {
+			cancel()
+			log.Errorw("BillingDaemon failed to list open usage sessions", "afterID", afterID, "error", err)
+			return err
+		}
+		log.Debugw("BillingDaemon found open sessions", "afterID", afterID, "count", len(sessions))
+		if err := b.billPage(pageCtx, sessions, now); err != nil && billErr == nil {
+			billErr = err
+		}
+		cancel()
+		if len(sessions) < billingPageSize {
+			return billErr
+		}
+		afterID = sessions[len(sessions)-1].ID
 	}
-	log.Debugw("BillingDaemon found servers", "count", len(servers))
-	rate := b.cfg.BillingRate / 3600.0 // $/second
-	now := time.Now()
-	log.Debugw("BillingDaemon billing rate", "rate", rate)
-	g, gctx := errgroup.WithContext(ctx)
-	for _, s := range servers {
-		s := s // capture loop var
+}
+
+// billPage bills sessions concurrently, at most BILLING_CONCURRENCY at a time so that billing
+// does not take every database connection from API requests
+func (b *BillingDaemon) billPage(ctx context.Context, sessions []*persistence.UsageSession, now time.Time) error {
+	log := logging.S(ctx)
+	var g errgroup.Group
+	g.SetLimit(b.concurrency())
+	for _, s := range sessions {
 		g.Go(func() error {
-			if s.StartedAt == nil {
-				return nil
-			}
-			delta := now.Sub(*s.StartedAt).Seconds()
-			if delta <= 0 {
+			err := billUsage(ctx, b.usage, b.prices, s, now, false, b.cfg)
+			if errors.Is(err, persistence.ErrUsageConflict) {
+				// Closed or billed by a stop, a terminate or another replica in the meantime
 				return nil
 			}
-			cost := rate * delta
-			log.Debugw("BillingDaemon billing server", "id", s.ID, "delta", delta, "cost", cost)
-			err := b.servers.UpdateBilling(gctx, s.ID, int64(delta), cost)
 			if err != nil {
-				log.Errorw("BillingDaemon failed to update billing for server", "id", s.ID, "error", err)
+				log.Errorw("BillingDaemon failed to bill usage session", "id", s.ID, "serverID", s.ServerID, "error", err)
 			} else {
-				log.Infow("Billed server", "id", s.ID, "cost", cost)
+				log.Debugw("Billed usage session", "id", s.ID, "serverID", s.ServerID)
 			}
 			return err
 		})
 	}
-	err = g.Wait()
-	if err != nil {
-		log.Errorw("BillingDaemon failed to bill servers", "error", err)
+	return g.Wait()
+}
+
+func (b *BillingDaemon) concurrency() int {
+	if b.cfg.BillingConcurrency > 0 {
+		return b.cfg.BillingConcurrency
 	}
+	return 8
 }
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/persistence"
+	mockPersistence "github.com/rhythin/sever-management/internal/persistence/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_BillingDaemon_billAll(t *testing.T) {
+	since := time.Now().Add(-time.Hour)
+	micro := &persistence.UsageSession{ID: 1, ServerID: "srv-1", Type: "t2.micro", LastBilledAt: since}
+	closed := &persistence.UsageSession{ID: 2, ServerID: "srv-2", Type: "unknown", LastBilledAt: since}
+	usage := &mockPersistence.UsageRepo{}
+	usage.On("ListOpen", mock.Anything, uint(0), billingPageSize).Return([]*persistence.UsageSession{micro, closed}, nil)
+	usage.On("Bill", mock.Anything, micro, mock.Anything, mock.Anything, (*time.Time)(nil)).Return(nil)
+	// Stopped while the daemon was billing it
+	usage.On("Bill", mock.Anything, closed, mock.Anything, mock.Anything, (*time.Time)(nil)).Return(persistence.ErrUsageConflict)
+
+	cfg := &internal.Config{BillingRate: 3600, RequestTimeout: time.Minute, ServerTypes: internal.ServerTypeCatalog{{Name: "t2.micro", HourlyPrice: 1}}}
+	if err := NewBillingDaemon(usage, nil, NewPriceService(newPriceRepo(), nil, cfg), nil, cfg).billAll(context.Background(), time.Now()); err != nil {
+		t.Fatalf("billAll() error = %v", err)
+	}
+
+	usage.AssertExpectations(t)
+	for _, call := range usage.Calls {
+		if call.Method != "Bill" {
+			continue
+		}
+		session := call.Arguments.Get(1).(*persistence.UsageSession)
+		seconds, cost := sumCharges(call.Arguments.Get(2).([]*persistence.UsageCharge))
+		// Rates of one micro-unit per second and per hour
+		want := domain.Micros(seconds)
+		if session == micro {
+			want = 1
+		}
+		if seconds < 3600 || seconds > 3601 || cost != want {
+			t.Errorf("billAll() billed session %d %ds for %v, want %v", session.ID, seconds, cost, want)
+		}
+	}
+}
+
+func Test_BillingDaemon_billAll_Pages(t *testing.T) {
+	// Down for three days: each session is billed for the whole gap in one increment
+	since := time.Now().Add(-72 * time.Hour)
+	page := make([]*persistence.UsageSession, billingPageSize)
+	for i := range page {
+		page[i] = &persistence.UsageSession{ID: uint(i + 1), Type: "t2.micro", LastBilledAt: since}
+	}
+	last := &persistence.UsageSession{ID: billingPageSize + 7, Type: "t2.micro", LastBilledAt: since}
+	usage := &mockPersistence.UsageRepo{}
+	usage.On("ListOpen", mock.Anything, uint(0), billingPageSize).Return(page, nil)
+	usage.On("ListOpen", mock.Anything, uint(billingPageSize), billingPageSize).Return([]*persistence.UsageSession{last}, nil)
+	usage.On("Bill", mock.Anything, page[3], mock.Anything, mock.Anything, (*time.Time)(nil)).Return(errors.New("db error"))
+	usage.On("Bill", mock.Anything, mock.Anything, mock.Anything, mock.Anything, (*time.Time)(nil)).Return(nil)
+
+	cfg := &internal.Config{BillingRate: 3600, RequestTimeout: time.Minute}
+	err := NewBillingDaemon(usage, nil, NewPriceService(newPriceRepo(), nil, cfg), nil, cfg).billAll(context.Background(), time.Now())
+	if err == nil {
+		t.Errorf("billAll() error = nil, want the failed session's error")
+	}
+
+	usage.AssertExpectations(t)
+	usage.AssertNumberOfCalls(t, "Bill", billingPageSize+1)
+	for _, call := range usage.Calls {
+		if call.Method == "Bill" {
+			if seconds, _ := sumCharges(call.Arguments.Get(2).([]*persistence.UsageCharge)); seconds < 72*3600 {
+				t.Fatalf("billAll() billed %ds, want the whole %ds gap", seconds, 72*3600)
+			}
+		}
+	}
+}
+
+func Test_BillingDaemon_billPage_Concurrency(t *testing.T) {
+	since := time.Now().Add(-time.Hour)
+	sessions := make([]*persistence.UsageSession, 20)
+	for i := range sessions {
+		sessions[i] = &persistence.UsageSession{ID: uint(i + 1), Type: "t2.micro", LastBilledAt: since}
+	}
+	var inFlight, peak atomic.Int64
+	usage := &mockPersistence.UsageRepo{}
+	usage.On("Bill", mock.Anything, mock.Anything, mock.Anything, mock.Anything, (*time.Time)(nil)).Run(func(mock.Arguments) {
+		n := inFlight.Add(1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(5 * time.Millisecond)
+		inFlight.Add(-1)
+	}).Return(nil)
+
+	cfg := &internal.Config{BillingRate: 3600, BillingConcurrency: 3}
+	if err := NewBillingDaemon(usage, nil, NewPriceService(newPriceRepo(), nil, cfg), nil, cfg).billPage(context.Background(), sessions, time.Now()); err != nil {
+		t.Fatalf("billPage() error = %v", err)
+	}
+	usage.AssertNumberOfCalls(t, "Bill", len(sessions))
+	if p := peak.Load(); p > 3 {
+		t.Errorf("billPage() billed %d sessions at once, want at most 3", p)
+	}
+}
+
+func Test_BillingDaemon_tick(t *testing.T) {
+	tests := []struct {
+		name    string
+		held    bool
+		err     error
+		bills   bool
+		wantErr bool
+	}{
+		{name: "lease holder bills", held: true, bills: true},
+		{name: "standby does not bill", held: false, wantErr: true},
+		{name: "lease error does not bill", err: errors.New("db error"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leases := &mockPersistence.LeaseRepo{}
+			leases.On("TryAcquire", mock.Anything, billingLease).Return(tt.held, tt.err)
+			usage := &mockPersistence.UsageRepo{}
+			usage.On("ListOpen", mock.Anything, uint(0), billingPageSize).Return(nil, nil)
+			budgets := &evaluatedBudgets{}
+
+			cfg := &internal.Config{RequestTimeout: time.Minute}
+			err := NewBillingDaemon(usage, leases, nil, budgets, cfg).tick(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("tick() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.held && tt.err == nil && !errors.Is(err, ErrJobSkipped) {
+				t.Errorf("tick() error = %v, want ErrJobSkipped on standby", err)
+			}
+
+			leases.AssertExpectations(t)
+			if tt.bills {
+				usage.AssertExpectations(t)
+			} else {
+				usage.AssertNotCalled(t, "ListOpen", mock.Anything, mock.Anything, mock.Anything)
+			}
+			if (budgets.runs > 0) != tt.bills {
+				t.Errorf("tick() evaluated budgets %d times, want them evaluated: %v", budgets.runs, tt.bills)
+			}
+		})
+	}
+}
+
+// evaluatedBudgets counts budget evaluations
+type evaluatedBudgets struct {
+	BudgetService
+	runs int
+}
+
+func (b *evaluatedBudgets) Evaluate(ctx context.Context, now time.Time) error {
+	b.runs++
+	return nil
+}
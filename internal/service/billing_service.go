@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/persistence"
+)
+
+// ErrInvalidTimeRange is returned when a report's range ends before it starts
+var ErrInvalidTimeRange = errors.New("time range must end after it starts")
+
+// ErrForecastTooFar is returned when a forecast would extend beyond maxForecastHorizon
+var ErrForecastTooFar = errors.New("forecasts extend at most a year ahead")
+
+// maxForecastHorizon bounds how far ahead spend is projected
+const maxForecastHorizon = 366 * 24 * time.Hour
+
+// ServerForecast is a server's projected spend that has not been billed yet: its uptime from
+// its last billing, or from when it is expected to finish provisioning, through the forecast's
+// end, priced like a bill
+type ServerForecast struct {
+	ServerID string
+	Type     string
+	Region   string
+	Currency string
+	From     time.Time // the session's last billing, or the expected end of provisioning
+	Seconds  int64
+	Amount   domain.Micros
+}
+
+// BillingService reports on billed usage and projects spend
+
+type billingService struct {
+	usage   persistence.UsageRepo
+	servers persistence.ServerRepo
+	prices  PriceService
+	catalog CatalogService // boot times of servers still provisioning
+	cfg     *internal.Config
+}
+
+func NewBillingService(usage persistence.UsageRepo, servers persistence.ServerRepo, prices PriceService, catalog CatalogService, cfg *internal.Config) BillingService {
+	return &billingService{usage: usage, servers: servers, prices: prices, catalog: catalog, cfg: cfg}
+}
+
+// Summary aggregates the cost and uptime billed for [from, to), grouped by groupBy. Usage is
+// attributed to the billing increment it was billed in, so the range is exact to BILLING_INTERVAL.
+func (s *billingService) Summary(ctx context.Context, groupBy []domain.SummaryGroup, from, to time.Time) ([]*persistence.UsageSummaryRow, error) {
+	if len(groupBy) == 0 {
+		return nil, domain.ErrInvalidGroupBy
+	}
+	if !to.After(from) {
+		return nil, ErrInvalidTimeRange
+	}
+	return s.usage.Summarize(ctx, persistence.UsageSummaryQuery{GroupBy: groupBy, From: from, To: to})
+}
+
+// Forecast projects the spend of every server that will bill through until, assuming current
+// states persist: each open usage session, which running and rebooting servers hold, is priced
+// from its last billing to until with the same rates, scheduled price changes, period splits
+// and rounding as billing, and each provisioning server is priced the same way from when it is
+// expected to start. Stopped servers cost nothing. Actions that would end a session before
+// until, such as budget stops, are not anticipated. Billed spend plus the forecast is the
+// expected total, to within the rounding of individual billing increments.
+func (s *billingService) Forecast(ctx context.Context, now, until time.Time) ([]*ServerForecast, error) {
+	if !until.After(now) {
+		return nil, ErrInvalidTimeRange
+	}
+	if until.Sub(now) > maxForecastHorizon {
+		return nil, ErrForecastTooFar
+	}
+	var forecasts []*ServerForecast
+	for afterID := uint(0); ; {
+		sessions, err := s.usage.ListOpen(ctx, afterID, billingPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, session := range sessions {
+			f, err := s.forecast(ctx, session, until)
+			if err != nil {
+				return nil, err
+			}
+			forecasts = append(forecasts, f)
+		}
+		if len(sessions) < billingPageSize {
+			break
+		}
+		afterID = sessions[len(sessions)-1].ID
+	}
+	provisioning, err := s.servers.ListMatching(ctx, "", string(domain.ServerProvisioning), nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, server := range provisioning {
+		spec, err := s.catalog.GetType(ctx, server.Type)
+		if err != nil {
+			return nil, err
+		}
+		bootTime := spec.BootTime
+		if bootTime <= 0 {
+			bootTime = s.cfg.ProvisionDelay
+		}
+		// Its session opens when provisioning completes, which is due boot time after creation
+		start := server.CreatedAt.Add(bootTime)
+		if start.Before(now) {
+			start = now
+		}
+		f, err := s.forecast(ctx, &persistence.UsageSession{
+			ServerID:     server.ID,
+			Type:         server.Type,
+			Region:       server.Region,
+			Currency:     s.cfg.BillingCurrency,
+			StartedAt:    start,
+			LastBilledAt: start,
+		}, until)
+		if err != nil {
+			return nil, err
+		}
+		forecasts = append(forecasts, f)
+	}
+	return forecasts, nil
+}
+
+// forecast prices a session's unbilled uptime through until
+func (s *billingService) forecast(ctx context.Context, session *persistence.UsageSession, until time.Time) (*ServerForecast, error) {
+	charges, _, err := priceUsage(ctx, s.prices, session, until, s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	f := &ServerForecast{
+		ServerID: session.ServerID,
+		Type:     session.Type,
+		Region:   session.Region,
+		Currency: session.Currency,
+		From:     session.LastBilledAt,
+	}
+	for _, c := range charges {
+		f.Seconds += c.Seconds
+		f.Amount += c.Amount
+	}
+	return f, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/persistence"
+	mockPersistence "github.com/rhythin/sever-management/internal/persistence/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_billingService_Summary(t *testing.T) {
+	from := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	usage := &mockPersistence.UsageRepo{}
+	usage.On("Summarize", mock.Anything, persistence.UsageSummaryQuery{GroupBy: []domain.SummaryGroup{domain.GroupType}, From: from, To: to}).
+		Return([]*persistence.UsageSummaryRow{{Groups: []string{"t2.micro"}, Seconds: 60}}, nil)
+
+	tests := []struct {
+		name    string
+		groupBy []domain.SummaryGroup
+		from    time.Time
+		to      time.Time
+		wantErr error
+	}{
+		{name: "summarized", groupBy: []domain.SummaryGroup{domain.GroupType}, from: from, to: to},
+		{name: "no groups", from: from, to: to, wantErr: domain.ErrInvalidGroupBy},
+		{name: "empty range", groupBy: []domain.SummaryGroup{domain.GroupType}, from: to, to: to, wantErr: ErrInvalidTimeRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := NewBillingService(usage, nil, nil, nil, nil).Summary(context.Background(), tt.groupBy, tt.from, tt.to)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Summary() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (len(rows) != 1 || rows[0].Seconds != 60) {
+				t.Errorf("Summary() = %+v", rows)
+			}
+		})
+	}
+}
+
+func Test_billingService_Forecast(t *testing.T) {
+	now := time.Date(2026, 4, 30, 23, 30, 0, 0, time.UTC)
+	usage := &mockPersistence.UsageRepo{}
+	usage.On("ListOpen", mock.Anything, uint(0), billingPageSize).Return([]*persistence.UsageSession{
+		{ID: 1, ServerID: "srv-1", Type: "t3.large", Region: "us-east-1", Currency: "USD", LastBilledAt: now.Add(-30 * time.Minute)},
+		{ID: 2, ServerID: "srv-2", Type: "t3.large", Region: "us-east-1", Currency: "USD", LastBilledAt: now.Add(2 * time.Hour)},
+	}, nil)
+	// Provisioning servers bill from the end of their boot time, or from now once it has passed
+	servers := &mockPersistence.ServerRepo{}
+	servers.On("ListMatching", mock.Anything, "", "provisioning", map[string]string(nil)).Return([]*persistence.Server{
+		{ID: "srv-3", Type: "t3.large", Region: "us-east-1", CreatedAt: now.Add(-2 * time.Minute)},
+		{ID: "srv-4", Type: "t3.large", Region: "us-east-1", CreatedAt: now.Add(-time.Hour)},
+	}, nil)
+	cfg := &internal.Config{BillingRate: 3600, BillingCurrency: "USD", ServerTypes: internal.ServerTypeCatalog{{Name: "t3.large", HourlyPrice: 3600, BootTime: 10 * time.Minute}}}
+	svc := NewBillingService(usage, servers, NewPriceService(newPriceRepo(), nil, cfg), NewCatalogService(cfg, nil), cfg)
+
+	tests := []struct {
+		name    string
+		until   time.Time
+		want    []int64 // seconds per server
+		wantErr error
+	}{
+		// The projection crosses into May; the period split must not change the total
+		{name: "projected", until: now.Add(time.Hour), want: []int64{5400, 0, 3120, 3600}},
+		{name: "past", until: now.Add(-time.Minute), wantErr: ErrInvalidTimeRange},
+		{name: "too far", until: now.AddDate(2, 0, 0), wantErr: ErrForecastTooFar},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.Forecast(context.Background(), now, tt.until)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Forecast() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Forecast() = %d servers, want %d", len(got), len(tt.want))
+			}
+			for i, f := range got {
+				if f.Seconds != tt.want[i] || f.Amount != domain.Micros(tt.want[i]) {
+					t.Errorf("Forecast()[%d] = %d seconds costing %s, want %d seconds at 1 micro per second", i, f.Seconds, f.Amount, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/persistence"
+)
+
+var (
+	// ErrBudgetNotFound is returned when a budget ID is unknown
+	ErrBudgetNotFound = errors.New("budget not found")
+	// ErrInvalidBudget is returned when a budget's limit, selector or thresholds are malformed
+	ErrInvalidBudget = errors.New("invalid budget")
+)
+
+// maxThresholdPercent bounds thresholds, which may exceed 100% to act on overspend
+const maxThresholdPercent = 1000
+
+// BudgetService manages budgets and enforces them after each billing run. Event and webhook
+// thresholds fire once per month; stop thresholds keep stopping the budget's running servers
+// for the rest of the month, so servers started after the limit is reached are stopped again.
+
+type budgetService struct {
+	repo     persistence.BudgetRepo
+	usage    persistence.UsageRepo
+	servers  persistence.ServerRepo
+	webhooks persistence.WebhookRepo
+	uow      persistence.UnitOfWork
+	relay    *OutboxRelay  // nudged after budget events are queued in the outbox
+	actions  ServerService // stops go through the normal action path
+	catalog  CatalogService
+	cfg      *internal.Config
+}
+
+func NewBudgetService(repo persistence.BudgetRepo, usage persistence.UsageRepo, servers persistence.ServerRepo, webhooks persistence.WebhookRepo, uow persistence.UnitOfWork, relay *OutboxRelay, actions ServerService, catalog CatalogService, cfg *internal.Config) BudgetService {
+	return &budgetService{repo: repo, usage: usage, servers: servers, webhooks: webhooks, uow: uow, relay: relay, actions: actions, catalog: catalog, cfg: cfg}
+}
+
+// Create validates and stores a budget, which is kept in the billing currency
+func (s *budgetService) Create(ctx context.Context, budget *persistence.Budget) (*persistence.Budget, error) {
+	log := logging.S(ctx)
+	log.Infow("BudgetService.Create called", "name", budget.Name, "region", budget.Region, "selector", budget.Selector, "limit", budget.Limit)
+	if err := s.validate(ctx, budget); err != nil {
+		log.Warnw("Rejected budget", "name", budget.Name, "error", err)
+		return nil, err
+	}
+	budget.Currency = s.cfg.BillingCurrency
+	if err := s.repo.Create(ctx, budget); err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+func (s *budgetService) validate(ctx context.Context, budget *persistence.Budget) error {
+	if budget.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidBudget)
+	}
+	if budget.Limit <= 0 {
+		return fmt.Errorf("%w: monthly limit must be positive", ErrInvalidBudget)
+	}
+	if budget.Region != "" {
+		if _, err := s.catalog.GetRegion(ctx, budget.Region); err != nil {
+			return err
+		}
+	}
+	if err := domain.ValidateLabels(budget.Selector); err != nil {
+		return fmt.Errorf("%w: selector: %w", ErrInvalidBudget, err)
+	}
+	if len(budget.Thresholds) == 0 {
+		return fmt.Errorf("%w: at least one threshold is required", ErrInvalidBudget)
+	}
+	seen := make(map[persistence.BudgetThreshold]bool)
+	for _, t := range budget.Thresholds {
+		if t.Percent <= 0 || t.Percent > maxThresholdPercent {
+			return fmt.Errorf("%w: threshold percent must be between 1 and %d", ErrInvalidBudget, maxThresholdPercent)
+		}
+		action := domain.BudgetAction(t.Action)
+		if !domain.IsValidBudgetAction(action) {
+			return fmt.Errorf("%w: threshold action %q: want event, webhook or stop", ErrInvalidBudget, t.Action)
+		}
+		if (action == domain.BudgetActionWebhook) != (t.WebhookID != "") {
+			return fmt.Errorf("%w: webhook_id is required for, and only for, the webhook action", ErrInvalidBudget)
+		}
+		if t.WebhookID != "" {
+			hook, err := s.webhooks.GetByID(ctx, t.WebhookID)
+			if err != nil {
+				return err
+			}
+			if hook == nil {
+				return fmt.Errorf("%w: unknown webhook %s", ErrInvalidBudget, t.WebhookID)
+			}
+		}
+		key := persistence.BudgetThreshold{Percent: t.Percent, Action: t.Action, WebhookID: t.WebhookID}
+		if seen[key] {
+			return fmt.Errorf("%w: threshold %d%% %s repeated", ErrInvalidBudget, t.Percent, t.Action)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+func (s *budgetService) List(ctx context.Context) ([]*persistence.Budget, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *budgetService) Get(ctx context.Context, id string) (*persistence.Budget, error) {
+	budget, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if budget == nil {
+		return nil, ErrBudgetNotFound
+	}
+	return budget, nil
+}
+
+// Delete removes a budget and its alert history
+func (s *budgetService) Delete(ctx context.Context, id string) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// Status returns a budget's spend in the month containing now and the alerts it fired in it
+func (s *budgetService) Status(ctx context.Context, budget *persistence.Budget, now time.Time) (domain.Micros, []*persistence.BudgetAlert, error) {
+	period := domain.PeriodOf(now)
+	start, _, _ := domain.PeriodBounds(period)
+	spend, err := s.spend(ctx, budget, start, now)
+	if err != nil {
+		return 0, nil, err
+	}
+	alerts, err := s.repo.ListAlerts(ctx, budget.ID, period)
+	if err != nil {
+		return 0, nil, err
+	}
+	return spend, alerts, nil
+}
+
+// spend returns what a budget's servers were billed for uptime in [from, to)
+func (s *budgetService) spend(ctx context.Context, budget *persistence.Budget, from, to time.Time) (domain.Micros, error) {
+	rows, err := s.usage.Summarize(ctx, persistence.UsageSummaryQuery{From: from, To: to, Region: budget.Region, Labels: budget.Selector})
+	if err != nil {
+		return 0, err
+	}
+	for _, row := range rows {
+		if row.Currency == budget.Currency {
+			return row.Cost, nil
+		}
+	}
+	return 0, nil
+}
+
+// Evaluate checks every budget's spend this month against its thresholds. A failing budget
+// does not stop the others from being evaluated; the first error is returned.
+func (s *budgetService) Evaluate(ctx context.Context, now time.Time) error {
+	log := logging.S(ctx)
+	budgets, err := s.repo.List(ctx)
+	if err != nil {
+		log.Errorw("BudgetService failed to list budgets", "error", err)
+		return err
+	}
+	var evalErr error
+	for _, budget := range budgets {
+		if err := s.evaluate(ctx, budget, now); err != nil {
+			log.Errorw("BudgetService failed to evaluate budget", "id", budget.ID, "error", err)
+			if evalErr == nil {
+				evalErr = err
+			}
+		}
+	}
+	return evalErr
+}
+
+// evaluate fires the thresholds a budget has newly reached this month, and stops its running
+// servers while a stop threshold is reached. A threshold whose action fails is not recorded as
+// fired, so it is tried again on the next evaluation.
+func (s *budgetService) evaluate(ctx context.Context, budget *persistence.Budget, now time.Time) error {
+	log := logging.S(ctx)
+	period := domain.PeriodOf(now)
+	start, _, _ := domain.PeriodBounds(period)
+	spend, err := s.spend(ctx, budget, start, now)
+	if err != nil {
+		return err
+	}
+	var reached []*persistence.BudgetThreshold
+	stop := false
+	for _, t := range budget.Thresholds {
+		if int64(spend)*100 < int64(budget.Limit)*int64(t.Percent) {
+			continue
+		}
+		reached = append(reached, t)
+		if domain.BudgetAction(t.Action) == domain.BudgetActionStop {
+			stop = true
+		}
+	}
+	var evalErr error
+	if stop {
+		evalErr = s.stopServers(ctx, budget)
+	}
+	for _, t := range reached {
+		if domain.BudgetAction(t.Action) == domain.BudgetActionStop && evalErr != nil {
+			continue // recorded once the budget's servers are stopped
+		}
+		alert := &persistence.BudgetAlert{
+			BudgetID:    budget.ID,
+			ThresholdID: t.ID,
+			Period:      period,
+			Percent:     t.Percent,
+			Action:      t.Action,
+			Spend:       spend,
+			FiredAt:     now,
+		}
+		if err := s.fire(ctx, budget, t, alert); err != nil {
+			log.Errorw("Budget threshold action failed", "id", budget.ID, "percent", t.Percent, "action", t.Action, "error", err)
+			if evalErr == nil {
+				evalErr = err
+			}
+		}
+	}
+	return evalErr
+}
+
+// fire records a threshold's alert and performs its one-off action in one unit of work, so a
+// failed action leaves the threshold unfired. A budget_threshold event is logged in the budget's
+// own stream for event and webhook thresholds; event thresholds publish it through the outbox,
+// while webhook thresholds queue it for delivery to their webhook alone.
+func (s *budgetService) fire(ctx context.Context, budget *persistence.Budget, t *persistence.BudgetThreshold, alert *persistence.BudgetAlert) error {
+	action := domain.BudgetAction(t.Action)
+	fired := false
+	err := s.uow.Do(ctx, func(tx persistence.Repos) error {
+		var err error
+		if fired, err = tx.Budgets.RecordAlert(ctx, alert); err != nil || !fired {
+			return err
+		}
+		if action == domain.BudgetActionStop {
+			return nil // stops are enforced on every evaluation
+		}
+		event := &persistence.EventLog{
+			Stream:    "budget:" + budget.ID,
+			Timestamp: alert.FiredAt,
+			Type:      string(domain.EventBudgetThreshold),
+			Message:   fmt.Sprintf("Budget %s (%s) reached %d%% of its monthly limit: %s of %s %s", budget.Name, budget.ID, t.Percent, alert.Spend, budget.Limit, budget.Currency),
+		}
+		if err := tx.Events.Append(ctx, event); err != nil {
+			return err
+		}
+		if action == domain.BudgetActionEvent {
+			return tx.Outbox.Add(ctx, &persistence.OutboxEntry{EventID: event.ID, Region: budget.Region})
+		}
+		payload, err := json.Marshal(newEventEnvelope(PublishedEvent{EventLog: *event, Region: budget.Region}))
+		if err != nil {
+			return err
+		}
+		return tx.Webhooks.CreateDeliveries(ctx, []*persistence.WebhookDelivery{{
+			WebhookID:     t.WebhookID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       string(payload),
+			Status:        string(domain.DeliveryPending),
+			NextAttemptAt: alert.FiredAt,
+		}})
+	})
+	if err != nil || !fired {
+		return err
+	}
+	logging.S(ctx).Infow("Budget threshold reached", "id", budget.ID, "name", budget.Name, "percent", t.Percent, "action", t.Action, "spend", alert.Spend, "limit", budget.Limit)
+	if action == domain.BudgetActionEvent {
+		s.relay.Notify()
+	}
+	return nil
+}
+
+// stopServers stops a budget's running servers through the normal action path
+func (s *budgetService) stopServers(ctx context.Context, budget *persistence.Budget) error {
+	log := logging.S(ctx)
+	servers, err := s.servers.ListMatching(ctx, budget.Region, string(domain.ServerRunning), budget.Selector)
+	if err != nil {
+		return err
+	}
+	var stopErr error
+	for _, server := range servers {
+		_, err := s.actions.Action(ctx, server.ID, domain.ActionStop, 0)
+		switch {
+		case err == nil:
+			log.Infow("Stopped server over budget", "budgetID", budget.ID, "serverID", server.ID)
+		case errors.Is(err, domain.ErrInvalidTransition), errors.Is(err, ErrConcurrentUpdate), errors.Is(err, ErrServerNotFound):
+			// Changed state since it was listed
+		default:
+			log.Errorw("Failed to stop server over budget", "budgetID", budget.ID, "serverID", server.ID, "error", err)
+			if stopErr == nil {
+				stopErr = err
+			}
+		}
+	}
+	return stopErr
+}
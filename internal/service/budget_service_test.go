@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/persistence"
+	mockPersistence "github.com/rhythin/sever-management/internal/persistence/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_budgetService_Create(t *testing.T) {
+	webhooks := &mockPersistence.WebhookRepo{}
+	webhooks.On("GetByID", mock.Anything, "hook-1").Return(&persistence.Webhook{ID: "hook-1"}, nil)
+	webhooks.On("GetByID", mock.Anything, "hook-2").Return(nil, nil)
+	repo := &mockPersistence.BudgetRepo{}
+	repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	regions := &mockPersistence.RegionRepo{}
+	regions.On("GetByName", mock.Anything, "us-east-1").Return(&persistence.Region{Name: "us-east-1"}, nil)
+	regions.On("GetByName", mock.Anything, "mars-1").Return(nil, nil)
+	s := NewBudgetService(repo, nil, nil, webhooks, nil, nil, nil, NewCatalogService(&internal.Config{}, regions), &internal.Config{BillingCurrency: "USD"})
+
+	threshold := func(percent int, action domain.BudgetAction, hook string) *persistence.BudgetThreshold {
+		return &persistence.BudgetThreshold{Percent: percent, Action: string(action), WebhookID: hook}
+	}
+	tests := []struct {
+		name    string
+		budget  *persistence.Budget
+		wantErr error
+	}{
+		{
+			name: "valid",
+			budget: &persistence.Budget{Name: "payments", Region: "us-east-1", Selector: map[string]string{"team": "payments"}, Limit: 100_000_000, Thresholds: []*persistence.BudgetThreshold{
+				threshold(50, domain.BudgetActionEvent, ""), threshold(80, domain.BudgetActionWebhook, "hook-1"), threshold(100, domain.BudgetActionStop, ""),
+			}},
+		},
+		{name: "no name", budget: &persistence.Budget{Limit: 1, Thresholds: []*persistence.BudgetThreshold{threshold(50, domain.BudgetActionEvent, "")}}, wantErr: ErrInvalidBudget},
+		{name: "no limit", budget: &persistence.Budget{Name: "b", Thresholds: []*persistence.BudgetThreshold{threshold(50, domain.BudgetActionEvent, "")}}, wantErr: ErrInvalidBudget},
+		{name: "no thresholds", budget: &persistence.Budget{Name: "b", Limit: 1}, wantErr: ErrInvalidBudget},
+		{name: "unknown region", budget: &persistence.Budget{Name: "b", Limit: 1, Region: "mars-1", Thresholds: []*persistence.BudgetThreshold{threshold(50, domain.BudgetActionEvent, "")}}, wantErr: ErrUnknownRegion},
+		{name: "invalid selector", budget: &persistence.Budget{Name: "b", Limit: 1, Selector: map[string]string{"Team": "x"}, Thresholds: []*persistence.BudgetThreshold{threshold(50, domain.BudgetActionEvent, "")}}, wantErr: domain.ErrInvalidLabel},
+		{name: "zero percent", budget: &persistence.Budget{Name: "b", Limit: 1, Thresholds: []*persistence.BudgetThreshold{threshold(0, domain.BudgetActionEvent, "")}}, wantErr: ErrInvalidBudget},
+		{name: "unknown action", budget: &persistence.Budget{Name: "b", Limit: 1, Thresholds: []*persistence.BudgetThreshold{threshold(50, "page", "")}}, wantErr: ErrInvalidBudget},
+		{name: "webhook without id", budget: &persistence.Budget{Name: "b", Limit: 1, Thresholds: []*persistence.BudgetThreshold{threshold(50, domain.BudgetActionWebhook, "")}}, wantErr: ErrInvalidBudget},
+		{name: "unknown webhook", budget: &persistence.Budget{Name: "b", Limit: 1, Thresholds: []*persistence.BudgetThreshold{threshold(50, domain.BudgetActionWebhook, "hook-2")}}, wantErr: ErrInvalidBudget},
+		{name: "repeated threshold", budget: &persistence.Budget{Name: "b", Limit: 1, Thresholds: []*persistence.BudgetThreshold{threshold(50, domain.BudgetActionEvent, ""), threshold(50, domain.BudgetActionEvent, "")}}, wantErr: ErrInvalidBudget},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Create(context.Background(), tt.budget)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.Currency != "USD" {
+				t.Errorf("Create() currency = %q, want USD", got.Currency)
+			}
+		})
+	}
+}
+
+func Test_budgetService_Evaluate(t *testing.T) {
+	now := time.Date(2026, 4, 20, 12, 0, 0, 0, time.UTC)
+	budget := &persistence.Budget{
+		ID: "budget-1", Name: "payments", Selector: map[string]string{"team": "payments"}, Limit: 100_000_000, Currency: "USD",
+		Thresholds: []*persistence.BudgetThreshold{
+			{ID: 1, Percent: 50, Action: string(domain.BudgetActionEvent)},
+			{ID: 2, Percent: 80, Action: string(domain.BudgetActionWebhook), WebhookID: "hook-1"},
+			{ID: 3, Percent: 100, Action: string(domain.BudgetActionStop)},
+		},
+	}
+	tests := []struct {
+		name        string
+		spend       domain.Micros
+		newAlert    map[uint]bool // thresholds that have not fired this month
+		webhook     bool
+		deliveryErr error
+		stopErr     error
+		stops       bool
+		wantErr     bool
+	}{
+		{name: "under every threshold", spend: 40_000_000},
+		{name: "webhook threshold reached", spend: 85_000_000, newAlert: map[uint]bool{2: true}, webhook: true},
+		{name: "already fired", spend: 85_000_000},
+		{name: "limit reached", spend: 100_000_000, newAlert: map[uint]bool{3: true}, stops: true},
+		{name: "stops keep applying", spend: 120_000_000, stops: true},
+		{name: "failed delivery rolls its alert back", spend: 85_000_000, newAlert: map[uint]bool{2: true}, webhook: true, deliveryErr: errors.New("connection reset"), wantErr: true},
+		{name: "failed stop is not recorded", spend: 100_000_000, newAlert: map[uint]bool{3: true}, stopErr: errors.New("connection reset"), stops: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockPersistence.BudgetRepo{}
+			repo.On("List", mock.Anything).Return([]*persistence.Budget{budget}, nil)
+			repo.On("RecordAlert", mock.Anything, mock.Anything).Return(func(ctx context.Context, a *persistence.BudgetAlert) (bool, error) {
+				if a.Period != "2026-04" || a.Spend != tt.spend {
+					t.Errorf("RecordAlert(%+v), want period 2026-04 and spend %v", a, tt.spend)
+				}
+				return tt.newAlert[a.ThresholdID], nil
+			})
+			usage := &mockPersistence.UsageRepo{}
+			usage.On("Summarize", mock.Anything, persistence.UsageSummaryQuery{
+				From: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), To: now, Labels: budget.Selector,
+			}).Return([]*persistence.UsageSummaryRow{{Currency: "USD", Cost: tt.spend}}, nil)
+			events := &mockPersistence.EventRepo{}
+			events.On("Append", mock.Anything, mock.MatchedBy(func(e *persistence.EventLog) bool {
+				return e.Type == string(domain.EventBudgetThreshold) && e.ServerID == "" && e.Stream == "budget:budget-1"
+			})).Run(func(args mock.Arguments) { args.Get(1).(*persistence.EventLog).ID = 42 }).Return(nil)
+			webhooks := &mockPersistence.WebhookRepo{}
+			webhooks.On("CreateDeliveries", mock.Anything, mock.MatchedBy(func(d []*persistence.WebhookDelivery) bool {
+				return len(d) == 1 && d[0].WebhookID == "hook-1" && d[0].EventID == 42
+			})).Return(tt.deliveryErr)
+			servers := &mockPersistence.ServerRepo{}
+			servers.On("ListMatching", mock.Anything, "", "running", budget.Selector).Return([]*persistence.Server{{ID: "srv-1"}, {ID: "srv-2"}}, nil)
+			actions := &stoppedServers{stopped: map[string]bool{"srv-2": true}, err: tt.stopErr}
+
+			uow := &rollbackCounter{UnitOfWork: mockPersistence.NewUnitOfWork(persistence.Repos{Events: events, Budgets: repo, Webhooks: webhooks})}
+			s := NewBudgetService(repo, usage, servers, webhooks, uow, nil, actions, nil, &internal.Config{})
+			if err := s.Evaluate(context.Background(), now); (err != nil) != tt.wantErr {
+				t.Fatalf("Evaluate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.webhook {
+				webhooks.AssertExpectations(t)
+			} else {
+				webhooks.AssertNotCalled(t, "CreateDeliveries", mock.Anything, mock.Anything)
+			}
+			if tt.deliveryErr != nil && uow.rolledBack != 1 {
+				t.Errorf("Evaluate() rolled back %d units of work, want the alert's", uow.rolledBack)
+			}
+			if tt.stopErr != nil {
+				repo.AssertNotCalled(t, "RecordAlert", mock.Anything, mock.MatchedBy(func(a *persistence.BudgetAlert) bool { return a.ThresholdID == 3 }))
+			}
+			if !tt.stops {
+				servers.AssertNotCalled(t, "ListMatching", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			} else if tt.stopErr == nil && !actions.stopped["srv-1"] {
+				t.Errorf("Evaluate() did not stop srv-1")
+			}
+		})
+	}
+}
+
+// rollbackCounter counts the units of work that failed, and so would have rolled back
+type rollbackCounter struct {
+	persistence.UnitOfWork
+	rolledBack int
+}
+
+func (u *rollbackCounter) Do(ctx context.Context, fn func(tx persistence.Repos) error) error {
+	err := u.UnitOfWork.Do(ctx, fn)
+	if err != nil {
+		u.rolledBack++
+	}
+	return err
+}
+
+// stoppedServers records stop actions; servers already stopped reject them like the FSM does.
+// A set err fails every stop.
+type stoppedServers struct {
+	ServerService
+	stopped map[string]bool
+	err     error
+}
+
+func (s *stoppedServers) Action(ctx context.Context, id string, action domain.ServerAction, ifMatch int64) (*persistence.Server, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if action != domain.ActionStop || s.stopped[id] {
+		return nil, domain.ErrInvalidTransition
+	}
+	s.stopped[id] = true
+	return &persistence.Server{ID: id, State: string(domain.ServerStopped)}, nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/persistence"
+)
+
+var (
+	// ErrUnknownServerType is returned when a server type is not in the catalog
+	ErrUnknownServerType = errors.New("unknown server type")
+	// ErrUnknownRegion is returned when a region is not in the registry
+	ErrUnknownRegion = errors.New("unknown region")
+	// ErrRegionUnavailable is returned when a region is draining or disabled
+	ErrRegionUnavailable = errors.New("region is not accepting new servers")
+	// ErrRegionAtCapacity is returned when a region has reached its server limit
+	ErrRegionAtCapacity = errors.New("region is at capacity")
+	// ErrInvalidRegion is returned when a region update is malformed
+	ErrInvalidRegion = errors.New("invalid region status or capacity")
+)
+
+// RegionUsage is a registry entry together with its live server count
+
+type RegionUsage struct {
+	persistence.Region
+	ActiveServers int64
+}
+
+// CatalogService exposes the server type catalog and the region registry
+
+type catalogService struct {
+	cfg     *internal.Config
+	regions persistence.RegionRepo
+}
+
+func NewCatalogService(cfg *internal.Config, regions persistence.RegionRepo) CatalogService {
+	return &catalogService{cfg: cfg, regions: regions}
+}
+
+func (c *catalogService) ListTypes(ctx context.Context) []internal.ServerTypeSpec {
+	return c.cfg.ServerTypes
+}
+
+// GetType looks up a server type, returning ErrUnknownServerType if it is not offered
+func (c *catalogService) GetType(ctx context.Context, name string) (internal.ServerTypeSpec, error) {
+	spec, ok := c.cfg.ServerTypes.Lookup(name)
+	if !ok {
+		return internal.ServerTypeSpec{}, ErrUnknownServerType
+	}
+	return spec, nil
+}
+
+// ListRegions returns every registered region with its live usage
+func (c *catalogService) ListRegions(ctx context.Context) ([]RegionUsage, error) {
+	regions, err := c.regions.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := c.regions.CountActiveServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	usage := make([]RegionUsage, 0, len(regions))
+	for _, r := range regions {
+		usage = append(usage, RegionUsage{Region: *r, ActiveServers: counts[r.Name]})
+	}
+	return usage, nil
+}
+
+// GetRegion looks up a region, returning ErrUnknownRegion if it is not registered
+func (c *catalogService) GetRegion(ctx context.Context, name string) (*persistence.Region, error) {
+	region, err := c.regions.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if region == nil {
+		return nil, ErrUnknownRegion
+	}
+	return region, nil
+}
+
+// UpdateRegion registers a region or changes its status and capacity
+func (c *catalogService) UpdateRegion(ctx context.Context, name string, status domain.RegionStatus, maxServers int) error {
+	if name == "" || !domain.IsValidRegionStatus(status) || maxServers < 0 {
+		return ErrInvalidRegion
+	}
+	return c.regions.Update(ctx, name, string(status), maxServers)
+}
+
+// AdmitServer checks, within the caller's unit of work, that a region can take one more server.
+// It locks the region's row, so concurrent admissions to a region wait for each other to commit
+// and cannot both take its last slot.
+func (c *catalogService) AdmitServer(ctx context.Context, tx persistence.Repos, name string) error {
+	log := logging.S(ctx)
+	region, err := tx.Regions.LockByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if region == nil {
+		return ErrUnknownRegion
+	}
+	if domain.RegionStatus(region.Status) != domain.RegionEnabled {
+		log.Warnw("Region not accepting new servers", "region", name, "status", region.Status)
+		return ErrRegionUnavailable
+	}
+	active, err := tx.Regions.CountActiveInRegion(ctx, name)
+	if err != nil {
+		return err
+	}
+	if active >= int64(region.MaxServers) {
+		log.Warnw("Region at capacity", "region", name, "active", active, "max", region.MaxServers)
+		return ErrRegionAtCapacity
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/persistence"
+	mockPersistence "github.com/rhythin/sever-management/internal/persistence/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_catalogService_AdmitServer(t *testing.T) {
+	regions := &mockPersistence.RegionRepo{}
+	regions.On("LockByName", mock.Anything, "us-east-1").Return(&persistence.Region{Name: "us-east-1", Status: "enabled", MaxServers: 5}, nil)
+	regions.On("LockByName", mock.Anything, "us-west-1").Return(&persistence.Region{Name: "us-west-1", Status: "enabled", MaxServers: 2}, nil)
+	regions.On("LockByName", mock.Anything, "eu-west-1").Return(&persistence.Region{Name: "eu-west-1", Status: "draining", MaxServers: 5}, nil)
+	regions.On("LockByName", mock.Anything, "us-eats-1").Return(nil, nil)
+	regions.On("CountActiveInRegion", mock.Anything, "us-east-1").Return(int64(4), nil)
+	regions.On("CountActiveInRegion", mock.Anything, "us-west-1").Return(int64(2), nil)
+
+	tests := []struct {
+		name    string
+		region  string
+		wantErr error
+	}{
+		{name: "enabled region with room", region: "us-east-1"},
+		{name: "region at capacity", region: "us-west-1", wantErr: ErrRegionAtCapacity},
+		{name: "draining region", region: "eu-west-1", wantErr: ErrRegionUnavailable},
+		{name: "typo region", region: "us-eats-1", wantErr: ErrUnknownRegion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCatalogService(&internal.Config{}, nil)
+			if err := c.AdmitServer(context.Background(), persistence.Repos{Regions: regions}, tt.region); !errors.Is(err, tt.wantErr) {
+				t.Errorf("catalogService.AdmitServer() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_catalogService_UpdateRegion(t *testing.T) {
+	regions := &mockPersistence.RegionRepo{}
+	regions.On("Update", mock.Anything, "ap-south-1", "disabled", 0).Return(nil)
+
+	c := NewCatalogService(&internal.Config{}, regions)
+	if err := c.UpdateRegion(context.Background(), "ap-south-1", domain.RegionDisabled, 0); err != nil {
+		t.Errorf("catalogService.UpdateRegion() error = %v", err)
+	}
+	if err := c.UpdateRegion(context.Background(), "ap-south-1", "paused", 0); !errors.Is(err, ErrInvalidRegion) {
+		t.Errorf("catalogService.UpdateRegion() error = %v, want %v", err, ErrInvalidRegion)
+	}
+	if err := c.UpdateRegion(context.Background(), "ap-south-1", domain.RegionEnabled, -1); !errors.Is(err, ErrInvalidRegion) {
+		t.Errorf("catalogService.UpdateRegion() error = %v, want %v", err, ErrInvalidRegion)
+	}
+}
+
+func Test_catalogService_ListRegions(t *testing.T) {
+	regions := &mockPersistence.RegionRepo{}
+	regions.On("List", mock.Anything).Return([]*persistence.Region{{Name: "us-east-1", MaxServers: 5}, {Name: "eu-west-1", MaxServers: 5}}, nil)
+	regions.On("CountActiveServers", mock.Anything).Return(map[string]int64{"us-east-1": 4}, nil)
+
+	got, err := NewCatalogService(&internal.Config{}, regions).ListRegions(context.Background())
+	if err != nil {
+		t.Fatalf("catalogService.ListRegions() error = %v", err)
+	}
+	if len(got) != 2 || got[0].ActiveServers != 4 || got[1].ActiveServers != 0 {
+		t.Errorf("catalogService.ListRegions() = %+v", got)
+	}
+}
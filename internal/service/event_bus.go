@@ -0,0 +1,106 @@
+package service
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/persistence"
+)
+
+// PublishedEvent is an event as delivered to stream subscribers, with the
+// region of its server so that region filters don't need a lookup
+
+type PublishedEvent struct {
+	persistence.EventLog
+	Region string
+}
+
+// EventBus fans committed events out to in-process subscribers such as SSE streams
+
+type EventBus struct {
+	mu     sync.Mutex
+	subs   map[*EventSubscription]struct{}
+	buffer int
+}
+
+func NewEventBus(cfg *internal.Config) *EventBus {
+	buffer := cfg.EventStreamBuffer
+	if buffer <= 0 {
+		buffer = 256
+	}
+	return &EventBus{subs: make(map[*EventSubscription]struct{}), buffer: buffer}
+}
+
+// EventSubscription receives published events matching its filter. C is closed when the
+// subscription is closed or when the subscriber falls too far behind; subscribers should
+// then resume from the last event ID they saw.
+
+type EventSubscription struct {
+	C      <-chan PublishedEvent
+	ch     chan PublishedEvent
+	filter persistence.EventQuery
+	bus    *EventBus
+}
+
+// Subscribe registers a subscriber for events matching the server, region and type filters of q
+func (b *EventBus) Subscribe(q persistence.EventQuery) *EventSubscription {
+	ch := make(chan PublishedEvent, b.buffer)
+	sub := &EventSubscription{C: ch, ch: ch, filter: q, bus: b}
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// Close unregisters the subscription; it is safe to call more than once
+func (s *EventSubscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.remove(s)
+}
+
+// remove must be called with b.mu held
+func (b *EventBus) remove(s *EventSubscription) {
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.ch)
+	}
+}
+
+// Publish delivers events to matching subscribers without blocking. A nil bus discards events.
+func (b *EventBus) Publish(region string, events ...*persistence.EventLog) {
+	if b == nil || len(events) == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, e := range events {
+		pe := PublishedEvent{EventLog: *e, Region: region}
+		for sub := range b.subs {
+			if !sub.matches(pe) {
+				continue
+			}
+			select {
+			case sub.ch <- pe:
+			default:
+				// Slow subscriber: disconnect it rather than block writers
+				b.remove(sub)
+			}
+		}
+	}
+}
+
+func (s *EventSubscription) matches(e PublishedEvent) bool {
+	f := s.filter
+	if f.ServerID != "" && f.ServerID != e.ServerID {
+		return false
+	}
+	if f.Region != "" && f.Region != e.Region {
+		return false
+	}
+	if len(f.Types) > 0 && !slices.Contains(f.Types, e.Type) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/persistence"
+)
+
+func Test_EventBus_Filters(t *testing.T) {
+	bus := NewEventBus(&internal.Config{})
+	all := bus.Subscribe(persistence.EventQuery{})
+	terminationsEU := bus.Subscribe(persistence.EventQuery{Region: "eu-west-1", Types: []string{"terminated"}})
+	server := bus.Subscribe(persistence.EventQuery{ServerID: "srv-2"})
+	defer all.Close()
+	defer terminationsEU.Close()
+	defer server.Close()
+
+	bus.Publish("eu-west-1", &persistence.EventLog{ID: 1, ServerID: "srv-1", Type: "stopped"})
+	bus.Publish("eu-west-1", &persistence.EventLog{ID: 2, ServerID: "srv-1", Type: "terminated"})
+	bus.Publish("us-east-1", &persistence.EventLog{ID: 3, ServerID: "srv-2", Type: "terminated"})
+
+	tests := []struct {
+		name string
+		sub  *EventSubscription
+		want []uint
+	}{
+		{name: "no filter", sub: all, want: []uint{1, 2, 3}},
+		{name: "region and type", sub: terminationsEU, want: []uint{2}},
+		{name: "server", sub: server, want: []uint{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.sub.C) != len(tt.want) {
+				t.Fatalf("subscription received %d events, want %d", len(tt.sub.C), len(tt.want))
+			}
+			for _, id := range tt.want {
+				if e := <-tt.sub.C; e.ID != id {
+					t.Errorf("subscription received event %d, want %d", e.ID, id)
+				}
+			}
+		})
+	}
+}
+
+func Test_EventBus_SlowSubscriberDisconnected(t *testing.T) {
+	bus := NewEventBus(&internal.Config{EventStreamBuffer: 1})
+	sub := bus.Subscribe(persistence.EventQuery{})
+
+	bus.Publish("us-east-1", &persistence.EventLog{ID: 1}, &persistence.EventLog{ID: 2})
+
+	if e, ok := <-sub.C; !ok || e.ID != 1 {
+		t.Fatalf("first event = %v, %v; want event 1", e.ID, ok)
+	}
+	if _, ok := <-sub.C; ok {
+		t.Errorf("subscription still open after overflowing its buffer")
+	}
+	sub.Close() // already removed; must not panic
+}
+
+func Test_EventBus_NilDiscards(t *testing.T) {
+	var bus *EventBus
+	bus.Publish("us-east-1", &persistence.EventLog{ID: 1})
+}
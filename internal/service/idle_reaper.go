@@ -2,85 +2,110 @@ package service
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 
 	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
 	"github.com/rhythin/sever-management/internal/logging"
-	"github.com/rhythin/sever-management/internal/persistence"
 	"golang.org/x/sync/errgroup"
 )
 
-// IdleReaper terminates servers stopped for longer than IdleTimeout
+// IdleReaper terminates servers stopped for longer than the idle timeout of their reaper
+// policy through ServerService.Terminate, like the terminate action. Each server is warned
+// REAPER_WARNING before it is reaped. Servers under dry-run policies are only logged.
 
 type IdleReaper struct {
-	servers persistence.ServerRepo
-	cfg     *internal.Config
+	policies ReaperService
+	actions  ServerService
+	cfg      *internal.Config
 }
 
-func NewIdleReaper(servers persistence.ServerRepo, cfg *internal.Config) *IdleReaper {
-	return &IdleReaper{servers: servers, cfg: cfg}
+func NewIdleReaper(policies ReaperService, actions ServerService, cfg *internal.Config) *IdleReaper {
+	return &IdleReaper{policies: policies, actions: actions, cfg: cfg}
 }
 
-func (r *IdleReaper) Run(ctx context.Context) {
-	interval := r.cfg.ReaperInterval
-	if interval <= 0 {
-		interval = time.Minute
-	}
-	for {
-		ticker := time.NewTicker(interval)
-		for {
-			select {
-			case <-ctx.Done():
-				ticker.Stop()
-				return
-			case <-ticker.C:
-				func() {
-					defer func() {
-						if rec := recover(); rec != nil {
-							logging.S(ctx).Errorw("IdleReaper panicked; will continue", "recover", rec)
-						}
-					}()
-					ctx, cancel := context.WithTimeout(ctx, r.cfg.RequestTimeout)
-					defer cancel()
-					r.reap(ctx)
-				}()
-			}
-		}
-	}
+// Job runs the reaper on the JobRunner every REAPER_INTERVAL
+func (r *IdleReaper) Job() Job {
+	return Job{Name: "reaper", Interval: r.cfg.ReaperInterval, Run: r.reap}
 }
 
-func (r *IdleReaper) reap(ctx context.Context) {
+// reap warns and terminates the candidates that are due, at most REAPER_CONCURRENCY
+// terminations at a time. A failure does not cancel the others; once every candidate has been
+// tried, it returns all the errors met, joined.
+func (r *IdleReaper) reap(ctx context.Context) error {
 	log := logging.S(ctx)
 	log.Debugw("IdleReaper running reap")
-	servers, err := r.servers.List(ctx, "", string("stopped"), "", 1000, 0)
+	candidates, err := r.policies.Candidates(ctx)
 	if err != nil {
-		log.Errorw("IdleReaper failed to list servers", "error", err)
-		return
+		log.Errorw("IdleReaper failed to evaluate policies", "error", err)
+		return err
 	}
-	cutoff := time.Now().Add(-r.cfg.IdleTimeout)
-	g, ctx := errgroup.WithContext(ctx)
-	for _, s := range servers {
-		s := s // capture loop var
-		log.Debugw("IdleReaper checking server", "id", s.ID, "stopped_at", s.StoppedAt, "cutoff", cutoff, "now", time.Now(), "condition", s.StoppedAt.Before(cutoff))
-		if s.StoppedAt != nil && s.StoppedAt.Before(cutoff) {
-			s := s
-			g.Go(func() error {
-				if err := r.servers.UpdateState(ctx, s.ID, "terminated"); err != nil {
-					log.Errorw("IdleReaper failed to terminate server", "id", s.ID, "error", err)
-					return err
-				}
-				err = r.servers.UpdateTimestamps(ctx, s.ID, nil, nil, ptrTime(time.Now()))
-				if err != nil {
-					log.Errorw("IdleReaper failed to update timestamps for server", "id", s.ID, "error", err)
-					return err
+	now := time.Now()
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	var g errgroup.Group
+	g.SetLimit(r.concurrency())
+	for _, c := range candidates {
+		s := c.Server
+		log.Debugw("IdleReaper checking server", "id", s.ID, "stopped_at", s.StoppedAt, "policy", c.PolicyName, "reapAt", c.ReapAt)
+		if c.DryRun {
+			if !c.ReapAt.After(now) {
+				log.Infow("IdleReaper would reap server (dry run)", "id", s.ID, "policy", c.PolicyName, "idleTimeout", c.IdleTimeout, "reapAt", c.ReapAt)
+			}
+			continue
+		}
+		if warning := r.cfg.ReaperWarning; warning > 0 && !c.Warned() {
+			// Warn up to a tick early, so that waiting for the next tick does not cut the notice short
+			if c.ReapAt.Add(-warning - r.cfg.ReaperInterval).After(now) {
+				continue
+			}
+			// Reaping waits for the next tick, so that the server is listed again at its new version
+			switch err := r.policies.Warn(ctx, c, now); {
+			case err == nil:
+				log.Infow("IdleReaper warned server", "id", s.ID, "policy", c.PolicyName, "reapAt", c.ReapAt)
+			case errors.Is(err, ErrConcurrentUpdate):
+				log.Infow("IdleReaper skipped server modified since listing", "id", s.ID)
+			default:
+				log.Errorw("IdleReaper failed to warn server", "id", s.ID, "error", err)
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+			continue
+		}
+		if c.ReapAt.After(now) {
+			continue
+		}
+		g.Go(func() error {
+			// The listed version guards against reaping a server started since it was listed
+			if _, err := r.actions.Terminate(ctx, s.ID, domain.CauseReaper, s.Version); err != nil {
+				if errors.Is(err, ErrVersionMismatch) || errors.Is(err, ErrConcurrentUpdate) ||
+					errors.Is(err, ErrServerNotFound) || errors.Is(err, domain.ErrInvalidTransition) {
+					log.Infow("IdleReaper skipped server modified since listing", "id", s.ID)
+					return nil
 				}
-
-				log.Warnw("IdleReaper terminated idle server", "id", s.ID)
+				log.Errorw("IdleReaper failed to terminate server", "id", s.ID, "error", err)
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
 				return nil
-			})
-		}
+			}
+
+			log.Warnw("IdleReaper terminated idle server", "id", s.ID, "policy", c.PolicyName)
+			return nil
+		})
 	}
-	_ = g.Wait()
+	g.Wait()
+	return errors.Join(errs...)
 }
 
-func ptrTime(t time.Time) *time.Time { return &t }
+func (r *IdleReaper) concurrency() int {
+	if r.cfg.ReaperConcurrency > 0 {
+		return r.cfg.ReaperConcurrency
+	}
+	return 4
+}
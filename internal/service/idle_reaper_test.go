@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/persistence"
+	mockPersistence "github.com/rhythin/sever-management/internal/persistence/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_IdleReaper_reap(t *testing.T) {
+	idle := time.Now().Add(-time.Hour)
+	servers := &mockPersistence.ServerRepo{}
+	servers.On("ListMatching", mock.Anything, "", string(domain.ServerStopped), map[string]string(nil)).Return([]*persistence.Server{
+		{ID: "idle", State: "stopped", StoppedAt: &idle, Version: 4},
+		{ID: "kept", Type: "t2.medium", State: "stopped", StoppedAt: &idle, Version: 2},
+		{ID: "trial", State: "stopped", StoppedAt: &idle, Version: 1, Labels: []*persistence.ServerLabel{{Key: "env", Value: "trial"}}},
+		{ID: "started", State: "stopped", StoppedAt: &idle, Version: 6}, // started again since it was listed
+	}, nil)
+	policies := &mockPersistence.ReaperPolicyRepo{}
+	policies.On("List", mock.Anything).Return([]*persistence.ReaperPolicy{
+		{ID: "p1", Name: "medium", Type: "t2.medium", IdleTimeout: 2 * time.Hour},
+		{ID: "p2", Name: "trial", Selector: map[string]string{"env": "trial"}, IdleTimeout: time.Minute, DryRun: true},
+	}, nil)
+	cfg := &internal.Config{IdleTimeout: 30 * time.Minute}
+	actions := &terminatedServers{stale: map[string]bool{"started": true}}
+
+	NewIdleReaper(NewReaperService(policies, servers, nil, nil, nil, cfg), actions, cfg).reap(context.Background())
+
+	if len(actions.terminated) != 1 || actions.terminated["idle"] != 4 {
+		t.Errorf("reap() terminated %v, want idle at version 4", actions.terminated)
+	}
+}
+
+func Test_IdleReaper_reap_warns(t *testing.T) {
+	now := time.Now()
+	stoppedAt := now.Add(-18 * time.Minute).Truncate(time.Second)
+	reapAt := stoppedAt.Add(30 * time.Minute)
+	overdue := now.Add(-time.Hour).Truncate(time.Second)
+	overdueReapAt := overdue.Add(30 * time.Minute)
+	servers := &mockPersistence.ServerRepo{}
+	servers.On("ListMatching", mock.Anything, "", string(domain.ServerStopped), map[string]string(nil)).Return([]*persistence.Server{
+		{ID: "soon", State: "stopped", StoppedAt: &stoppedAt, Version: 2},
+		{ID: "unwarned", State: "stopped", StoppedAt: &overdue, Version: 3},
+		{ID: "warned", State: "stopped", StoppedAt: &overdue, ReapWarnedFor: &overdueReapAt, Version: 4},
+	}, nil)
+	servers.On("UpdateReapSchedule", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	policies := &mockPersistence.ReaperPolicyRepo{}
+	policies.On("List", mock.Anything).Return([]*persistence.ReaperPolicy(nil), nil)
+	events := &mockPersistence.EventRepo{}
+	events.On("Append", mock.Anything, mock.Anything).Return(nil)
+	outbox := &mockPersistence.OutboxRepo{}
+	outbox.On("Add", mock.Anything, mock.Anything).Return(nil)
+	uow := mockPersistence.NewUnitOfWork(persistence.Repos{Servers: servers, Events: events, Outbox: outbox})
+	cfg := &internal.Config{IdleTimeout: 30 * time.Minute, ReaperInterval: 5 * time.Minute, ReaperWarning: 10 * time.Minute}
+	actions := &terminatedServers{}
+
+	NewIdleReaper(NewReaperService(policies, servers, uow, nil, nil, cfg), actions, cfg).reap(context.Background())
+
+	if len(actions.terminated) != 1 || actions.terminated["warned"] != 4 {
+		t.Errorf("reap() terminated %v, want only warned", actions.terminated)
+	}
+	// Warned on time, the server keeps its reap time
+	servers.AssertCalled(t, "UpdateReapSchedule", mock.Anything, "soon", int64(2), (*time.Time)(nil), &reapAt)
+	// Warned late, the server gets the full notice
+	servers.AssertCalled(t, "UpdateReapSchedule", mock.Anything, "unwarned", int64(3), mock.MatchedBy(func(after *time.Time) bool {
+		return after != nil && !after.Before(now.Add(9*time.Minute))
+	}), mock.Anything)
+	events.AssertNumberOfCalls(t, "Append", 2)
+}
+
+func Test_IdleReaper_reap_failures(t *testing.T) {
+	idle := time.Now().Add(-time.Hour)
+	var listed []*persistence.Server
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		listed = append(listed, &persistence.Server{ID: id, State: "stopped", StoppedAt: &idle, Version: 1})
+	}
+	servers := &mockPersistence.ServerRepo{}
+	servers.On("ListMatching", mock.Anything, "", string(domain.ServerStopped), map[string]string(nil)).Return(listed, nil)
+	policies := &mockPersistence.ReaperPolicyRepo{}
+	policies.On("List", mock.Anything).Return([]*persistence.ReaperPolicy(nil), nil)
+	cfg := &internal.Config{IdleTimeout: 30 * time.Minute, ReaperConcurrency: 2}
+	errA, errD := errors.New("a failed"), errors.New("d failed")
+	actions := &terminatedServers{failing: map[string]error{"a": errA, "d": errD}}
+
+	err := NewIdleReaper(NewReaperService(policies, servers, nil, nil, nil, cfg), actions, cfg).reap(context.Background())
+
+	// One failure does not cancel the terminations after it
+	if len(actions.terminated) != 3 || actions.terminated["b"] != 1 || actions.terminated["c"] != 1 || actions.terminated["e"] != 1 {
+		t.Errorf("reap() terminated %v, want b, c and e", actions.terminated)
+	}
+	if !errors.Is(err, errA) || !errors.Is(err, errD) {
+		t.Errorf("reap() error = %v, want both failures", err)
+	}
+}
+
+// terminatedServers records reaper terminations and the versions they were made at; stale
+// servers fail the version check like ServerService.Terminate does, and failing servers fail
+// with their error. A canceled context fails every termination.
+type terminatedServers struct {
+	ServerService
+	mu         sync.Mutex
+	stale      map[string]bool
+	failing    map[string]error
+	terminated map[string]int64
+}
+
+func (s *terminatedServers) Terminate(ctx context.Context, id string, cause domain.TerminationCause, ifMatch int64) (*persistence.Server, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if cause != domain.CauseReaper || s.stale[id] {
+		return nil, ErrVersionMismatch
+	}
+	if err := s.failing[id]; err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.terminated == nil {
+		s.terminated = make(map[string]int64)
+	}
+	s.terminated[id] = ifMatch
+	return &persistence.Server{ID: id, State: string(domain.ServerTerminated)}, nil
+}
@@ -2,16 +2,110 @@ package service
 
 import (
 	"context"
+	"time"
 
+	"github.com/rhythin/sever-management/internal"
 	"github.com/rhythin/sever-management/internal/domain"
 	"github.com/rhythin/sever-management/internal/persistence"
 )
 
 // ServerService defines the interface for server operations needed by handlers
 type ServerService interface {
-	Provision(ctx context.Context, region, typ string) (string, error)
-	Action(ctx context.Context, id string, action domain.ServerAction) error
+	Provision(ctx context.Context, region, typ string, labels map[string]string) (*persistence.Operation, error)
+	GetOperation(ctx context.Context, id string) (*persistence.Operation, error)
+	CompleteOperation(ctx context.Context, id string) error
+	Action(ctx context.Context, id string, action domain.ServerAction, ifMatch int64) (*persistence.Server, error)
+	Terminate(ctx context.Context, id string, cause domain.TerminationCause, ifMatch int64) (*persistence.Server, error)
 	GetEvents(ctx context.Context, id string, n int) ([]persistence.EventLog, error)
+	QueryEvents(ctx context.Context, q persistence.EventQuery) ([]persistence.EventLog, uint, error)
+	SubscribeEvents(q persistence.EventQuery) *EventSubscription
+	ListUsage(ctx context.Context, id string) ([]*persistence.UsageSession, error)
 	ListServers(ctx context.Context, region, status, typ string, limit, offset int) ([]*persistence.Server, error)
 	GetServerByID(ctx context.Context, id string) (*persistence.Server, error)
 }
+
+// CatalogService defines the catalog lookups needed by handlers and the server service
+type CatalogService interface {
+	ListTypes(ctx context.Context) []internal.ServerTypeSpec
+	GetType(ctx context.Context, name string) (internal.ServerTypeSpec, error)
+	ListRegions(ctx context.Context) ([]RegionUsage, error)
+	GetRegion(ctx context.Context, name string) (*persistence.Region, error)
+	UpdateRegion(ctx context.Context, name string, status domain.RegionStatus, maxServers int) error
+	AdmitServer(ctx context.Context, tx persistence.Repos, region string) error
+}
+
+// WebhookService manages webhook subscriptions and exposes their delivery history
+type WebhookService interface {
+	Register(ctx context.Context, url string, types []string) (*persistence.Webhook, error)
+	List(ctx context.Context) ([]*persistence.Webhook, error)
+	Get(ctx context.Context, id string) (*persistence.Webhook, error)
+	Delete(ctx context.Context, id string) error
+	ListDeliveries(ctx context.Context, id string, limit int) ([]*persistence.WebhookDelivery, error)
+}
+
+// PriceService manages the price book and prices uptime from it
+type PriceService interface {
+	AddPrice(ctx context.Context, typ, region string, hourly domain.Micros, effectiveFrom time.Time) (*persistence.Price, error)
+	ListPrices(ctx context.Context, typ, region string) ([]*persistence.Price, error)
+	Charges(ctx context.Context, typ, region string, from, to time.Time) ([]domain.Charge, error)
+}
+
+// InvoiceService serves invoices and their lines
+type InvoiceService interface {
+	List(ctx context.Context, status string) ([]*persistence.Invoice, error)
+	Get(ctx context.Context, id uint) (*persistence.Invoice, error)
+}
+
+// BillingService reports on billed usage for finance and projects spend
+type BillingService interface {
+	Summary(ctx context.Context, groupBy []domain.SummaryGroup, from, to time.Time) ([]*persistence.UsageSummaryRow, error)
+	Forecast(ctx context.Context, now, until time.Time) ([]*ServerForecast, error)
+}
+
+// BudgetService manages budgets and enforces their thresholds
+type BudgetService interface {
+	Create(ctx context.Context, budget *persistence.Budget) (*persistence.Budget, error)
+	List(ctx context.Context) ([]*persistence.Budget, error)
+	Get(ctx context.Context, id string) (*persistence.Budget, error)
+	Delete(ctx context.Context, id string) error
+	Status(ctx context.Context, budget *persistence.Budget, now time.Time) (domain.Micros, []*persistence.BudgetAlert, error)
+	Evaluate(ctx context.Context, now time.Time) error
+}
+
+// ReaperService manages idle reaper policies and plans which stopped servers they reap
+type ReaperService interface {
+	CreatePolicy(ctx context.Context, policy *persistence.ReaperPolicy) (*persistence.ReaperPolicy, error)
+	ListPolicies(ctx context.Context) ([]*persistence.ReaperPolicy, error)
+	GetPolicy(ctx context.Context, id string) (*persistence.ReaperPolicy, error)
+	UpdatePolicy(ctx context.Context, policy *persistence.ReaperPolicy) (*persistence.ReaperPolicy, error)
+	DeletePolicy(ctx context.Context, id string) error
+	Candidates(ctx context.Context) ([]*ReapCandidate, error)
+	ReapAt(ctx context.Context, server *persistence.Server) (*time.Time, error)
+	Warn(ctx context.Context, c *ReapCandidate, now time.Time) error
+	Postpone(ctx context.Context, id string, by time.Duration) (time.Time, error)
+}
+
+// IPPoolService manages IP pools and the CIDRs in them
+type IPPoolService interface {
+	CreatePool(ctx context.Context, name, region string, cidrs []string) (*persistence.IPPool, error)
+	ListPools(ctx context.Context, region string) ([]*persistence.IPPool, error)
+	GetPool(ctx context.Context, id string) (*persistence.IPPool, error)
+	AddCIDR(ctx context.Context, poolID, cidr string) (*persistence.IPPool, error)
+	RetireCIDR(ctx context.Context, poolID string, cidrID uint) (*persistence.IPPool, error)
+	DeletePool(ctx context.Context, id string) error
+}
+
+// JobService lists background jobs and their run history, and runs them on demand
+type JobService interface {
+	ListJobs(ctx context.Context) ([]*JobStatus, error)
+	ListRuns(ctx context.Context, name string, limit int) ([]*persistence.JobRun, error)
+	Trigger(ctx context.Context, name string) error
+}
+
+// EventSink receives committed events from the outbox relay. Delivery is at-least-once:
+// an event is sent again if the relay fails before recording it as dispatched, so sinks
+// must tolerate duplicates, which share an event ID.
+type EventSink interface {
+	Name() string
+	Send(ctx context.Context, e PublishedEvent) error
+}
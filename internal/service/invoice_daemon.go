@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/persistence"
+)
+
+// InvoiceDaemon periodically regenerates the draft invoice of the current period from billed
+// usage, and closes each past period once INVOICE_CLOSE_DELAY has passed since it ended,
+// freezing its invoice. Charges for a closed period that are billed later appear as
+// adjustment lines on the next invoice generated.
+
+type InvoiceDaemon struct {
+	repo persistence.InvoiceRepo
+	cfg  *internal.Config
+}
+
+func NewInvoiceDaemon(repo persistence.InvoiceRepo, cfg *internal.Config) *InvoiceDaemon {
+	return &InvoiceDaemon{repo: repo, cfg: cfg}
+}
+
+// Job runs invoicing on the JobRunner every INVOICE_INTERVAL
+func (d *InvoiceDaemon) Job() Job {
+	interval := d.cfg.InvoiceInterval
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+	return Job{Name: "invoices", Interval: interval, Run: func(ctx context.Context) error { return d.sync(ctx, time.Now()) }}
+}
+
+// sync generates invoices for the current period and every period with uninvoiced charges,
+// oldest first, so that past periods are closed before later invoices take their late
+// charges as adjustments
+func (d *InvoiceDaemon) sync(ctx context.Context, now time.Time) error {
+	log := logging.S(ctx)
+	periods, err := d.repo.ListUninvoicedPeriods(ctx)
+	if err != nil {
+		log.Errorw("InvoiceDaemon failed to list uninvoiced periods", "error", err)
+		return err
+	}
+	if current := domain.PeriodOf(now); !slices.Contains(periods, current) {
+		periods = append(periods, current)
+	}
+	slices.Sort(periods)
+	for _, period := range periods {
+		if err := d.invoice(ctx, period, now); err != nil {
+			log.Errorw("InvoiceDaemon failed to generate invoice", "period", period, "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// invoice regenerates a period's draft invoice, closing it if the period is over
+func (d *InvoiceDaemon) invoice(ctx context.Context, period string, now time.Time) error {
+	start, end, err := domain.PeriodBounds(period)
+	if err != nil {
+		return err
+	}
+	invoice, err := d.repo.EnsureDraft(ctx, &persistence.Invoice{
+		Period:      period,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Currency:    d.cfg.BillingCurrency,
+	})
+	if err != nil {
+		return err
+	}
+	if invoice.Status == string(domain.InvoiceClosed) {
+		return nil // late charges go on the next invoice
+	}
+	closing := !now.Before(end.Add(d.closeDelay()))
+	if err := d.repo.Refresh(ctx, invoice.ID, closing); err != nil && !errors.Is(err, persistence.ErrInvoiceClosed) {
+		return err
+	}
+	if closing {
+		logging.S(ctx).Infow("Invoice closed", "id", invoice.ID, "period", period)
+	}
+	return nil
+}
+
+// closeDelay leaves time for the last increments of a period to be billed before it is closed
+func (d *InvoiceDaemon) closeDelay() time.Duration {
+	if d.cfg.InvoiceCloseDelay > 0 {
+		return d.cfg.InvoiceCloseDelay
+	}
+	return time.Hour
+}
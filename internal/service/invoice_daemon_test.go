@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/persistence"
+	mockPersistence "github.com/rhythin/sever-management/internal/persistence/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_InvoiceDaemon_sync(t *testing.T) {
+	period := func(p string) any {
+		return mock.MatchedBy(func(i *persistence.Invoice) bool { return i.Period == p })
+	}
+	tests := []struct {
+		name  string
+		now   time.Time
+		close bool // whether April is closed
+	}{
+		{name: "within close delay", now: time.Date(2026, 5, 1, 0, 30, 0, 0, time.UTC), close: false},
+		{name: "after close delay", now: time.Date(2026, 5, 1, 1, 0, 0, 0, time.UTC), close: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockPersistence.InvoiceRepo{}
+			// March was closed before some of its usage was billed
+			repo.On("ListUninvoicedPeriods", mock.Anything).Return([]string{"2026-04", "2026-03"}, nil)
+			repo.On("EnsureDraft", mock.Anything, period("2026-03")).Return(&persistence.Invoice{ID: 3, Period: "2026-03", Status: "closed"}, nil)
+			repo.On("EnsureDraft", mock.Anything, period("2026-04")).Return(&persistence.Invoice{ID: 4, Period: "2026-04", Status: "draft"}, nil)
+			repo.On("EnsureDraft", mock.Anything, period("2026-05")).Return(&persistence.Invoice{ID: 5, Period: "2026-05", Status: "draft"}, nil)
+			repo.On("Refresh", mock.Anything, uint(4), tt.close).Return(nil).Once()
+			repo.On("Refresh", mock.Anything, uint(5), false).Return(nil).Once()
+
+			NewInvoiceDaemon(repo, &internal.Config{InvoiceCloseDelay: time.Hour}).sync(context.Background(), tt.now)
+
+			repo.AssertExpectations(t)
+			repo.AssertNotCalled(t, "Refresh", mock.Anything, uint(3), mock.Anything)
+			var order []uint
+			for _, call := range repo.Calls {
+				if call.Method == "Refresh" {
+					order = append(order, call.Arguments.Get(1).(uint))
+				}
+			}
+			if len(order) != 2 || order[0] != 4 || order[1] != 5 {
+				t.Errorf("sync() refreshed invoices %v, want [4 5]", order)
+			}
+		})
+	}
+}
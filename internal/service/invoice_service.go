@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rhythin/sever-management/internal/persistence"
+)
+
+// ErrInvoiceNotFound is returned when an invoice does not exist
+var ErrInvoiceNotFound = errors.New("invoice not found")
+
+// InvoiceService serves the invoices the invoice daemon generates
+
+type invoiceService struct {
+	repo persistence.InvoiceRepo
+}
+
+func NewInvoiceService(repo persistence.InvoiceRepo) InvoiceService {
+	return &invoiceService{repo: repo}
+}
+
+// List returns invoices without their lines, newest period first, optionally with one status
+func (s *invoiceService) List(ctx context.Context, status string) ([]*persistence.Invoice, error) {
+	return s.repo.List(ctx, status)
+}
+
+// Get returns an invoice with its lines
+func (s *invoiceService) Get(ctx context.Context, id uint) (*persistence.Invoice, error) {
+	invoice, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice == nil {
+		return nil, ErrInvoiceNotFound
+	}
+	return invoice, nil
+}
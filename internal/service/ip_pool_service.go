@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/persistence"
+)
+
+var (
+	// ErrIPPoolNotFound is returned when an IP pool ID is unknown
+	ErrIPPoolNotFound = errors.New("IP pool not found")
+	// ErrPoolCIDRNotFound is returned when a CIDR ID is not one of the pool's
+	ErrPoolCIDRNotFound = errors.New("CIDR not found in IP pool")
+	// ErrInvalidIPPool is returned when an IP pool's name or CIDRs are malformed
+	ErrInvalidIPPool = errors.New("invalid IP pool")
+	// ErrIPPoolConflict is returned when a pool name is taken or a CIDR overlaps one already
+	// in a pool, retired or not
+	ErrIPPoolConflict = errors.New("IP pool conflict")
+	// ErrIPPoolInUse is returned when deleting a pool with addresses still allocated
+	ErrIPPoolInUse = errors.New("IP pool has allocated addresses")
+)
+
+// IPPoolService manages the IP pools that servers get their addresses from. A pool belongs
+// to one region for good, and servers are only given addresses from their region's pools.
+// CIDRs never overlap, so an address belongs to at most one pool; a retired CIDR keeps its
+// place so that its addresses are not handed out elsewhere while servers may still hold them.
+
+type ipPoolService struct {
+	repo    persistence.IPPoolRepo
+	catalog CatalogService
+}
+
+func NewIPPoolService(repo persistence.IPPoolRepo, catalog CatalogService) IPPoolService {
+	return &ipPoolService{repo: repo, catalog: catalog}
+}
+
+func (s *ipPoolService) CreatePool(ctx context.Context, name, region string, cidrs []string) (*persistence.IPPool, error) {
+	log := logging.S(ctx)
+	log.Infow("IPPoolService.CreatePool called", "name", name, "region", region, "cidrs", cidrs)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidIPPool)
+	}
+	if _, err := s.catalog.GetRegion(ctx, region); err != nil {
+		return nil, err
+	}
+	nets, err := parsePoolCIDRs(cidrs)
+	if err != nil {
+		log.Warnw("Rejected IP pool", "name", name, "error", err)
+		return nil, err
+	}
+	pools, err := s.repo.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pools {
+		if p.Name == name {
+			return nil, fmt.Errorf("%w: name %q is taken", ErrIPPoolConflict, name)
+		}
+	}
+	if err := checkOverlap(pools, nets); err != nil {
+		log.Warnw("Rejected IP pool", "name", name, "error", err)
+		return nil, err
+	}
+	pool := &persistence.IPPool{Name: name, Region: region}
+	for _, n := range nets {
+		pool.CIDRs = append(pool.CIDRs, &persistence.IPPoolCIDR{CIDR: n.String()})
+	}
+	// The checks above race with concurrent changes; the repo's unique constraints settle it
+	if err := s.repo.Create(ctx, pool); err != nil {
+		if errors.Is(err, persistence.ErrIPPoolExists) {
+			return nil, fmt.Errorf("%w: name %q or one of its CIDRs is taken", ErrIPPoolConflict, name)
+		}
+		return nil, err
+	}
+	return s.GetPool(ctx, pool.ID)
+}
+
+// ListPools returns the pools in a region, or all pools if region is empty
+func (s *ipPoolService) ListPools(ctx context.Context, region string) ([]*persistence.IPPool, error) {
+	return s.repo.List(ctx, region)
+}
+
+func (s *ipPoolService) GetPool(ctx context.Context, id string) (*persistence.IPPool, error) {
+	pool, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pool == nil {
+		return nil, ErrIPPoolNotFound
+	}
+	return pool, nil
+}
+
+// AddCIDR adds a CIDR to a pool, making its addresses available to the pool's region
+func (s *ipPoolService) AddCIDR(ctx context.Context, poolID, cidr string) (*persistence.IPPool, error) {
+	log := logging.S(ctx)
+	log.Infow("IPPoolService.AddCIDR called", "id", poolID, "cidr", cidr)
+	if _, err := s.GetPool(ctx, poolID); err != nil {
+		return nil, err
+	}
+	nets, err := parsePoolCIDRs([]string{cidr})
+	if err != nil {
+		return nil, err
+	}
+	pools, err := s.repo.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOverlap(pools, nets); err != nil {
+		log.Warnw("Rejected IP pool CIDR", "id", poolID, "error", err)
+		return nil, err
+	}
+	if err := s.repo.AddCIDR(ctx, &persistence.IPPoolCIDR{PoolID: poolID, CIDR: nets[0].String()}); err != nil {
+		if errors.Is(err, persistence.ErrIPPoolExists) {
+			return nil, fmt.Errorf("%w: %s is taken", ErrIPPoolConflict, nets[0])
+		}
+		return nil, err
+	}
+	return s.GetPool(ctx, poolID)
+}
+
+// RetireCIDR stops a pool's CIDR from being allocated from. Servers holding its addresses
+// keep them. Retiring a retired CIDR does nothing.
+func (s *ipPoolService) RetireCIDR(ctx context.Context, poolID string, cidrID uint) (*persistence.IPPool, error) {
+	log := logging.S(ctx)
+	log.Infow("IPPoolService.RetireCIDR called", "id", poolID, "cidrID", cidrID)
+	pool, err := s.GetPool(ctx, poolID)
+	if err != nil {
+		return nil, err
+	}
+	var cidr *persistence.IPPoolCIDR
+	for _, c := range pool.CIDRs {
+		if c.ID == cidrID {
+			cidr = c
+		}
+	}
+	if cidr == nil {
+		return nil, ErrPoolCIDRNotFound
+	}
+	if cidr.RetiredAt != nil {
+		return pool, nil
+	}
+	if err := s.repo.RetireCIDR(ctx, cidrID, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.GetPool(ctx, poolID)
+}
+
+// DeletePool removes a pool and its addresses, refusing while any of them is allocated
+func (s *ipPoolService) DeletePool(ctx context.Context, id string) error {
+	log := logging.S(ctx)
+	log.Infow("IPPoolService.DeletePool called", "id", id)
+	pool, err := s.GetPool(ctx, id)
+	if err != nil {
+		return err
+	}
+	var allocated int64
+	for _, c := range pool.CIDRs {
+		allocated += c.Allocated
+	}
+	if allocated > 0 {
+		return fmt.Errorf("%w: %d in use", ErrIPPoolInUse, allocated)
+	}
+	// An address may be allocated after the check above; the repo rechecks under lock
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Is(err, persistence.ErrIPPoolAllocated) {
+			return fmt.Errorf("%w: an address was allocated while deleting", ErrIPPoolInUse)
+		}
+		return err
+	}
+	return nil
+}
+
+func parsePoolCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		n, err := domain.ParsePoolCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidIPPool, err)
+		}
+		for _, other := range nets {
+			if domain.CIDRsOverlap(n, other) {
+				return nil, fmt.Errorf("%w: %s overlaps %s", ErrInvalidIPPool, n, other)
+			}
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// checkOverlap rejects CIDRs overlapping any CIDR of the existing pools, including retired ones
+func checkOverlap(pools []*persistence.IPPool, nets []*net.IPNet) error {
+	for _, p := range pools {
+		for _, c := range p.CIDRs {
+			_, existing, err := net.ParseCIDR(c.CIDR)
+			if err != nil {
+				continue
+			}
+			for _, n := range nets {
+				if domain.CIDRsOverlap(n, existing) {
+					return fmt.Errorf("%w: %s overlaps %s in pool %s", ErrIPPoolConflict, n, c.CIDR, p.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/persistence"
+	mockPersistence "github.com/rhythin/sever-management/internal/persistence/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newIPPoolCatalog() CatalogService {
+	regions := &mockPersistence.RegionRepo{}
+	regions.On("GetByName", mock.Anything, "us-east-1").Return(&persistence.Region{Name: "us-east-1", Status: "enabled"}, nil)
+	regions.On("GetByName", mock.Anything, mock.Anything).Return(nil, nil)
+	return NewCatalogService(&internal.Config{}, regions)
+}
+
+func Test_ipPoolService_CreatePool(t *testing.T) {
+	retired := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	repo := &mockPersistence.IPPoolRepo{}
+	repo.On("List", mock.Anything, "").Return([]*persistence.IPPool{
+		{ID: "west", Name: "us-west", Region: "us-west-1", CIDRs: []*persistence.IPPoolCIDR{
+			{ID: 1, PoolID: "west", CIDR: "10.1.0.0/24"},
+			{ID: 2, PoolID: "west", CIDR: "10.2.0.0/24", RetiredAt: &retired},
+		}},
+	}, nil)
+	// Created by a concurrent request after the name check
+	repo.On("Create", mock.Anything, mock.MatchedBy(func(p *persistence.IPPool) bool { return p.Name == "racer" })).Return(persistence.ErrIPPoolExists)
+	repo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*persistence.IPPool).ID = "east"
+	}).Return(nil)
+	repo.On("GetByID", mock.Anything, "east").Return(&persistence.IPPool{ID: "east"}, nil)
+	s := NewIPPoolService(repo, newIPPoolCatalog())
+
+	tests := []struct {
+		name    string
+		pool    string
+		region  string
+		cidrs   []string
+		wantErr error
+	}{
+		{name: "valid", pool: "us-east", region: "us-east-1", cidrs: []string{"10.3.0.0/24", "10.3.1.0/24"}},
+		{name: "no CIDRs", pool: "us-east", region: "us-east-1"},
+		{name: "no name", region: "us-east-1", wantErr: ErrInvalidIPPool},
+		{name: "unknown region", pool: "mars", region: "mars-1", wantErr: ErrUnknownRegion},
+		{name: "invalid CIDR", pool: "us-east", region: "us-east-1", cidrs: []string{"10.3.0.1/24"}, wantErr: domain.ErrInvalidCIDR},
+		{name: "CIDRs overlap each other", pool: "us-east", region: "us-east-1", cidrs: []string{"10.3.0.0/16", "10.3.1.0/24"}, wantErr: ErrInvalidIPPool},
+		{name: "name taken", pool: "us-west", region: "us-east-1", wantErr: ErrIPPoolConflict},
+		{name: "overlaps another pool", pool: "us-east", region: "us-east-1", cidrs: []string{"10.1.0.128/25"}, wantErr: ErrIPPoolConflict},
+		{name: "overlaps a retired CIDR", pool: "us-east", region: "us-east-1", cidrs: []string{"10.2.0.0/16"}, wantErr: ErrIPPoolConflict},
+		{name: "name taken concurrently", pool: "racer", region: "us-east-1", wantErr: ErrIPPoolConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.CreatePool(context.Background(), tt.pool, tt.region, tt.cidrs); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreatePool() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	repo.AssertNumberOfCalls(t, "Create", 3)
+}
+
+func Test_ipPoolService_AddCIDR(t *testing.T) {
+	repo := &mockPersistence.IPPoolRepo{}
+	pool := &persistence.IPPool{ID: "east", Name: "us-east", Region: "us-east-1", CIDRs: []*persistence.IPPoolCIDR{{ID: 1, PoolID: "east", CIDR: "10.1.0.0/24"}}}
+	repo.On("GetByID", mock.Anything, "east").Return(pool, nil)
+	repo.On("GetByID", mock.Anything, mock.Anything).Return(nil, nil)
+	repo.On("List", mock.Anything, "").Return([]*persistence.IPPool{pool}, nil)
+	repo.On("AddCIDR", mock.Anything, &persistence.IPPoolCIDR{PoolID: "east", CIDR: "10.1.1.0/24"}).Return(nil)
+	repo.On("AddCIDR", mock.Anything, &persistence.IPPoolCIDR{PoolID: "east", CIDR: "10.1.2.0/24"}).Return(persistence.ErrIPPoolExists)
+	s := NewIPPoolService(repo, newIPPoolCatalog())
+
+	_, err := s.AddCIDR(context.Background(), "east", "10.1.1.0/24")
+	require.NoError(t, err)
+	_, err = s.AddCIDR(context.Background(), "east", "10.1.0.0/23")
+	assert.ErrorIs(t, err, ErrIPPoolConflict)
+	// Added by a concurrent request after the overlap check
+	_, err = s.AddCIDR(context.Background(), "east", "10.1.2.0/24")
+	assert.ErrorIs(t, err, ErrIPPoolConflict)
+	_, err = s.AddCIDR(context.Background(), "east", "2001:db8::/120")
+	assert.ErrorIs(t, err, ErrInvalidIPPool)
+	_, err = s.AddCIDR(context.Background(), "west", "10.9.0.0/24")
+	assert.ErrorIs(t, err, ErrIPPoolNotFound)
+	repo.AssertNumberOfCalls(t, "AddCIDR", 2)
+}
+
+func Test_ipPoolService_RetireCIDR(t *testing.T) {
+	retired := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	repo := &mockPersistence.IPPoolRepo{}
+	repo.On("GetByID", mock.Anything, "east").Return(&persistence.IPPool{ID: "east", CIDRs: []*persistence.IPPoolCIDR{
+		{ID: 1, PoolID: "east", CIDR: "10.1.0.0/24"},
+		{ID: 2, PoolID: "east", CIDR: "10.1.1.0/24", RetiredAt: &retired},
+	}}, nil)
+	repo.On("RetireCIDR", mock.Anything, uint(1), mock.Anything).Return(nil)
+	s := NewIPPoolService(repo, newIPPoolCatalog())
+
+	_, err := s.RetireCIDR(context.Background(), "east", 1)
+	require.NoError(t, err)
+	_, err = s.RetireCIDR(context.Background(), "east", 2)
+	require.NoError(t, err)
+	_, err = s.RetireCIDR(context.Background(), "east", 3)
+	assert.ErrorIs(t, err, ErrPoolCIDRNotFound)
+	repo.AssertNumberOfCalls(t, "RetireCIDR", 1)
+}
+
+func Test_ipPoolService_DeletePool(t *testing.T) {
+	repo := &mockPersistence.IPPoolRepo{}
+	repo.On("GetByID", mock.Anything, "busy").Return(&persistence.IPPool{ID: "busy", CIDRs: []*persistence.IPPoolCIDR{
+		{ID: 1, PoolID: "busy", CIDR: "10.1.0.0/24", Addresses: 254, Allocated: 3},
+	}}, nil)
+	repo.On("GetByID", mock.Anything, "idle").Return(&persistence.IPPool{ID: "idle", CIDRs: []*persistence.IPPoolCIDR{
+		{ID: 2, PoolID: "idle", CIDR: "10.2.0.0/24", Addresses: 254},
+	}}, nil)
+	// An address of "racing" is allocated after it was read as idle; the locked recheck catches it
+	repo.On("GetByID", mock.Anything, "racing").Return(&persistence.IPPool{ID: "racing", CIDRs: []*persistence.IPPoolCIDR{
+		{ID: 3, PoolID: "racing", CIDR: "10.3.0.0/24", Addresses: 254},
+	}}, nil)
+	repo.On("GetByID", mock.Anything, mock.Anything).Return(nil, nil)
+	repo.On("Delete", mock.Anything, "idle").Return(nil)
+	repo.On("Delete", mock.Anything, "racing").Return(persistence.ErrIPPoolAllocated)
+	s := NewIPPoolService(repo, newIPPoolCatalog())
+
+	assert.ErrorIs(t, s.DeletePool(context.Background(), "busy"), ErrIPPoolInUse)
+	assert.ErrorIs(t, s.DeletePool(context.Background(), "racing"), ErrIPPoolInUse)
+	assert.ErrorIs(t, s.DeletePool(context.Background(), "gone"), ErrIPPoolNotFound)
+	require.NoError(t, s.DeletePool(context.Background(), "idle"))
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/metrics"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrJobNotFound is returned when a job name is unknown
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobBusy is returned when triggering a job that already has a manual run queued
+	ErrJobBusy = errors.New("job run already queued")
+	// ErrJobSkipped is returned by a job run that had nothing to do on this replica
+	ErrJobSkipped = errors.New("job skipped")
+)
+
+// Job is periodic background work for the JobRunner
+
+type Job struct {
+	Name       string
+	Interval   time.Duration
+	RunAtStart bool                            // run at startup rather than an interval later
+	Run        func(ctx context.Context) error // ErrJobSkipped records the run as skipped
+	Stop       func(ctx context.Context)       // optional, called once the runner stops
+}
+
+// JobStatus is a job's schedule and its most recent run
+
+type JobStatus struct {
+	Name      string
+	Interval  time.Duration
+	Timeout   time.Duration
+	Running   bool
+	Failures  int // consecutive failed runs
+	NextRunAt time.Time
+	LastRun   *persistence.JobRun
+}
+
+// JobRunner runs each job on its own loop: every interval plus up to JOB_JITTER of it at
+// random, so that replicas drift apart, or after a failure again after JOB_BACKOFF, doubling
+// with each consecutive failure up to the interval. Runs of a job never overlap, each is
+// bounded by JOB_TIMEOUT (or its JOB_TIMEOUTS override), and a panic fails the run rather
+// than the loop. Every run is recorded in the job's history, which keeps the last
+// JOB_HISTORY runs, and in the job_run_duration_seconds and job_runs_total metrics.
+
+type JobRunner struct {
+	repo persistence.JobRunRepo
+	cfg  *internal.Config
+	jobs []*jobState // in registration order
+}
+
+// jobState is a job and its place in its schedule
+
+type jobState struct {
+	Job
+	timeout time.Duration
+	trigger chan struct{} // a queued manual run
+
+	mu       sync.Mutex
+	running  bool
+	failures int
+	next     time.Time
+}
+
+func NewJobRunner(repo persistence.JobRunRepo, cfg *internal.Config, jobs ...Job) *JobRunner {
+	r := &JobRunner{repo: repo, cfg: cfg}
+	for _, job := range jobs {
+		if job.Interval <= 0 {
+			job.Interval = time.Minute
+		}
+		timeout, ok := cfg.JobTimeouts[job.Name]
+		if !ok {
+			timeout = cfg.JobTimeout
+		}
+		if timeout <= 0 {
+			timeout = cfg.RequestTimeout
+		}
+		r.jobs = append(r.jobs, &jobState{Job: job, timeout: timeout, trigger: make(chan struct{}, 1)})
+	}
+	return r
+}
+
+// Run runs every job until ctx is done
+func (r *JobRunner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, j := range r.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.loop(ctx, j)
+		}()
+	}
+	wg.Wait()
+}
+
+func (r *JobRunner) loop(ctx context.Context, j *jobState) {
+	zap.S().Infow("Job started", "job", j.Name, "interval", j.Interval, "timeout", j.timeout)
+	delay := r.jitter(j.Interval)
+	if j.RunAtStart {
+		delay = 0
+	}
+	for {
+		j.mu.Lock()
+		j.next = time.Now().Add(delay)
+		j.mu.Unlock()
+		timer := time.NewTimer(delay)
+		trigger := domain.JobTriggerSchedule
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if j.Stop != nil {
+				stopCtx, cancel := context.WithTimeout(context.Background(), r.cfg.RequestTimeout)
+				j.Stop(stopCtx)
+				cancel()
+			}
+			zap.S().Infow("Job stopped", "job", j.Name)
+			return
+		case <-timer.C:
+		case <-j.trigger:
+			timer.Stop()
+			trigger = domain.JobTriggerManual
+		}
+		delay = r.delay(j, r.run(ctx, j, trigger))
+	}
+}
+
+// run runs a job once and records the run, returning the job's consecutive failures
+func (r *JobRunner) run(ctx context.Context, j *jobState, trigger domain.JobTrigger) int {
+	log := zap.S().With("job", j.Name)
+	run := &persistence.JobRun{
+		Job:       j.Name,
+		Trigger:   string(trigger),
+		Status:    string(domain.JobRunRunning),
+		StartedAt: time.Now(),
+	}
+	r.record(ctx, func(ctx context.Context) error { return r.repo.Create(ctx, run) })
+	j.mu.Lock()
+	j.running = true
+	j.mu.Unlock()
+
+	err := r.call(ctx, j)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	result := "success"
+	switch {
+	case err == nil:
+		run.Status = string(domain.JobRunSucceeded)
+	case errors.Is(err, ErrJobSkipped):
+		run.Status, result = string(domain.JobRunSkipped), "skipped"
+		log.Debugw("Job skipped", "trigger", trigger)
+	default:
+		run.Status, run.Error, result = string(domain.JobRunFailed), err.Error(), "failure"
+		log.Errorw("Job failed", "trigger", trigger, "error", err)
+	}
+	metrics.ObserveJobRun(j.Name, result, finished.Sub(run.StartedAt))
+
+	j.mu.Lock()
+	j.running = false
+	if result == "failure" {
+		j.failures++
+	} else {
+		j.failures = 0
+	}
+	failures := j.failures
+	j.mu.Unlock()
+
+	if run.ID != 0 {
+		r.record(ctx, func(ctx context.Context) error { return r.repo.Finish(ctx, run) })
+		r.record(ctx, func(ctx context.Context) error { return r.repo.Prune(ctx, j.Name, r.history()) })
+	}
+	return failures
+}
+
+// call runs a job within its timeout, turning a panic into an error
+func (r *JobRunner) call(ctx context.Context, j *jobState) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, j.timeout)
+	defer cancel()
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("job panicked: %v", rec)
+		}
+	}()
+	return j.Run(ctx)
+}
+
+// record writes run history; failing to record a run does not fail the run
+func (r *JobRunner) record(ctx context.Context, write func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.RequestTimeout)
+	defer cancel()
+	if err := write(ctx); err != nil {
+		zap.S().Errorw("JobRunner failed to record job run", "error", err)
+	}
+}
+
+// delay returns the wait before a job's next run: its interval, or after consecutive
+// failures JOB_BACKOFF doubled for each but the first, up to the interval. Either way some
+// jitter is added.
+func (r *JobRunner) delay(j *jobState, failures int) time.Duration {
+	if failures == 0 {
+		return r.jitter(j.Interval)
+	}
+	delay := r.cfg.JobBackoff
+	if delay <= 0 {
+		delay = 10 * time.Second
+	}
+	for i := 1; i < failures && delay < j.Interval; i++ {
+		delay *= 2
+	}
+	return r.jitter(min(delay, j.Interval))
+}
+
+// jitter adds up to JOB_JITTER of d at random
+func (r *JobRunner) jitter(d time.Duration) time.Duration {
+	if spread := time.Duration(float64(d) * r.cfg.JobJitter); spread > 0 {
+		return d + rand.N(spread)
+	}
+	return d
+}
+
+func (r *JobRunner) history() int {
+	if r.cfg.JobHistory > 0 {
+		return r.cfg.JobHistory
+	}
+	return 100
+}
+
+// ListJobs returns every job's schedule and last run, in registration order
+func (r *JobRunner) ListJobs(ctx context.Context) ([]*JobStatus, error) {
+	statuses := make([]*JobStatus, 0, len(r.jobs))
+	for _, j := range r.jobs {
+		runs, err := r.repo.List(ctx, j.Name, 1)
+		if err != nil {
+			return nil, err
+		}
+		j.mu.Lock()
+		status := &JobStatus{
+			Name:      j.Name,
+			Interval:  j.Interval,
+			Timeout:   j.timeout,
+			Running:   j.running,
+			Failures:  j.failures,
+			NextRunAt: j.next,
+		}
+		j.mu.Unlock()
+		if len(runs) > 0 {
+			status.LastRun = runs[0]
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// ListRuns returns a job's most recent runs, newest first
+func (r *JobRunner) ListRuns(ctx context.Context, name string, limit int) ([]*persistence.JobRun, error) {
+	if r.job(name) == nil {
+		return nil, ErrJobNotFound
+	}
+	return r.repo.List(ctx, name, limit)
+}
+
+// Trigger queues a run of a job on this replica, to start as soon as any current run ends
+func (r *JobRunner) Trigger(ctx context.Context, name string) error {
+	j := r.job(name)
+	if j == nil {
+		return ErrJobNotFound
+	}
+	select {
+	case j.trigger <- struct{}{}:
+		zap.S().Infow("Job run triggered", "job", name)
+		return nil
+	default:
+		return ErrJobBusy
+	}
+}
+
+func (r *JobRunner) job(name string) *jobState {
+	for _, j := range r.jobs {
+		if j.Name == name {
+			return j
+		}
+	}
+	return nil
+}
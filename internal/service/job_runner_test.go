@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/persistence"
+	mockPersistence "github.com/rhythin/sever-management/internal/persistence/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_JobRunner_run(t *testing.T) {
+	tests := []struct {
+		name       string
+		run        func(ctx context.Context) error
+		wantStatus domain.JobRunStatus
+		wantErr    string
+	}{
+		{name: "succeeded", run: func(ctx context.Context) error { return nil }, wantStatus: domain.JobRunSucceeded},
+		{name: "skipped", run: func(ctx context.Context) error { return ErrJobSkipped }, wantStatus: domain.JobRunSkipped},
+		{name: "failed", run: func(ctx context.Context) error { return errors.New("db error") }, wantStatus: domain.JobRunFailed, wantErr: "db error"},
+		{name: "panicked", run: func(ctx context.Context) error { panic("boom") }, wantStatus: domain.JobRunFailed, wantErr: "job panicked: boom"},
+		{name: "timed out", run: func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }, wantStatus: domain.JobRunFailed, wantErr: context.DeadlineExceeded.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockPersistence.JobRunRepo{}
+			repo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) { args.Get(1).(*persistence.JobRun).ID = 7 }).Return(nil)
+			repo.On("Finish", mock.Anything, mock.Anything).Return(nil)
+			repo.On("Prune", mock.Anything, "test", 100).Return(nil)
+			cfg := &internal.Config{JobTimeouts: map[string]time.Duration{"test": 10 * time.Millisecond}, RequestTimeout: time.Second}
+			r := NewJobRunner(repo, cfg, Job{Name: "test", Run: tt.run})
+
+			failures := r.run(context.Background(), r.jobs[0], domain.JobTriggerManual)
+
+			repo.AssertExpectations(t)
+			run := repo.Calls[1].Arguments.Get(1).(*persistence.JobRun)
+			if run.Status != string(tt.wantStatus) || run.Error != tt.wantErr || run.Trigger != string(domain.JobTriggerManual) || run.FinishedAt == nil {
+				t.Errorf("run() recorded %+v, want status %s, error %q", run, tt.wantStatus, tt.wantErr)
+			}
+			wantFailures := 0
+			if tt.wantStatus == domain.JobRunFailed {
+				wantFailures = 1
+			}
+			if failures != wantFailures {
+				t.Errorf("run() failures = %d, want %d", failures, wantFailures)
+			}
+		})
+	}
+}
+
+func Test_JobRunner_delay(t *testing.T) {
+	r := NewJobRunner(nil, &internal.Config{JobBackoff: 10 * time.Second}, Job{Name: "test", Interval: time.Minute})
+	for failures, want := range map[int]time.Duration{0: time.Minute, 1: 10 * time.Second, 2: 20 * time.Second, 3: 40 * time.Second, 4: time.Minute, 60: time.Minute} {
+		if got := r.delay(r.jobs[0], failures); got != want {
+			t.Errorf("delay(%d) = %v, want %v", failures, got, want)
+		}
+	}
+
+	r.cfg.JobJitter = 0.5
+	for range 100 {
+		if got := r.delay(r.jobs[0], 0); got < time.Minute || got >= 90*time.Second {
+			t.Fatalf("delay(0) with jitter = %v, want within [1m, 1m30s)", got)
+		}
+	}
+}
+
+func Test_JobRunner_Trigger(t *testing.T) {
+	r := NewJobRunner(nil, &internal.Config{}, Job{Name: "billing"})
+	if err := r.Trigger(context.Background(), "billing"); err != nil {
+		t.Fatalf("Trigger() error = %v", err)
+	}
+	if err := r.Trigger(context.Background(), "billing"); !errors.Is(err, ErrJobBusy) {
+		t.Errorf("Trigger() twice error = %v, want ErrJobBusy", err)
+	}
+	if err := r.Trigger(context.Background(), "unknown"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Trigger() unknown job error = %v, want ErrJobNotFound", err)
+	}
+}
@@ -6,9 +6,9 @@ import (
 	context "context"
 
 	domain "github.com/rhythin/sever-management/internal/domain"
-	mock "github.com/stretchr/testify/mock"
-
 	persistence "github.com/rhythin/sever-management/internal/persistence"
+	service "github.com/rhythin/sever-management/internal/service"
+	mock "github.com/stretchr/testify/mock"
 )
 
 // ServerService is an autogenerated mock type for the ServerService type
@@ -16,17 +16,47 @@ type ServerService struct {
 	mock.Mock
 }
 
-// Action provides a mock function with given fields: ctx, id, action
-func (_m *ServerService) Action(ctx context.Context, id string, action domain.ServerAction) error {
-	ret := _m.Called(ctx, id, action)
+// Action provides a mock function with given fields: ctx, id, action, ifMatch
+func (_m *ServerService) Action(ctx context.Context, id string, action domain.ServerAction, ifMatch int64) (*persistence.Server, error) {
+	ret := _m.Called(ctx, id, action, ifMatch)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Action")
 	}
 
+	var r0 *persistence.Server
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.ServerAction, int64) (*persistence.Server, error)); ok {
+		return rf(ctx, id, action, ifMatch)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.ServerAction, int64) *persistence.Server); ok {
+		r0 = rf(ctx, id, action, ifMatch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*persistence.Server)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, domain.ServerAction, int64) error); ok {
+		r1 = rf(ctx, id, action, ifMatch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CompleteOperation provides a mock function with given fields: ctx, id
+func (_m *ServerService) CompleteOperation(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CompleteOperation")
+	}
+
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, domain.ServerAction) error); ok {
-		r0 = rf(ctx, id, action)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -64,6 +94,36 @@ func (_m *ServerService) GetEvents(ctx context.Context, id string, n int) ([]per
 	return r0, r1
 }
 
+// GetOperation provides a mock function with given fields: ctx, id
+func (_m *ServerService) GetOperation(ctx context.Context, id string) (*persistence.Operation, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOperation")
+	}
+
+	var r0 *persistence.Operation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*persistence.Operation, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *persistence.Operation); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*persistence.Operation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetServerByID provides a mock function with given fields: ctx, id
 func (_m *ServerService) GetServerByID(ctx context.Context, id string) (*persistence.Server, error) {
 	ret := _m.Called(ctx, id)
@@ -124,27 +184,146 @@ func (_m *ServerService) ListServers(ctx context.Context, region string, status
 	return r0, r1
 }
 
-// Provision provides a mock function with given fields: ctx, region, typ
-func (_m *ServerService) Provision(ctx context.Context, region string, typ string) (string, error) {
-	ret := _m.Called(ctx, region, typ)
+// ListUsage provides a mock function with given fields: ctx, id
+func (_m *ServerService) ListUsage(ctx context.Context, id string) ([]*persistence.UsageSession, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUsage")
+	}
+
+	var r0 []*persistence.UsageSession
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]*persistence.UsageSession, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*persistence.UsageSession); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*persistence.UsageSession)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Provision provides a mock function with given fields: ctx, region, typ, labels
+func (_m *ServerService) Provision(ctx context.Context, region string, typ string, labels map[string]string) (*persistence.Operation, error) {
+	ret := _m.Called(ctx, region, typ, labels)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Provision")
 	}
 
-	var r0 string
+	var r0 *persistence.Operation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, map[string]string) (*persistence.Operation, error)); ok {
+		return rf(ctx, region, typ, labels)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, map[string]string) *persistence.Operation); ok {
+		r0 = rf(ctx, region, typ, labels)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*persistence.Operation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, map[string]string) error); ok {
+		r1 = rf(ctx, region, typ, labels)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QueryEvents provides a mock function with given fields: ctx, q
+func (_m *ServerService) QueryEvents(ctx context.Context, q persistence.EventQuery) ([]persistence.EventLog, uint, error) {
+	ret := _m.Called(ctx, q)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QueryEvents")
+	}
+
+	var r0 []persistence.EventLog
+	var r1 uint
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, persistence.EventQuery) ([]persistence.EventLog, uint, error)); ok {
+		return rf(ctx, q)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, persistence.EventQuery) []persistence.EventLog); ok {
+		r0 = rf(ctx, q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]persistence.EventLog)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, persistence.EventQuery) uint); ok {
+		r1 = rf(ctx, q)
+	} else {
+		r1 = ret.Get(1).(uint)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, persistence.EventQuery) error); ok {
+		r2 = rf(ctx, q)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// SubscribeEvents provides a mock function with given fields: q
+func (_m *ServerService) SubscribeEvents(q persistence.EventQuery) *service.EventSubscription {
+	ret := _m.Called(q)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SubscribeEvents")
+	}
+
+	var r0 *service.EventSubscription
+	if rf, ok := ret.Get(0).(func(persistence.EventQuery) *service.EventSubscription); ok {
+		r0 = rf(q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*service.EventSubscription)
+		}
+	}
+
+	return r0
+}
+
+// Terminate provides a mock function with given fields: ctx, id, cause, ifMatch
+func (_m *ServerService) Terminate(ctx context.Context, id string, cause domain.TerminationCause, ifMatch int64) (*persistence.Server, error) {
+	ret := _m.Called(ctx, id, cause, ifMatch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Terminate")
+	}
+
+	var r0 *persistence.Server
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
-		return rf(ctx, region, typ)
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.TerminationCause, int64) (*persistence.Server, error)); ok {
+		return rf(ctx, id, cause, ifMatch)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
-		r0 = rf(ctx, region, typ)
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.TerminationCause, int64) *persistence.Server); ok {
+		r0 = rf(ctx, id, cause, ifMatch)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*persistence.Server)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, region, typ)
+	if rf, ok := ret.Get(1).(func(context.Context, string, domain.TerminationCause, int64) error); ok {
+		r1 = rf(ctx, id, cause, ifMatch)
 	} else {
 		r1 = ret.Error(1)
 	}
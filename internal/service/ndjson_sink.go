@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// NDJSONSink appends each event as one JSON line to a file, for log shippers and offline
+// analysis. Lines are synced before Send returns; consumers deduplicate by event_id.
+
+type NDJSONSink struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func NewNDJSONSink(path string) (*NDJSONSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &NDJSONSink{f: f}, nil
+}
+
+func (s *NDJSONSink) Name() string {
+	return "ndjson"
+}
+
+func (s *NDJSONSink) Send(ctx context.Context, e PublishedEvent) error {
+	line, err := json.Marshal(newEventEnvelope(e))
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return s.f.Sync()
+}
+
+func (s *NDJSONSink) Close() error {
+	return s.f.Close()
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"go.uber.org/zap"
+)
+
+// OperationQueue hands newly created operations from the service to the worker
+
+type OperationQueue struct {
+	ch chan *persistence.Operation
+}
+
+func NewOperationQueue(cfg *internal.Config) *OperationQueue {
+	size := cfg.OperationQueueSize
+	if size <= 0 {
+		size = 1024
+	}
+	return &OperationQueue{ch: make(chan *persistence.Operation, size)}
+}
+
+// Enqueue never blocks; operations that don't fit are picked up by the worker's pending sweep
+func (q *OperationQueue) Enqueue(ctx context.Context, op *persistence.Operation) bool {
+	select {
+	case q.ch <- op:
+		return true
+	default:
+		logging.S(ctx).Warnw("Operation queue full; deferring to pending sweep", "operationID", op.ID)
+		return false
+	}
+}
+
+// OperationWorker drives asynchronous operations (provisioning, reboots) to completion
+
+type OperationWorker struct {
+	queue    *OperationQueue
+	svc      ServerService
+	ops      persistence.OperationRepo
+	cfg      *internal.Config
+	inflight map[string]struct{}
+	done     chan string
+}
+
+func NewOperationWorker(queue *OperationQueue, svc ServerService, ops persistence.OperationRepo, cfg *internal.Config) *OperationWorker {
+	return &OperationWorker{
+		queue:    queue,
+		svc:      svc,
+		ops:      ops,
+		cfg:      cfg,
+		inflight: make(map[string]struct{}),
+		done:     make(chan string, 64),
+	}
+}
+
+func (w *OperationWorker) Run(ctx context.Context) {
+	zap.S().Infow("OperationWorker started")
+	// Pending operations are swept periodically so that work survives restarts and queue overflow
+	sweep := time.NewTicker(w.sweepInterval())
+	defer sweep.Stop()
+	w.resumePending(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			zap.S().Infow("OperationWorker stopped")
+			return
+		case op := <-w.queue.ch:
+			w.schedule(ctx, op)
+		case id := <-w.done:
+			delete(w.inflight, id)
+		case <-sweep.C:
+			w.resumePending(ctx)
+		}
+	}
+}
+
+func (w *OperationWorker) sweepInterval() time.Duration {
+	interval := 10 * w.cfg.ProvisionDelay
+	if interval < time.Second {
+		interval = time.Second
+	}
+	return interval
+}
+
+func (w *OperationWorker) resumePending(ctx context.Context) {
+	ops, err := w.ops.ListPending(ctx, w.batchSize())
+	if err != nil {
+		zap.S().Errorw("OperationWorker failed to list pending operations", "error", err)
+		return
+	}
+	for _, op := range ops {
+		w.schedule(ctx, op)
+	}
+}
+
+func (w *OperationWorker) batchSize() int {
+	if w.cfg.OperationQueueSize > 0 {
+		return w.cfg.OperationQueueSize
+	}
+	return 1024
+}
+
+// schedule completes op once it is due; each operation is scheduled at most once
+func (w *OperationWorker) schedule(ctx context.Context, op *persistence.Operation) {
+	if _, ok := w.inflight[op.ID]; ok {
+		return
+	}
+	w.inflight[op.ID] = struct{}{}
+	due := op.DueAt
+	if due.IsZero() {
+		due = op.CreatedAt.Add(w.cfg.ProvisionDelay)
+	}
+	wait := time.Until(due)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				zap.S().Errorw("OperationWorker panicked while completing operation", "operationID", op.ID, "recover", r)
+			}
+			select {
+			case w.done <- op.ID:
+			case <-ctx.Done():
+			}
+		}()
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+		}
+		cctx, cancel := context.WithTimeout(ctx, w.cfg.RequestTimeout)
+		defer cancel()
+		if err := w.svc.CompleteOperation(cctx, op.ID); err != nil {
+			zap.S().Errorw("OperationWorker failed to complete operation", "operationID", op.ID, "error", err)
+		}
+	}()
+}
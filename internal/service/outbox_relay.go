@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"go.uber.org/zap"
+)
+
+// outboxLease names the lease that elects the one replica that relays the outbox
+const outboxLease = "outbox"
+
+// streamTailWindow bounds how long after it was added an outbox entry can commit and still
+// reach the stream bus live
+const streamTailWindow = time.Minute
+
+// OutboxRelay reads committed events from the outbox and hands them to every sink, marking
+// each entry dispatched once all sinks accept it. An entry that a sink rejects stays pending
+// and is retried, together with everything after it, on the next sweep, so sinks see events
+// at least once. Entries are dispatched in ID order, which is not strictly commit order: an
+// entry that commits late with a lower ID is dispatched after higher ones already sent.
+//
+// Only the replica holding the outbox lease relays to the sinks, so each entry reaches them
+// once per sweep rather than once per replica. The stream bus is not a sink: every replica
+// tails the outbox and publishes to its own bus, so streams on any replica see live events.
+
+type OutboxRelay struct {
+	repo      persistence.OutboxRepo
+	leases    persistence.LeaseRepo
+	bus       *EventBus
+	sinks     []EventSink
+	cfg       *internal.Config
+	wake      chan struct{}
+	started   time.Time
+	published map[uint]time.Time // entries tailed to the bus, by creation time
+}
+
+func NewOutboxRelay(repo persistence.OutboxRepo, leases persistence.LeaseRepo, bus *EventBus, cfg *internal.Config, sinks ...EventSink) *OutboxRelay {
+	return &OutboxRelay{repo: repo, leases: leases, bus: bus, sinks: sinks, cfg: cfg, wake: make(chan struct{}, 1), started: time.Now(), published: make(map[uint]time.Time)}
+}
+
+// Notify wakes the relay after a commit that added outbox entries. It never blocks, and a
+// nil relay ignores it.
+func (r *OutboxRelay) Notify() {
+	if r == nil {
+		return
+	}
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *OutboxRelay) Run(ctx context.Context) {
+	zap.S().Infow("OutboxRelay started", "sinks", len(r.sinks))
+	poll := time.NewTicker(r.pollInterval())
+	defer poll.Stop()
+	purge := time.NewTicker(time.Hour)
+	defer purge.Stop()
+	r.sweep(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			r.leases.Release(context.Background(), outboxLease)
+			zap.S().Infow("OutboxRelay stopped")
+			return
+		case <-r.wake:
+			r.sweep(ctx)
+		case <-poll.C:
+			r.sweep(ctx)
+		case <-purge.C:
+			r.purge(ctx)
+		}
+	}
+}
+
+// sweep publishes new entries to this replica's bus, then relays pending ones to the sinks
+func (r *OutboxRelay) sweep(ctx context.Context) {
+	r.tail(ctx, time.Now())
+	r.drain(ctx)
+}
+
+// tail publishes to this replica's stream bus the entries added within streamTailWindow of now
+// that it has not published yet, whether or not they are dispatched. Entries are found by
+// creation time rather than by ID, since IDs are taken before commit; one that commits more
+// than the window after it was added is not published live, but is replayed on resume.
+func (r *OutboxRelay) tail(ctx context.Context, now time.Time) {
+	if r.bus == nil {
+		return
+	}
+	since := now.Add(-streamTailWindow)
+	if since.Before(r.started) {
+		since = r.started // entries from before this replica started are history, not live
+	}
+	for afterID := uint(0); ; {
+		entries, err := r.repo.ListSince(ctx, since, afterID, r.batchSize())
+		if err != nil {
+			zap.S().Errorw("OutboxRelay failed to list recent entries", "error", err)
+			return
+		}
+		for _, entry := range entries {
+			if _, ok := r.published[entry.ID]; ok {
+				continue
+			}
+			r.published[entry.ID] = entry.CreatedAt
+			if entry.Event != nil {
+				r.bus.Publish(entry.Region, entry.Event)
+			}
+		}
+		if len(entries) < r.batchSize() {
+			break
+		}
+		afterID = entries[len(entries)-1].ID
+	}
+	for id, created := range r.published {
+		if created.Before(since) {
+			delete(r.published, id)
+		}
+	}
+}
+
+// drain dispatches pending entries in order until none are left or one fails. It does nothing
+// unless this replica holds, or can take, the outbox lease.
+func (r *OutboxRelay) drain(ctx context.Context) {
+	held, err := r.leases.TryAcquire(ctx, outboxLease)
+	if err != nil {
+		zap.S().Errorw("OutboxRelay failed to acquire outbox lease", "error", err)
+		return
+	}
+	if !held {
+		zap.S().Debugw("OutboxRelay standing by; another replica holds the outbox lease")
+		return
+	}
+	for {
+		entries, err := r.repo.ListPending(ctx, r.batchSize())
+		if err != nil {
+			zap.S().Errorw("OutboxRelay failed to list pending entries", "error", err)
+			return
+		}
+		for _, entry := range entries {
+			if err := r.dispatch(ctx, entry); err != nil {
+				zap.S().Warnw("OutboxRelay failed to dispatch event; will retry", "entryID", entry.ID, "eventID", entry.EventID, "attempts", entry.Attempts+1, "error", err)
+				if err := r.repo.RecordFailure(ctx, entry.ID, err.Error()); err != nil {
+					zap.S().Errorw("OutboxRelay failed to record dispatch failure", "entryID", entry.ID, "error", err)
+				}
+				return
+			}
+			if err := r.repo.MarkDispatched(ctx, entry.ID); err != nil {
+				// The entry is dispatched again on the next sweep, which sinks tolerate
+				zap.S().Errorw("OutboxRelay failed to mark entry dispatched", "entryID", entry.ID, "error", err)
+				return
+			}
+		}
+		if len(entries) < r.batchSize() {
+			return
+		}
+	}
+}
+
+func (r *OutboxRelay) dispatch(ctx context.Context, entry *persistence.OutboxEntry) error {
+	if entry.Event == nil {
+		zap.S().Warnw("OutboxRelay skipping entry for a missing event", "entryID", entry.ID, "eventID", entry.EventID)
+		return nil
+	}
+	e := PublishedEvent{EventLog: *entry.Event, Region: entry.Region}
+	for _, sink := range r.sinks {
+		if err := sink.Send(ctx, e); err != nil {
+			return fmt.Errorf("sink %s: %w", sink.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (r *OutboxRelay) purge(ctx context.Context) {
+	retention := r.cfg.OutboxRetention
+	if retention <= 0 {
+		retention = 24 * time.Hour
+	}
+	n, err := r.repo.PurgeDispatched(ctx, time.Now().Add(-retention))
+	if err != nil {
+		zap.S().Errorw("OutboxRelay failed to purge dispatched entries", "error", err)
+		return
+	}
+	zap.S().Debugw("OutboxRelay purged dispatched entries", "count", n)
+}
+
+func (r *OutboxRelay) pollInterval() time.Duration {
+	if r.cfg.OutboxPollInterval > 0 {
+		return r.cfg.OutboxPollInterval
+	}
+	return time.Second
+}
+
+func (r *OutboxRelay) batchSize() int {
+	if r.cfg.OutboxBatchSize > 0 {
+		return r.cfg.OutboxBatchSize
+	}
+	return 100
+}
+
+// newEventEnvelope renders an event in the JSON shape shared by the webhook and NDJSON sinks
+func newEventEnvelope(e PublishedEvent) packets.EventEnvelope {
+	return packets.EventEnvelope{
+		EventID: e.ID,
+		Region:  e.Region,
+		EventLogResponse: packets.EventLogResponse{
+			Sequence:  e.Sequence,
+			ServerID:  e.ServerID,
+			Timestamp: e.Timestamp.Format(time.RFC3339),
+			Type:      e.Type,
+			Message:   e.Message,
+			FromState: e.FromState,
+			ToState:   e.ToState,
+			Action:    e.Action,
+			Cause:     e.Cause,
+			RequestID: e.RequestID,
+		},
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	mockPersistence "github.com/rhythin/sever-management/internal/persistence/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+// recordingSink records the event IDs it receives and fails the first send of failOnce
+type recordingSink struct {
+	name     string
+	failOnce uint
+	got      []uint
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Send(ctx context.Context, e PublishedEvent) error {
+	if e.ID == s.failOnce {
+		s.failOnce = 0
+		return errors.New("sink unavailable")
+	}
+	s.got = append(s.got, e.ID)
+	return nil
+}
+
+func outboxEntry(id uint) *persistence.OutboxEntry {
+	return &persistence.OutboxEntry{ID: id, EventID: id * 10, Event: &persistence.EventLog{ID: id * 10}, Region: "us-east-1"}
+}
+
+// heldLease is a lease repo under which this replica always holds the lease
+func heldLease() *mockPersistence.LeaseRepo {
+	leases := &mockPersistence.LeaseRepo{}
+	leases.On("TryAcquire", mock.Anything, outboxLease).Return(true, nil)
+	return leases
+}
+
+func Test_OutboxRelay_drain(t *testing.T) {
+	repo := &mockPersistence.OutboxRepo{}
+	repo.On("ListPending", mock.Anything, 100).Return([]*persistence.OutboxEntry{outboxEntry(1), outboxEntry(2), outboxEntry(3)}, nil).Once()
+	repo.On("ListPending", mock.Anything, 100).Return([]*persistence.OutboxEntry{outboxEntry(2), outboxEntry(3)}, nil).Once()
+	repo.On("MarkDispatched", mock.Anything, mock.Anything).Return(nil)
+	repo.On("RecordFailure", mock.Anything, uint(2), "sink flaky: sink unavailable").Return(nil).Once()
+
+	stable := &recordingSink{name: "stable"}
+	flaky := &recordingSink{name: "flaky", failOnce: 20}
+	r := NewOutboxRelay(repo, heldLease(), nil, &internal.Config{}, stable, flaky)
+
+	// The first sweep stops at the failed entry so that later events stay in order
+	r.drain(context.Background())
+	repo.AssertCalled(t, "MarkDispatched", mock.Anything, uint(1))
+	repo.AssertNotCalled(t, "MarkDispatched", mock.Anything, uint(2))
+	repo.AssertNotCalled(t, "MarkDispatched", mock.Anything, uint(3))
+
+	// The retry redelivers to every sink: at-least-once
+	r.drain(context.Background())
+	repo.AssertExpectations(t)
+	if got := stable.got; len(got) != 4 || got[0] != 10 || got[1] != 20 || got[2] != 20 || got[3] != 30 {
+		t.Errorf("stable sink received %v, want [10 20 20 30]", got)
+	}
+	if got := flaky.got; len(got) != 3 || got[0] != 10 || got[1] != 20 || got[2] != 30 {
+		t.Errorf("flaky sink received %v, want [10 20 30]", got)
+	}
+}
+
+func Test_OutboxRelay_MissingEvent(t *testing.T) {
+	repo := &mockPersistence.OutboxRepo{}
+	repo.On("ListPending", mock.Anything, 100).Return([]*persistence.OutboxEntry{{ID: 1, EventID: 10}}, nil)
+	repo.On("MarkDispatched", mock.Anything, uint(1)).Return(nil)
+	sink := &recordingSink{name: "sink"}
+
+	NewOutboxRelay(repo, heldLease(), nil, &internal.Config{}, sink).drain(context.Background())
+	repo.AssertExpectations(t)
+	if len(sink.got) != 0 {
+		t.Errorf("sink received %v for a missing event", sink.got)
+	}
+}
+
+func Test_OutboxRelay_StandBy(t *testing.T) {
+	repo := &mockPersistence.OutboxRepo{}
+	leases := &mockPersistence.LeaseRepo{}
+	leases.On("TryAcquire", mock.Anything, outboxLease).Return(false, nil)
+	sink := &recordingSink{name: "sink"}
+
+	// Another replica holds the lease, so this one must not dispatch the same entries
+	NewOutboxRelay(repo, leases, nil, &internal.Config{}, sink).drain(context.Background())
+	repo.AssertNotCalled(t, "ListPending", mock.Anything, mock.Anything)
+	if len(sink.got) != 0 {
+		t.Errorf("standby relay dispatched %v", sink.got)
+	}
+}
+
+func Test_OutboxRelay_sweep_EveryReplicaStreams(t *testing.T) {
+	entry := outboxEntry(1)
+	repo := &mockPersistence.OutboxRepo{}
+	repo.On("ListSince", mock.Anything, mock.Anything, uint(0), 100).Return([]*persistence.OutboxEntry{entry}, nil)
+	repo.On("ListPending", mock.Anything, 100).Return([]*persistence.OutboxEntry{entry}, nil).Once()
+	repo.On("ListPending", mock.Anything, 100).Return(nil, nil)
+	repo.On("MarkDispatched", mock.Anything, uint(1)).Return(nil).Once()
+	standby := &mockPersistence.LeaseRepo{}
+	standby.On("TryAcquire", mock.Anything, outboxLease).Return(false, nil)
+
+	// Two replicas share the outbox; only the first holds the lease
+	cfg := &internal.Config{}
+	webhooks := &recordingSink{name: "webhooks"}
+	buses := []*EventBus{NewEventBus(cfg), NewEventBus(cfg)}
+	relays := []*OutboxRelay{
+		NewOutboxRelay(repo, heldLease(), buses[0], cfg, webhooks),
+		NewOutboxRelay(repo, standby, buses[1], cfg, webhooks),
+	}
+	entry.CreatedAt = time.Now() // added after both replicas started
+	var subs []*EventSubscription
+	for _, bus := range buses {
+		subs = append(subs, bus.Subscribe(persistence.EventQuery{}))
+	}
+	for range 2 {
+		for _, r := range relays {
+			r.sweep(context.Background())
+		}
+	}
+
+	repo.AssertExpectations(t)
+	if len(webhooks.got) != 1 || webhooks.got[0] != 10 {
+		t.Errorf("webhook sink received %v, want [10] from the lease holder alone", webhooks.got)
+	}
+	for i, sub := range subs {
+		if got := len(sub.C); got != 1 {
+			t.Fatalf("replica %d bus received %d events, want 1", i, got)
+		}
+		if e := <-sub.C; e.ID != 10 || e.Region != "us-east-1" {
+			t.Errorf("replica %d bus received %+v, want event 10 in us-east-1", i, e)
+		}
+	}
+}
+
+func Test_OutboxRelay_NilNotify(t *testing.T) {
+	var r *OutboxRelay
+	r.Notify()
+}
+
+func TestNDJSONSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	sink, err := NewNDJSONSink(path)
+	if err != nil {
+		t.Fatalf("NewNDJSONSink() error = %v", err)
+	}
+	for _, id := range []uint{1, 2} {
+		if err := sink.Send(context.Background(), PublishedEvent{EventLog: persistence.EventLog{ID: id, Type: "started"}, Region: "eu-west-1"}); err != nil {
+			t.Fatalf("NDJSONSink.Send() error = %v", err)
+		}
+	}
+	sink.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []packets.EventEnvelope
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e packets.EventEnvelope
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("NDJSONSink wrote invalid JSON %q: %v", sc.Text(), err)
+		}
+		lines = append(lines, e)
+	}
+	if len(lines) != 2 || lines[0].EventID != 1 || lines[1].EventID != 2 || lines[1].Region != "eu-west-1" || lines[1].Type != "started" {
+		t.Errorf("NDJSONSink wrote %+v", lines)
+	}
+}
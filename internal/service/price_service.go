@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/persistence"
+)
+
+// ErrInvalidPrice is returned when a price is negative or does not take effect in the future
+var ErrInvalidPrice = errors.New("price must be non-negative and take effect in the future")
+
+// PriceService manages the price book: hourly prices per server type, in one region or in all
+// regions, each in effect from its effective time until the next. A regional price takes
+// precedence over the all-regions price; types without either are billed at their catalog
+// price, or BILLING_RATE if they are not in the catalog.
+
+type priceService struct {
+	repo    persistence.PriceRepo
+	catalog CatalogService
+	cfg     *internal.Config
+}
+
+func NewPriceService(repo persistence.PriceRepo, catalog CatalogService, cfg *internal.Config) PriceService {
+	return &priceService{repo: repo, catalog: catalog, cfg: cfg}
+}
+
+// AddPrice schedules a price for a server type, in one region or in all regions when region
+// is empty. Prices only take effect in the future, so that billed uptime is never repriced.
+func (p *priceService) AddPrice(ctx context.Context, typ, region string, hourly domain.Micros, effectiveFrom time.Time) (*persistence.Price, error) {
+	log := logging.S(ctx)
+	log.Infow("PriceService.AddPrice called", "type", typ, "region", region, "hourlyPrice", hourly, "effectiveFrom", effectiveFrom)
+	if hourly < 0 || !effectiveFrom.After(time.Now()) {
+		return nil, ErrInvalidPrice
+	}
+	if _, err := p.catalog.GetType(ctx, typ); err != nil {
+		return nil, err
+	}
+	if region != "" {
+		if _, err := p.catalog.GetRegion(ctx, region); err != nil {
+			return nil, err
+		}
+	}
+	price := &persistence.Price{
+		ServerType:    typ,
+		Region:        region,
+		HourlyPrice:   hourly,
+		Currency:      p.cfg.BillingCurrency,
+		EffectiveFrom: effectiveFrom.UTC(),
+	}
+	if err := p.repo.Create(ctx, price); err != nil {
+		return nil, err
+	}
+	return price, nil
+}
+
+// ListPrices returns price history, optionally for one type and region
+func (p *priceService) ListPrices(ctx context.Context, typ, region string) ([]*persistence.Price, error) {
+	return p.repo.List(ctx, typ, region)
+}
+
+// Charges splits the uptime of a server of a type in a region between from and to into
+// contiguous spans billed at the rate in effect during each, so that a span crossing a price
+// change is billed at both prices. A price applies from the first whole second after from
+// at which it is in effect, so spans last whole seconds when to is a whole number of
+// seconds after from.
+func (p *priceService) Charges(ctx context.Context, typ, region string, from, to time.Time) ([]domain.Charge, error) {
+	prices, err := p.repo.ListEffective(ctx, typ, region, to)
+	if err != nil {
+		return nil, err
+	}
+	// The rate can only change where a price takes effect
+	bounds := []time.Time{from}
+	for _, price := range prices {
+		if price.EffectiveFrom.After(from) {
+			b := ceilSecond(from, price.EffectiveFrom)
+			if b.After(to) {
+				b = to
+			}
+			bounds = append(bounds, b)
+		}
+	}
+	bounds = append(bounds, to)
+	var charges []domain.Charge
+	for i := 0; i+1 < len(bounds); i++ {
+		if d := bounds[i+1].Sub(bounds[i]); d > 0 {
+			charges = append(charges, domain.Charge{Rate: p.rateAt(prices, typ, region, bounds[i]), Duration: d})
+		}
+	}
+	return charges, nil
+}
+
+// rateAt returns the hourly rate in effect at t, given a type's prices ordered by effective time
+func (p *priceService) rateAt(prices []*persistence.Price, typ, region string, t time.Time) domain.Micros {
+	var regional, global *persistence.Price
+	for _, price := range prices {
+		if price.EffectiveFrom.After(t) {
+			break
+		}
+		if price.Region == "" {
+			global = price
+		} else if price.Region == region {
+			regional = price
+		}
+	}
+	switch {
+	case regional != nil:
+		return regional.HourlyPrice
+	case global != nil:
+		return global.HourlyPrice
+	}
+	return hourlyRate(p.cfg, typ)
+}
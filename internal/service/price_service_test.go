@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/persistence"
+	mockPersistence "github.com/rhythin/sever-management/internal/persistence/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_priceService_AddPrice(t *testing.T) {
+	future := time.Now().Add(24 * time.Hour)
+	regions := &mockPersistence.RegionRepo{}
+	regions.On("GetByName", mock.Anything, "eu-west-1").Return(&persistence.Region{Name: "eu-west-1"}, nil)
+	regions.On("GetByName", mock.Anything, "mars-1").Return(nil, nil)
+	cfg := &internal.Config{BillingCurrency: "EUR", ServerTypes: internal.ServerTypeCatalog{{Name: "t2.micro"}}}
+
+	tests := []struct {
+		name          string
+		typ, region   string
+		hourly        domain.Micros
+		effectiveFrom time.Time
+		wantErr       error
+	}{
+		{name: "all regions", typ: "t2.micro", hourly: 12500, effectiveFrom: future},
+		{name: "one region", typ: "t2.micro", region: "eu-west-1", hourly: 13000, effectiveFrom: future},
+		{name: "in the past", typ: "t2.micro", hourly: 12500, effectiveFrom: time.Now().Add(-time.Minute), wantErr: ErrInvalidPrice},
+		{name: "negative", typ: "t2.micro", hourly: -1, effectiveFrom: future, wantErr: ErrInvalidPrice},
+		{name: "unknown type", typ: "t2.nano", hourly: 12500, effectiveFrom: future, wantErr: ErrUnknownServerType},
+		{name: "unknown region", typ: "t2.micro", region: "mars-1", hourly: 12500, effectiveFrom: future, wantErr: ErrUnknownRegion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockPersistence.PriceRepo{}
+			repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+			s := NewPriceService(repo, NewCatalogService(cfg, regions), cfg)
+
+			price, err := s.AddPrice(context.Background(), tt.typ, tt.region, tt.hourly, tt.effectiveFrom)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("priceService.AddPrice() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			if price.ServerType != tt.typ || price.Region != tt.region || price.HourlyPrice != tt.hourly || price.Currency != "EUR" || !price.EffectiveFrom.Equal(tt.effectiveFrom) {
+				t.Errorf("priceService.AddPrice() = %+v", price)
+			}
+		})
+	}
+}
+
+func Test_priceService_Charges(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(3 * time.Hour)
+	prices := []*persistence.Price{
+		{ServerType: "t2.micro", HourlyPrice: 100, EffectiveFrom: time.Unix(0, 0)},
+		{ServerType: "t2.micro", HourlyPrice: 200, EffectiveFrom: from.Add(time.Hour)},
+		{ServerType: "t2.micro", Region: "eu-west-1", HourlyPrice: 300, EffectiveFrom: from.Add(2 * time.Hour)},
+	}
+	repo := &mockPersistence.PriceRepo{}
+	repo.On("ListEffective", mock.Anything, "t2.micro", "eu-west-1", to).Return(prices, nil)
+	repo.On("ListEffective", mock.Anything, "t2.micro", "us-east-1", to).Return(prices[:2], nil)
+	repo.On("ListEffective", mock.Anything, "t2.small", "us-east-1", to).Return(nil, nil)
+	s := NewPriceService(repo, nil, &internal.Config{ServerTypes: internal.ServerTypeCatalog{{Name: "t2.small", HourlyPrice: 23000}}})
+
+	tests := []struct {
+		name        string
+		typ, region string
+		want        []domain.Charge
+	}{
+		{
+			name: "regional price overrides the all-regions price", typ: "t2.micro", region: "eu-west-1",
+			want: []domain.Charge{{Rate: 100, Duration: time.Hour}, {Rate: 200, Duration: time.Hour}, {Rate: 300, Duration: time.Hour}},
+		},
+		{
+			name: "all-regions price change", typ: "t2.micro", region: "us-east-1",
+			want: []domain.Charge{{Rate: 100, Duration: time.Hour}, {Rate: 200, Duration: 2 * time.Hour}},
+		},
+		{
+			name: "no price book entry falls back to the catalog", typ: "t2.small", region: "us-east-1",
+			want: []domain.Charge{{Rate: 23000, Duration: 3 * time.Hour}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Charges(context.Background(), tt.typ, tt.region, from, to)
+			if err != nil {
+				t.Fatalf("priceService.Charges() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("priceService.Charges() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/persistence"
+)
+
+var (
+	// ErrReaperPolicyNotFound is returned when a reaper policy ID is unknown
+	ErrReaperPolicyNotFound = errors.New("reaper policy not found")
+	// ErrInvalidReaperPolicy is returned when a reaper policy's name, scope or timeout is malformed
+	ErrInvalidReaperPolicy = errors.New("invalid reaper policy")
+	// ErrNotReapable is returned when postponing the reaping of a server the reaper would not reap
+	ErrNotReapable = errors.New("server is not scheduled to be reaped")
+	// ErrInvalidPostponement is returned when a postponement does not move reap_at later, or
+	// moves it beyond REAPER_MAX_POSTPONE
+	ErrInvalidPostponement = errors.New("invalid reap postponement")
+)
+
+// DefaultReaperPolicy names the IDLE_TIMEOUT fallback for servers no policy matches
+const DefaultReaperPolicy = "default"
+
+// ReapCandidate is a stopped server and when the idle reaper reaps it, under which policy.
+// ReapAt is the end of the policy's idle timeout, or the server's postponement if later.
+
+type ReapCandidate struct {
+	Server      *persistence.Server
+	PolicyID    string // empty for the default policy
+	PolicyName  string
+	IdleTimeout time.Duration
+	ReapAt      time.Time
+	DryRun      bool // the policy or REAPER_DRY_RUN only reports the server
+}
+
+// Warned reports whether a reap warning has announced the candidate's current ReapAt
+func (c *ReapCandidate) Warned() bool {
+	return c.Server.ReapWarnedFor != nil && c.Server.ReapWarnedFor.Equal(c.ReapAt)
+}
+
+// ReaperService manages reaper policies and decides which policy applies to each stopped
+// server: the most specific matching policy, or IDLE_TIMEOUT when none matches. Servers
+// carrying REAPER_OPT_OUT_LABEL are never reaped. It also records reap warnings and owners'
+// postponements.
+
+type reaperService struct {
+	repo    persistence.ReaperPolicyRepo
+	servers persistence.ServerRepo
+	uow     persistence.UnitOfWork
+	relay   *OutboxRelay // nudged after reap events are queued in the outbox
+	catalog CatalogService
+	cfg     *internal.Config
+}
+
+func NewReaperService(repo persistence.ReaperPolicyRepo, servers persistence.ServerRepo, uow persistence.UnitOfWork, relay *OutboxRelay, catalog CatalogService, cfg *internal.Config) ReaperService {
+	return &reaperService{repo: repo, servers: servers, uow: uow, relay: relay, catalog: catalog, cfg: cfg}
+}
+
+func (s *reaperService) CreatePolicy(ctx context.Context, policy *persistence.ReaperPolicy) (*persistence.ReaperPolicy, error) {
+	log := logging.S(ctx)
+	log.Infow("ReaperService.CreatePolicy called", "name", policy.Name, "region", policy.Region, "type", policy.Type, "selector", policy.Selector, "idleTimeout", policy.IdleTimeout, "dryRun", policy.DryRun)
+	if err := s.validate(ctx, policy); err != nil {
+		log.Warnw("Rejected reaper policy", "name", policy.Name, "error", err)
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// UpdatePolicy replaces an existing policy's name, scope, timeout and mode
+func (s *reaperService) UpdatePolicy(ctx context.Context, policy *persistence.ReaperPolicy) (*persistence.ReaperPolicy, error) {
+	log := logging.S(ctx)
+	log.Infow("ReaperService.UpdatePolicy called", "id", policy.ID, "idleTimeout", policy.IdleTimeout, "dryRun", policy.DryRun)
+	existing, err := s.GetPolicy(ctx, policy.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validate(ctx, policy); err != nil {
+		log.Warnw("Rejected reaper policy", "id", policy.ID, "error", err)
+		return nil, err
+	}
+	policy.CreatedAt = existing.CreatedAt
+	policy.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (s *reaperService) validate(ctx context.Context, policy *persistence.ReaperPolicy) error {
+	if policy.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidReaperPolicy)
+	}
+	if policy.IdleTimeout <= 0 {
+		return fmt.Errorf("%w: idle timeout must be positive", ErrInvalidReaperPolicy)
+	}
+	if policy.Region != "" {
+		if _, err := s.catalog.GetRegion(ctx, policy.Region); err != nil {
+			return err
+		}
+	}
+	if policy.Type != "" {
+		if _, err := s.catalog.GetType(ctx, policy.Type); err != nil {
+			return err
+		}
+	}
+	if err := domain.ValidateLabels(policy.Selector); err != nil {
+		return fmt.Errorf("%w: selector: %w", ErrInvalidReaperPolicy, err)
+	}
+	return nil
+}
+
+func (s *reaperService) ListPolicies(ctx context.Context) ([]*persistence.ReaperPolicy, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *reaperService) GetPolicy(ctx context.Context, id string) (*persistence.ReaperPolicy, error) {
+	policy, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, ErrReaperPolicyNotFound
+	}
+	return policy, nil
+}
+
+func (s *reaperService) DeletePolicy(ctx context.Context, id string) error {
+	if _, err := s.GetPolicy(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// Candidates returns every stopped server that a policy will reap, soonest first. Servers that
+// have opted out, and servers that no policy matches when IDLE_TIMEOUT is not positive, are
+// left out.
+func (s *reaperService) Candidates(ctx context.Context) ([]*ReapCandidate, error) {
+	policies, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	servers, err := s.servers.ListMatching(ctx, "", string(domain.ServerStopped), nil)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*ReapCandidate
+	for _, server := range servers {
+		if c := s.candidate(server, policies); c != nil {
+			candidates = append(candidates, c)
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b *ReapCandidate) int { return a.ReapAt.Compare(b.ReapAt) })
+	return candidates, nil
+}
+
+// candidate returns when and under which policy a server is reaped, or nil if it is not
+func (s *reaperService) candidate(server *persistence.Server, policies []*persistence.ReaperPolicy) *ReapCandidate {
+	if server.State != string(domain.ServerStopped) || server.StoppedAt == nil || hasLabel(server, s.cfg.ReaperOptOutLabel) {
+		return nil
+	}
+	c := &ReapCandidate{Server: server, PolicyName: DefaultReaperPolicy, IdleTimeout: s.cfg.IdleTimeout, DryRun: s.cfg.ReaperDryRun}
+	if p := reaperPolicyFor(server, policies); p != nil {
+		c.PolicyID, c.PolicyName, c.IdleTimeout = p.ID, p.Name, p.IdleTimeout
+		c.DryRun = c.DryRun || p.DryRun
+	} else if s.cfg.IdleTimeout <= 0 {
+		return nil
+	}
+	c.ReapAt = server.StoppedAt.Add(c.IdleTimeout)
+	if server.ReapAfter != nil && server.ReapAfter.After(c.ReapAt) {
+		c.ReapAt = *server.ReapAfter
+	}
+	return c
+}
+
+// ReapAt returns when the idle reaper will reap a server, or nil if it will not: the server
+// is not stopped, has opted out, no policy covers it, or its policy is a dry run
+func (s *reaperService) ReapAt(ctx context.Context, server *persistence.Server) (*time.Time, error) {
+	if server.State != string(domain.ServerStopped) {
+		return nil, nil
+	}
+	policies, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c := s.candidate(server, policies)
+	if c == nil || c.DryRun {
+		return nil, nil
+	}
+	return &c.ReapAt, nil
+}
+
+// Warn records a reap_warning event for a candidate, which the outbox publishes to event
+// streams and to webhooks subscribed to it. A candidate warned less than REAPER_WARNING
+// before its ReapAt is postponed so that the notice is never shorter.
+func (s *reaperService) Warn(ctx context.Context, c *ReapCandidate, now time.Time) error {
+	log := logging.S(ctx)
+	server := c.Server
+	reapAt, reapAfter := c.ReapAt, (*time.Time)(nil)
+	if earliest := now.Add(s.cfg.ReaperWarning).Truncate(time.Second); reapAt.Before(earliest) {
+		reapAt, reapAfter = earliest, &earliest
+	}
+	log.Infow("ReaperService.Warn called", "id", server.ID, "policy", c.PolicyName, "reapAt", reapAt)
+	event := &persistence.EventLog{
+		ServerID:  server.ID,
+		Timestamp: now,
+		Type:      string(domain.EventReapWarning),
+		Message:   fmt.Sprintf("Server will be reaped at %s under reaper policy %s unless started or postponed", reapAt.Format(time.RFC3339), c.PolicyName),
+		FromState: server.State,
+		ToState:   server.State,
+	}
+	if err := s.schedule(ctx, server, reapAfter, &reapAt, event); err != nil {
+		return err
+	}
+	c.ReapAt = reapAt
+	return nil
+}
+
+// Postpone moves a stopped server's reap time later by the given duration, recording a
+// reap_postponed event. It returns the new reap time.
+func (s *reaperService) Postpone(ctx context.Context, id string, by time.Duration) (time.Time, error) {
+	log := logging.S(ctx)
+	log.Infow("ReaperService.Postpone called", "id", id, "by", by)
+	server, err := s.servers.GetByID(ctx, id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if server == nil {
+		return time.Time{}, ErrServerNotFound
+	}
+	policies, err := s.repo.List(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	c := s.candidate(server, policies)
+	if c == nil || c.DryRun {
+		return time.Time{}, ErrNotReapable
+	}
+	until := c.ReapAt.Add(by).Truncate(time.Second)
+	if !until.After(c.ReapAt) {
+		return time.Time{}, fmt.Errorf("%w: must move reap_at later", ErrInvalidPostponement)
+	}
+	if limit := s.cfg.ReaperMaxPostpone; limit > 0 && until.Sub(time.Now()) > limit {
+		return time.Time{}, fmt.Errorf("%w: reap_at may be at most %s from now", ErrInvalidPostponement, limit)
+	}
+	event := &persistence.EventLog{
+		ServerID:  server.ID,
+		Timestamp: time.Now(),
+		Type:      string(domain.EventReapPostponed),
+		Message:   fmt.Sprintf("Server reaping postponed from %s to %s", c.ReapAt.Format(time.RFC3339), until.Format(time.RFC3339)),
+		FromState: server.State,
+		ToState:   server.State,
+		RequestID: requestID(ctx),
+	}
+	if err := s.schedule(ctx, server, &until, nil, event); err != nil {
+		return time.Time{}, err
+	}
+	log.Infow("Postponed reaping of server", "id", id, "reapAt", until)
+	return until, nil
+}
+
+// schedule updates a server's reap schedule and logs the event explaining it in one unit of
+// work, guarded by the server's version
+func (s *reaperService) schedule(ctx context.Context, server *persistence.Server, reapAfter, reapWarnedFor *time.Time, event *persistence.EventLog) error {
+	err := s.uow.Do(ctx, func(tx persistence.Repos) error {
+		if err := tx.Servers.UpdateReapSchedule(ctx, server.ID, server.Version, reapAfter, reapWarnedFor); err != nil {
+			return err
+		}
+		if err := tx.Events.Append(ctx, event); err != nil {
+			return err
+		}
+		return tx.Outbox.Add(ctx, &persistence.OutboxEntry{EventID: event.ID, Region: server.Region})
+	})
+	if errors.Is(err, persistence.ErrVersionConflict) {
+		return ErrConcurrentUpdate
+	}
+	if err != nil {
+		return err
+	}
+	s.relay.Notify()
+	return nil
+}
+
+// reaperPolicyFor returns the most specific policy matching a server, the one scoped by the
+// most of region, type and selector labels, preferring the shorter timeout on ties. It returns
+// nil if no policy matches.
+func reaperPolicyFor(server *persistence.Server, policies []*persistence.ReaperPolicy) *persistence.ReaperPolicy {
+	var best *persistence.ReaperPolicy
+	bestScore := -1
+	for _, p := range policies {
+		if p.Region != "" && p.Region != server.Region || p.Type != "" && p.Type != server.Type {
+			continue
+		}
+		score := len(p.Selector)
+		for k, v := range p.Selector {
+			if !hasLabelValue(server, k, v) {
+				score = -1
+				break
+			}
+		}
+		if score < 0 {
+			continue
+		}
+		if p.Region != "" {
+			score++
+		}
+		if p.Type != "" {
+			score++
+		}
+		if score > bestScore || score == bestScore && p.IdleTimeout < best.IdleTimeout {
+			best, bestScore = p, score
+		}
+	}
+	return best
+}
+
+// hasLabel reports whether a server carries a label, whatever its value
+func hasLabel(server *persistence.Server, key string) bool {
+	return key != "" && slices.ContainsFunc(server.Labels, func(l *persistence.ServerLabel) bool { return l.Key == key })
+}
+
+func hasLabelValue(server *persistence.Server, key, value string) bool {
+	return slices.ContainsFunc(server.Labels, func(l *persistence.ServerLabel) bool { return l.Key == key && l.Value == value })
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/persistence"
+	mockPersistence "github.com/rhythin/sever-management/internal/persistence/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_reaperService_CreatePolicy(t *testing.T) {
+	repo := &mockPersistence.ReaperPolicyRepo{}
+	repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	catalog := NewCatalogService(&internal.Config{ServerTypes: internal.ServerTypeCatalog{{Name: "t2.micro"}}}, newUnknownRegionRepo())
+	s := NewReaperService(repo, nil, nil, nil, catalog, &internal.Config{})
+
+	tests := []struct {
+		name    string
+		policy  persistence.ReaperPolicy
+		wantErr error
+	}{
+		{name: "valid", policy: persistence.ReaperPolicy{Name: "micro", Type: "t2.micro", Selector: map[string]string{"env": "dev"}, IdleTimeout: time.Hour}},
+		{name: "no name", policy: persistence.ReaperPolicy{IdleTimeout: time.Hour}, wantErr: ErrInvalidReaperPolicy},
+		{name: "no timeout", policy: persistence.ReaperPolicy{Name: "never"}, wantErr: ErrInvalidReaperPolicy},
+		{name: "unknown type", policy: persistence.ReaperPolicy{Name: "big", Type: "x9.huge", IdleTimeout: time.Hour}, wantErr: ErrUnknownServerType},
+		{name: "unknown region", policy: persistence.ReaperPolicy{Name: "mars", Region: "mars-1", IdleTimeout: time.Hour}, wantErr: ErrUnknownRegion},
+		{name: "invalid selector", policy: persistence.ReaperPolicy{Name: "bad", Selector: map[string]string{"Env": "dev"}, IdleTimeout: time.Hour}, wantErr: domain.ErrInvalidLabel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := tt.policy
+			if _, err := s.CreatePolicy(context.Background(), &policy); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreatePolicy() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_reaperService_Candidates(t *testing.T) {
+	stoppedAt := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	server := func(id, region, typ string, labels ...string) *persistence.Server {
+		s := &persistence.Server{ID: id, Region: region, Type: typ, State: "stopped", StoppedAt: &stoppedAt}
+		for i := 0; i+1 < len(labels); i += 2 {
+			s.Labels = append(s.Labels, &persistence.ServerLabel{Key: labels[i], Value: labels[i+1]})
+		}
+		return s
+	}
+	servers := &mockPersistence.ServerRepo{}
+	servers.On("ListMatching", mock.Anything, "", string(domain.ServerStopped), map[string]string(nil)).Return([]*persistence.Server{
+		server("unmatched", "us-west-1", "t2.micro"),
+		server("regional", "eu-west-1", "t2.micro"),
+		server("specific", "eu-west-1", "t2.small", "env", "prod"),
+		server("tie", "eu-west-1", "t2.micro", "env", "dev"),
+		server("opted-out", "eu-west-1", "t2.micro", "reaper-opt-out", ""),
+	}, nil)
+	repo := &mockPersistence.ReaperPolicyRepo{}
+	repo.On("List", mock.Anything).Return([]*persistence.ReaperPolicy{
+		{ID: "eu", Name: "eu", Region: "eu-west-1", IdleTimeout: 2 * time.Hour},
+		{ID: "eu-prod", Name: "eu-prod", Region: "eu-west-1", Selector: map[string]string{"env": "prod"}, IdleTimeout: 72 * time.Hour},
+		{ID: "dev", Name: "dev", Selector: map[string]string{"env": "dev"}, IdleTimeout: 10 * time.Minute, DryRun: true},
+	}, nil)
+
+	tests := []struct {
+		name        string
+		idleTimeout time.Duration
+		want        map[string]string // server ID to policy
+	}{
+		{
+			name:        "default applies to unmatched servers",
+			idleTimeout: 30 * time.Minute,
+			want:        map[string]string{"unmatched": DefaultReaperPolicy, "regional": "eu", "specific": "eu-prod", "tie": "dev"},
+		},
+		{
+			name: "no default",
+			want: map[string]string{"regional": "eu", "specific": "eu-prod", "tie": "dev"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &internal.Config{IdleTimeout: tt.idleTimeout, ReaperOptOutLabel: "reaper-opt-out"}
+			got, err := NewReaperService(repo, servers, nil, nil, nil, cfg).Candidates(context.Background())
+			if err != nil {
+				t.Fatalf("Candidates() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Candidates() = %d servers, want %d", len(got), len(tt.want))
+			}
+			for i, c := range got {
+				if c.PolicyName != tt.want[c.Server.ID] || !c.ReapAt.Equal(stoppedAt.Add(c.IdleTimeout)) {
+					t.Errorf("Candidates()[%d] = %s under %s at %s", i, c.Server.ID, c.PolicyName, c.ReapAt)
+				}
+				if c.DryRun != (c.PolicyName == "dev") {
+					t.Errorf("Candidates()[%d] DryRun = %v", i, c.DryRun)
+				}
+				if i > 0 && c.ReapAt.Before(got[i-1].ReapAt) {
+					t.Errorf("Candidates() not ordered by reap time")
+				}
+			}
+		})
+	}
+}
+
+func Test_reaperService_Postpone(t *testing.T) {
+	stoppedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	reapAt := stoppedAt.Add(2 * time.Hour)
+	later := reapAt.Add(3 * time.Hour)
+	servers := &mockPersistence.ServerRepo{}
+	servers.On("GetByID", mock.Anything, "idle").Return(&persistence.Server{ID: "idle", State: "stopped", StoppedAt: &stoppedAt, Version: 3}, nil)
+	servers.On("GetByID", mock.Anything, "postponed").Return(&persistence.Server{ID: "postponed", State: "stopped", StoppedAt: &stoppedAt, ReapAfter: &later, Version: 5}, nil)
+	servers.On("GetByID", mock.Anything, "running").Return(&persistence.Server{ID: "running", State: "running", Version: 2}, nil)
+	servers.On("GetByID", mock.Anything, "missing").Return(nil, nil)
+	servers.On("UpdateReapSchedule", mock.Anything, mock.Anything, mock.Anything, mock.Anything, (*time.Time)(nil)).Return(nil)
+	repo := &mockPersistence.ReaperPolicyRepo{}
+	repo.On("List", mock.Anything).Return([]*persistence.ReaperPolicy(nil), nil)
+	events := &mockPersistence.EventRepo{}
+	events.On("Append", mock.Anything, mock.MatchedBy(func(e *persistence.EventLog) bool {
+		return e.Type == string(domain.EventReapPostponed)
+	})).Return(nil)
+	outbox := &mockPersistence.OutboxRepo{}
+	outbox.On("Add", mock.Anything, mock.Anything).Return(nil)
+	uow := mockPersistence.NewUnitOfWork(persistence.Repos{Servers: servers, Events: events, Outbox: outbox})
+	cfg := &internal.Config{IdleTimeout: 2 * time.Hour, ReaperMaxPostpone: 24 * time.Hour}
+	s := NewReaperService(repo, servers, uow, nil, nil, cfg)
+
+	tests := []struct {
+		name    string
+		id      string
+		by      time.Duration
+		want    time.Time
+		wantErr error
+	}{
+		{name: "extends the idle timeout", id: "idle", by: 4 * time.Hour, want: reapAt.Add(4 * time.Hour)},
+		{name: "extends an earlier postponement", id: "postponed", by: time.Hour, want: later.Add(time.Hour)},
+		{name: "not later", id: "idle", by: -time.Hour, wantErr: ErrInvalidPostponement},
+		{name: "beyond the limit", id: "idle", by: 48 * time.Hour, wantErr: ErrInvalidPostponement},
+		{name: "not stopped", id: "running", by: time.Hour, wantErr: ErrNotReapable},
+		{name: "unknown server", id: "missing", by: time.Hour, wantErr: ErrServerNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Postpone(context.Background(), tt.id, tt.by)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Postpone() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !got.Equal(tt.want) {
+				t.Errorf("Postpone() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+	servers.AssertCalled(t, "UpdateReapSchedule", mock.Anything, "postponed", int64(5), mock.Anything, (*time.Time)(nil))
+}
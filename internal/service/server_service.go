@@ -3,153 +3,499 @@ package service
 import (
 	"context"
 	"errors"
+	"maps"
+	"slices"
 	"time"
 
+	"github.com/rhythin/sever-management/internal"
 	"github.com/rhythin/sever-management/internal/domain"
 	"github.com/rhythin/sever-management/internal/logging"
 	"github.com/rhythin/sever-management/internal/persistence"
 )
 
+// ErrServerNotFound is returned when an action targets an unknown server
+var ErrServerNotFound = errors.New("server not found")
+
+// ErrVersionMismatch is returned when a caller's expected version is stale
+var ErrVersionMismatch = errors.New("server version does not match")
+
+// ErrConcurrentUpdate is returned when another writer changed the server between read and write
+var ErrConcurrentUpdate = errors.New("server was modified concurrently")
+
+// errUnsupportedOperation fails operations of a type the worker cannot complete
+var errUnsupportedOperation = errors.New("unsupported operation type")
+
 // ServerService orchestrates server FSM and actions
 
 type serverService struct {
 	servers persistence.ServerRepo
 	ips     persistence.IPRepo
 	events  persistence.EventRepo
+	ops     persistence.OperationRepo
+	usage   persistence.UsageRepo
+	uow     persistence.UnitOfWork // writes go through the unit of work; the repos above are for reads
+	queue   *OperationQueue
+	bus     *EventBus    // stream subscriptions; the outbox relay publishes to it
+	relay   *OutboxRelay // nudged after commits that queue outbox entries
+	catalog CatalogService
+	prices  PriceService
+	cfg     *internal.Config
+}
+
+func NewServerService(servers persistence.ServerRepo, ips persistence.IPRepo, events persistence.EventRepo, ops persistence.OperationRepo, usage persistence.UsageRepo, uow persistence.UnitOfWork, queue *OperationQueue, bus *EventBus, relay *OutboxRelay, catalog CatalogService, prices PriceService, cfg *internal.Config) ServerService {
+	return &serverService{servers: servers, ips: ips, events: events, ops: ops, usage: usage, uow: uow, queue: queue, bus: bus, relay: relay, catalog: catalog, prices: prices, cfg: cfg}
+}
+
+// Action performs a client-requested state transition (start, stop, reboot, terminate);
+// terminations go through Terminate. A non-zero ifMatch requires the server to be at that
+// version. The updated server is returned.
+func (s *serverService) Action(ctx context.Context, id string, action domain.ServerAction, ifMatch int64) (*persistence.Server, error) {
+	log := logging.S(ctx)
+	log.Infow("ServerService.Action called", "id", id, "action", action, "ifMatch", ifMatch)
+	if !domain.IsValidAction(action) {
+		log.Warnw("Rejected non-client action", "id", id, "action", action)
+		return nil, domain.ErrInvalidTransition
+	}
+	if action == domain.ActionTerminate {
+		return s.Terminate(ctx, id, domain.CauseUser, ifMatch)
+	}
+	if action == domain.ActionStart {
+		if err := s.checkRegionInService(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	server, err := s.loadServer(ctx, id, ifMatch)
+	if err != nil {
+		return nil, err
+	}
+	var updated *persistence.Server
+	var op *persistence.Operation
+	err = s.uow.Do(ctx, func(tx persistence.Repos) error {
+		var err error
+		if updated, err = s.transition(ctx, tx, server, action, ""); err != nil {
+			return err
+		}
+		// Reboots complete asynchronously once the type's reboot duration has elapsed
+		if action == domain.ActionReboot {
+			op, err = s.createOperation(ctx, tx, id, domain.OperationReboot, s.rebootDuration(server.Type))
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.relay.Notify()
+	if op != nil {
+		s.queue.Enqueue(ctx, op)
+	}
+	return updated, nil
+}
+
+// Terminate is the termination pipeline that every caller uses, whether a client or the idle
+// reaper. In one unit of work it terminates the server through the FSM, bills and closes its
+// open usage, releases its IP (quarantined for IP_QUARANTINE, if set) and logs the terminated
+// event with its cause. A non-zero ifMatch requires the server to be at that version.
+func (s *serverService) Terminate(ctx context.Context, id string, cause domain.TerminationCause, ifMatch int64) (*persistence.Server, error) {
+	log := logging.S(ctx)
+	log.Infow("ServerService.Terminate called", "id", id, "cause", cause, "ifMatch", ifMatch)
+	server, err := s.loadServer(ctx, id, ifMatch)
+	if err != nil {
+		return nil, err
+	}
+	var updated *persistence.Server
+	err = s.uow.Do(ctx, func(tx persistence.Repos) error {
+		var err error
+		if updated, err = s.transition(ctx, tx, server, domain.ActionTerminate, cause); err != nil {
+			return err
+		}
+		return s.releaseIP(ctx, tx, server)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.relay.Notify()
+	updated.IPID, updated.IP = nil, nil
+	return updated, nil
+}
+
+// releaseIP frees a terminated server's IP, or quarantines it when IP_QUARANTINE is set, and
+// detaches it from the server
+func (s *serverService) releaseIP(ctx context.Context, tx persistence.Repos, server *persistence.Server) error {
+	if server.IPID == nil {
+		return nil
+	}
+	var err error
+	if s.cfg.IPQuarantine > 0 {
+		err = tx.IPs.QuarantineIP(ctx, *server.IPID, time.Now().Add(s.cfg.IPQuarantine))
+	} else {
+		err = tx.IPs.ReleaseIP(ctx, *server.IPID)
+	}
+	if err != nil {
+		logging.S(ctx).Errorw("Failed to release IP of server", "id", server.ID, "ipID", *server.IPID, "error", err)
+		return err
+	}
+	return tx.Servers.DetachIP(ctx, server.ID)
+}
+
+// checkRegionInService rejects starts in disabled regions
+func (s *serverService) checkRegionInService(ctx context.Context, id string) error {
+	server, err := s.servers.GetByID(ctx, id)
+	if err != nil || server == nil {
+		return ErrServerNotFound
+	}
+	region, err := s.catalog.GetRegion(ctx, server.Region)
+	if errors.Is(err, ErrUnknownRegion) {
+		return nil // servers created before the registry existed
+	}
+	if err != nil {
+		return err
+	}
+	if domain.RegionStatus(region.Status) == domain.RegionDisabled {
+		logging.S(ctx).Warnw("Rejected start in disabled region", "id", id, "region", server.Region)
+		return ErrRegionUnavailable
+	}
+	return nil
 }
 
-func NewServerService(servers persistence.ServerRepo, ips persistence.IPRepo, events persistence.EventRepo) ServerService {
-	return &serverService{servers: servers, ips: ips, events: events}
+// createOperation records a pending operation. Callers enqueue it for the worker once
+// the surrounding unit of work has committed.
+func (s *serverService) createOperation(ctx context.Context, tx persistence.Repos, serverID string, typ domain.OperationType, after time.Duration) (*persistence.Operation, error) {
+	op := &persistence.Operation{
+		Type:      string(typ),
+		ServerID:  serverID,
+		Status:    string(domain.OperationPending),
+		RequestID: requestID(ctx),
+		DueAt:     time.Now().Add(after),
+	}
+	if err := tx.Operations.Create(ctx, op); err != nil {
+		logging.S(ctx).Errorw("Failed to create operation", "serverID", serverID, "type", typ, "error", err)
+		return nil, err
+	}
+	return op, nil
+}
+
+// rebootDuration returns the configured reboot time for a server type
+func (s *serverService) rebootDuration(typ string) time.Duration {
+	if d, ok := s.cfg.RebootDurations[typ]; ok {
+		return d
+	}
+	return s.cfg.RebootDuration
 }
 
-// Action performs a state transition (start, stop, reboot, terminate)
-func (s *serverService) Action(ctx context.Context, id string, action domain.ServerAction) error {
+// loadServer reads a server; a non-zero ifMatch must equal its version
+func (s *serverService) loadServer(ctx context.Context, id string, ifMatch int64) (*persistence.Server, error) {
 	log := logging.S(ctx)
-	log.Infow("ServerService.Action called", "id", id, "action", action)
 	server, err := s.servers.GetByID(ctx, id)
 	if err != nil || server == nil {
 		log.Warnw("Server not found", "id", id)
-		return errors.New("server not found")
+		return nil, ErrServerNotFound
 	}
+	if ifMatch != 0 && server.Version != ifMatch {
+		log.Warnw("Server version does not match precondition", "id", id, "version", server.Version, "ifMatch", ifMatch)
+		return nil, ErrVersionMismatch
+	}
+	return server, nil
+}
+
+// transition runs the FSM for any action, including internal ones, and persists the new
+// state, timestamps and events within tx; cause is recorded on the events of terminations.
+// The state update is a compare-and-swap on the version that was read. It returns the server
+// with its new state and version.
+func (s *serverService) transition(ctx context.Context, tx persistence.Repos, server *persistence.Server, action domain.ServerAction, cause domain.TerminationCause) (*persistence.Server, error) {
+	log := logging.S(ctx)
+	id := server.ID
 	d := toDomainServer(server)
+	rule, _ := domain.LookupTransition(d.State, action)
 	if err := d.Transition(ctx, action); err != nil {
 		log.Warnw("Invalid FSM transition for server", "id", id, "error", err)
-		return err
+		return nil, err
 	}
-	// Persist state and timestamps
-	var started, stopped, terminated *time.Time
-	switch domain.ServerAction(action) {
-	case domain.ActionStart:
-		started = d.StartedAt
-	case domain.ActionStop:
-		stopped = d.StoppedAt
-	case domain.ActionTerminate:
-		terminated = d.TerminatedAt
-	}
-	if err := s.servers.UpdateState(ctx, id, string(d.State)); err != nil {
+	// Persist state and the timestamp the transition stamps
+	var started, stopped, terminated, stamped *time.Time
+	switch rule.Stamp {
+	case domain.StampStarted:
+		started, stamped = d.StartedAt, d.StartedAt
+	case domain.StampStopped:
+		stopped, stamped = d.StoppedAt, d.StoppedAt
+	case domain.StampTerminated:
+		terminated, stamped = d.TerminatedAt, d.TerminatedAt
+	}
+	if err := tx.Servers.UpdateState(ctx, id, server.Version, string(d.State)); err != nil {
+		if errors.Is(err, persistence.ErrVersionConflict) {
+			log.Warnw("Server modified concurrently", "id", id, "action", action)
+			return nil, ErrConcurrentUpdate
+		}
 		log.Errorw("Failed to update state for server", "id", id, "error", err)
-		return err
+		return nil, err
 	}
-	if err := s.servers.UpdateTimestamps(ctx, id, started, stopped, terminated); err != nil {
+	if err := tx.Servers.UpdateTimestamps(ctx, id, started, stopped, terminated); err != nil {
 		log.Errorw("Failed to update timestamps for server", "id", id, "error", err)
-		return err
+		return nil, err
 	}
-	// Log event
-	for _, e := range d.Log.List() {
-		_ = s.events.Append(ctx, &persistence.EventLog{
+	// A started server is no longer idle; its reap schedule starts over when it next stops
+	if rule.Stamp == domain.StampStarted && (server.ReapAfter != nil || server.ReapWarnedFor != nil) {
+		if err := tx.Servers.ClearReapSchedule(ctx, id); err != nil {
+			log.Errorw("Failed to clear reap schedule for server", "id", id, "error", err)
+			return nil, err
+		}
+	}
+	if err := s.recordUsage(ctx, tx, server, rule.Stamp, stamped); err != nil {
+		log.Errorw("Failed to record usage for server", "id", id, "error", err)
+		return nil, err
+	}
+	// Log the events raised by this transition; the repo assigns their sequence numbers
+	for _, e := range d.TakeEvents() {
+		event := &persistence.EventLog{
 			ServerID:  id,
 			Timestamp: e.Timestamp,
 			Type:      string(e.Type),
 			Message:   e.Message,
-		})
+			FromState: string(e.From),
+			ToState:   string(e.To),
+			Action:    string(e.Action),
+			Cause:     string(cause),
+			RequestID: requestID(ctx),
+		}
+		if err := s.appendEvent(ctx, tx, server.Region, event); err != nil {
+			log.Errorw("Failed to log event for server", "id", id, "event", e.Type, "error", err)
+			return nil, err
+		}
+	}
+	updated := *server
+	updated.State = string(d.State)
+	updated.Version++
+	updated.StartedAt, updated.StoppedAt, updated.TerminatedAt = d.StartedAt, d.StoppedAt, d.TerminatedAt
+	if rule.Stamp == domain.StampStarted {
+		updated.ReapAfter, updated.ReapWarnedFor = nil, nil
 	}
 	log.Infow("Action performed on server", "action", action, "id", id)
+	return &updated, nil
+}
+
+// recordUsage opens a usage session when a server starts, and bills and closes it when the
+// server stops or terminates
+func (s *serverService) recordUsage(ctx context.Context, tx persistence.Repos, server *persistence.Server, stamp domain.Stamp, at *time.Time) error {
+	switch stamp {
+	case domain.StampStarted:
+		return tx.Usage.Open(ctx, &persistence.UsageSession{
+			ServerID:     server.ID,
+			Type:         server.Type,
+			Region:       server.Region,
+			Currency:     s.cfg.BillingCurrency,
+			StartedAt:    *at,
+			LastBilledAt: *at,
+		})
+	case domain.StampStopped, domain.StampTerminated:
+		session, err := tx.Usage.GetOpen(ctx, server.ID)
+		if err != nil || session == nil {
+			return err
+		}
+		return billUsage(ctx, tx.Usage, s.prices, session, *at, true, s.cfg)
+	}
 	return nil
 }
 
-// Provision provisions a new server, allocates an IP, and persists it
-func (s *serverService) Provision(ctx context.Context, region, typ string) (string, error) {
+// appendEvent logs an event and queues it in the outbox within tx, so that it is published
+// if and only if tx commits
+func (s *serverService) appendEvent(ctx context.Context, tx persistence.Repos, region string, event *persistence.EventLog) error {
+	if err := tx.Events.Append(ctx, event); err != nil {
+		return err
+	}
+	return tx.Outbox.Add(ctx, &persistence.OutboxEntry{EventID: event.ID, Region: region})
+}
+
+// Provision allocates an IP and persists a new server in the provisioning state with the
+// given labels. The server is brought up asynchronously; the returned operation tracks progress.
+func (s *serverService) Provision(ctx context.Context, region, typ string, labels map[string]string) (*persistence.Operation, error) {
 	log := logging.S(ctx)
-	log.Infow("ServerService.Provision called", "region", region, "type", typ)
+	log.Infow("ServerService.Provision called", "region", region, "type", typ, "labels", labels)
 
-	// Allocate IP
-	ip, err := s.ips.AllocateIP(ctx)
+	if err := domain.ValidateLabels(labels); err != nil {
+		log.Warnw("Rejected provisioning with invalid labels", "error", err)
+		return nil, err
+	}
+	spec, err := s.catalog.GetType(ctx, typ)
 	if err != nil {
-		log.Errorw("Failed to allocate IP", "error", err)
-		return "", err
+		log.Warnw("Rejected provisioning of unknown server type", "type", typ)
+		return nil, err
 	}
-	if ip == nil {
-		log.Warnw("No available IPs for provisioning")
-		return "", errors.New("no available IPs")
+	bootTime := spec.BootTime
+	if bootTime <= 0 {
+		bootTime = s.cfg.ProvisionDelay
 	}
 
-	// Create server model
-	now := time.Now()
-	server := &persistence.Server{
-		Region:    region,
-		Type:      typ,
-		IPID:      &ip.ID,
-		State:     string(domain.ServerProvisioning),
-		CreatedAt: now,
-		UpdatedAt: now,
-		Billing:   &persistence.Billing{},
-	}
+	// Admission, IP allocation, the server row, its provisioned event and the boot operation
+	// commit together; the region stays locked until then, so its capacity cannot be overrun.
+	// AllocateIP runs in this transaction, so a failure after it rolls the allocation back too.
+	var server *persistence.Server
+	var op *persistence.Operation
+	err = s.uow.Do(ctx, func(tx persistence.Repos) error {
+		if err := s.catalog.AdmitServer(ctx, tx, region); err != nil {
+			log.Warnw("Rejected provisioning in region", "region", region, "error", err)
+			return err
+		}
 
-	// Persist server
-	err = s.servers.Create(ctx, server)
-	if err != nil {
-		log.Errorw("Failed to persist server", "error", err)
-		// Rollback IP allocation
-		if err := s.ips.ReleaseIP(ctx, ip.ID); err != nil {
-			log.Errorw("Failed to release IP", "error", err)
+		// Allocate IP
+		ip, err := tx.IPs.AllocateIP(ctx, region)
+		if err != nil {
+			log.Errorw("Failed to allocate IP", "error", err)
+			return err
+		}
+		if ip == nil {
+			log.Warnw("No available IPs for provisioning", "region", region)
+			return errors.New("no available IPs")
 		}
-		return "", err
-	}
-	timeCreated := time.Now()
 
-	// Link IP to server record for reverse reference
-	err = s.ips.AssignIPToServer(ctx, ip.ID, server.ID)
-	if err != nil {
-		log.Errorw("Failed to assign IP to server", "error", err)
-		return "", err
-	}
+		// Create server model
+		now := time.Now()
+		server = &persistence.Server{
+			Region:    region,
+			Type:      typ,
+			IPID:      &ip.ID,
+			State:     string(domain.InitialState),
+			CreatedAt: now,
+			UpdatedAt: now,
+			Billing:   &persistence.Billing{Currency: s.cfg.BillingCurrency},
+			Labels:    toServerLabels(labels),
+		}
+		if err := tx.Servers.Create(ctx, server); err != nil {
+			log.Errorw("Failed to persist server", "error", err)
+			return err
+		}
+
+		// Link IP to server record for reverse reference
+		if err := tx.IPs.AssignIPToServer(ctx, ip.ID, server.ID); err != nil {
+			log.Errorw("Failed to assign IP to server", "error", err)
+			return err
+		}
 
-	// Update server state to running immediately (simulate provisioning)
-	// simulate a small delay to simulate provisioning
-	time.Sleep(1 * time.Second)
-	timeStarted := time.Now()
-	err = s.servers.UpdateServer(ctx, server.ID, &persistence.Server{
-		State:     string(domain.ServerRunning),
-		StartedAt: &timeStarted,
+		provisioned := &persistence.EventLog{
+			ServerID:  server.ID,
+			Timestamp: server.CreatedAt,
+			Type:      string(domain.EventProvisioned),
+			Message:   domain.EventMessage(domain.EventProvisioned),
+			ToState:   server.State,
+			Action:    string(domain.ActionProvision),
+			RequestID: requestID(ctx),
+		}
+		if err := s.appendEvent(ctx, tx, region, provisioned); err != nil {
+			log.Errorw("Failed to log provision event", "error", err)
+			return err
+		}
+
+		op, err = s.createOperation(ctx, tx, server.ID, domain.OperationProvision, bootTime)
+		return err
 	})
 	if err != nil {
-		log.Errorw("Failed to update server state to running", "error", err)
-		return server.ID, err // server is created, but state update failed
+		return nil, err
 	}
+	s.relay.Notify()
+	s.queue.Enqueue(ctx, op)
 
-	// Log provision and running events
-	err = s.events.Append(ctx, &persistence.EventLog{
-		ServerID:  server.ID,
-		Timestamp: timeCreated,
-		Type:      string(domain.EventProvisioned),
-		Message:   "Server provisioned",
-	})
+	log.Infow("Provisioning started", "serverID", server.ID, "operationID", op.ID)
+	return op, nil
+}
+
+// toServerLabels converts labels to rows, ordered by key
+func toServerLabels(labels map[string]string) []*persistence.ServerLabel {
+	rows := make([]*persistence.ServerLabel, 0, len(labels))
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		rows = append(rows, &persistence.ServerLabel{Key: k, Value: labels[k]})
+	}
+	return rows
+}
+
+// CompleteOperation finishes a pending operation and records its outcome. Operations of an
+// unknown type, or whose transition no longer applies, fail; a failed provision gives up the
+// IP its server was allocated.
+func (s *serverService) CompleteOperation(ctx context.Context, id string) error {
+	log := logging.S(ctx)
+	log.Infow("ServerService.CompleteOperation called", "id", id)
+	op, err := s.ops.GetByID(ctx, id)
 	if err != nil {
-		log.Errorw("Failed to log provision event", "error", err)
-		return server.ID, err
-	}
-	err = s.events.Append(ctx, &persistence.EventLog{
-		ServerID:  server.ID,
-		Timestamp: timeStarted,
-		Type:      string(domain.EventStarted),
-		Message:   "Server running",
+		return err
+	}
+	if op == nil {
+		return errors.New("operation not found")
+	}
+	if op.Status != string(domain.OperationPending) {
+		return nil
+	}
+	// Attribute the completion to the request that started the operation
+	if op.RequestID != "" {
+		ctx = logging.WithRequestID(ctx, op.RequestID)
+		log = logging.S(ctx)
+	}
+
+	var action domain.ServerAction
+	switch domain.OperationType(op.Type) {
+	case domain.OperationProvision:
+		action = domain.ActionCompleteProvision
+	case domain.OperationReboot:
+		action = domain.ActionCompleteReboot
+	}
+
+	// The transition and the operation's success are recorded atomically
+	var opErr error
+	if action == "" {
+		opErr = errUnsupportedOperation
+	} else if server, err := s.loadServer(ctx, op.ServerID, 0); err != nil {
+		opErr = err
+	} else {
+		opErr = s.uow.Do(ctx, func(tx persistence.Repos) error {
+			if _, err := s.transition(ctx, tx, server, action, ""); err != nil {
+				return err
+			}
+			return tx.Operations.Complete(ctx, id, string(domain.OperationSucceeded), "")
+		})
+		if opErr == nil {
+			s.relay.Notify()
+		}
+	}
+	if opErr == nil {
+		log.Infow("Operation completed", "id", id, "status", domain.OperationSucceeded)
+		return nil
+	}
+
+	// Infrastructure errors leave the operation pending so that the worker retries it
+	if !errors.Is(opErr, domain.ErrInvalidTransition) && !errors.Is(opErr, ErrServerNotFound) && !errors.Is(opErr, errUnsupportedOperation) {
+		log.Errorw("Operation failed; will retry", "id", id, "error", opErr)
+		return opErr
+	}
+	err = s.uow.Do(ctx, func(tx persistence.Repos) error {
+		if err := tx.Operations.Complete(ctx, id, string(domain.OperationFailed), opErr.Error()); err != nil {
+			return err
+		}
+		if domain.OperationType(op.Type) == domain.OperationProvision {
+			return s.releaseFailedProvision(ctx, tx, op.ServerID)
+		}
+		return nil
 	})
 	if err != nil {
-		log.Errorw("Failed to log running event", "error", err)
-		return server.ID, err
+		return err
 	}
+	log.Infow("Operation completed", "id", id, "status", domain.OperationFailed, "error", opErr)
+	return nil
+}
+
+// releaseFailedProvision releases the IP of a server whose provisioning failed, unless the
+// server went on to run and still uses it. Terminations release IPs themselves, but servers
+// terminated before they did may still hold one.
+func (s *serverService) releaseFailedProvision(ctx context.Context, tx persistence.Repos, serverID string) error {
+	server, err := tx.Servers.GetByID(ctx, serverID)
+	if err != nil || server == nil || server.IPID == nil {
+		return err
+	}
+	state := domain.ServerState(server.State)
+	if state != domain.ServerProvisioning && !domain.IsTerminal(state) {
+		return nil
+	}
+	logging.S(ctx).Infow("Releasing IP of failed provision", "id", serverID, "state", state, "ipID", *server.IPID)
+	return s.releaseIP(ctx, tx, server)
+}
 
-	return server.ID, nil
+func (s *serverService) GetOperation(ctx context.Context, id string) (*persistence.Operation, error) {
+	return s.ops.GetByID(ctx, id)
 }
 
 // toDomainServer maps persistence.Server to domain.Server (minimal for FSM)
@@ -160,14 +506,57 @@ func toDomainServer(s *persistence.Server) *domain.Server {
 		StartedAt:    s.StartedAt,
 		StoppedAt:    s.StoppedAt,
 		TerminatedAt: s.TerminatedAt,
-		Log:          domain.NewEventRingBuffer(100), // Not persisted, just for FSM
 	}
 }
 
+// requestID returns the ID of the request behind ctx, or "" for background work
+func requestID(ctx context.Context) string {
+	if id := logging.RequestIDFromContext(ctx); id != "unknown" {
+		return id
+	}
+	return ""
+}
+
 func (s *serverService) GetEvents(ctx context.Context, id string, n int) ([]persistence.EventLog, error) {
 	return s.events.LastN(ctx, id, n)
 }
 
+// QueryEvents returns one page of events matching q and the cursor (an event ID) for
+// the next page, which is 0 when there are no more events
+func (s *serverService) QueryEvents(ctx context.Context, q persistence.EventQuery) ([]persistence.EventLog, uint, error) {
+	limit := q.Limit
+	if limit > 0 {
+		q.Limit = limit + 1 // one extra row tells us whether another page exists
+	}
+	events, err := s.events.Query(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+		return events, events[limit-1].ID, nil
+	}
+	return events, 0, nil
+}
+
+// SubscribeEvents streams events committed from now on that match the server, region and
+// type filters of q. Callers replay earlier events with QueryEvents and must Close the subscription.
+func (s *serverService) SubscribeEvents(q persistence.EventQuery) *EventSubscription {
+	return s.bus.Subscribe(q)
+}
+
+// ListUsage returns a server's usage sessions, oldest first
+func (s *serverService) ListUsage(ctx context.Context, id string) ([]*persistence.UsageSession, error) {
+	server, err := s.servers.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if server == nil {
+		return nil, ErrServerNotFound
+	}
+	return s.usage.ListByServer(ctx, id)
+}
+
 func (s *serverService) ListServers(ctx context.Context, region, status, typ string, limit, offset int) ([]*persistence.Server, error) {
 	return s.servers.List(ctx, region, status, typ, limit, offset)
 }
@@ -3,11 +3,16 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/rhythin/sever-management/internal"
 	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
 	"github.com/rhythin/sever-management/internal/persistence"
 	mockPersistence "github.com/rhythin/sever-management/internal/persistence/mocks"
 	"github.com/stretchr/testify/mock"
@@ -36,33 +41,49 @@ func Test_serverService_Action(t *testing.T) {
 	mockServerRepo := &mockPersistence.ServerRepoInterface{}
 	mockServerRepo.On("GetByID", mock.Anything, "1").Return(nil, nil)
 	mockServerRepo.On("GetByID", mock.Anything, "2").Return(validServer, nil)
-	mockServerRepo.On("GetByID", mock.Anything, "3").Return(validServer, nil)
-	mockServerRepo.On("GetByID", mock.Anything, "4").Return(validServer, nil)
+	mockServerRepo.On("GetByID", mock.Anything, "3").Return(withID(validServer, "3"), nil)
+	mockServerRepo.On("GetByID", mock.Anything, "4").Return(withID(validServer, "4"), nil)
 	mockServerRepo.On("GetByID", mock.Anything, "5").Return(stoppedServer, nil)
-	mockServerRepo.On("UpdateState", mock.Anything, "2", "stopped").Return(errors.New("update state failed")).Once()
-	mockServerRepo.On("UpdateState", mock.Anything, "3", "stopped").Return(nil)
-	mockServerRepo.On("UpdateState", mock.Anything, "4", "stopped").Return(nil)
-	mockServerRepo.On("UpdateState", mock.Anything, "4", "terminated").Return(nil)
-	mockServerRepo.On("UpdateState", mock.Anything, "5", "running").Return(nil)
-	mockServerRepo.On("UpdateState", mock.Anything, "5", "terminated").Return(nil)
+	mockServerRepo.On("UpdateState", mock.Anything, "2", mock.Anything, "stopped").Return(errors.New("update state failed")).Once()
+	mockServerRepo.On("UpdateState", mock.Anything, "3", mock.Anything, "stopped").Return(nil)
+	mockServerRepo.On("UpdateState", mock.Anything, "4", mock.Anything, "stopped").Return(nil)
+	mockServerRepo.On("UpdateState", mock.Anything, "4", mock.Anything, "terminated").Return(nil)
+	mockServerRepo.On("UpdateState", mock.Anything, "5", mock.Anything, "running").Return(nil)
+	mockServerRepo.On("UpdateState", mock.Anything, "5", mock.Anything, "terminated").Return(nil)
+	mockServerRepo.On("GetByID", mock.Anything, "6").Return(&persistence.Server{ID: "6", Type: "t2.small", State: "running"}, nil)
+	mockServerRepo.On("UpdateState", mock.Anything, "6", mock.Anything, "rebooting").Return(nil)
+	mockServerRepo.On("UpdateTimestamps", mock.Anything, "6", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockServerRepo.On("GetByID", mock.Anything, "7").Return(&persistence.Server{ID: "7", State: "rebooting"}, nil)
 	mockServerRepo.On("UpdateTimestamps", mock.Anything, "3", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("update timestamps failed")).Once()
 	mockServerRepo.On("UpdateTimestamps", mock.Anything, "4", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	mockServerRepo.On("UpdateTimestamps", mock.Anything, "5", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
+	mockServerRepo.On("GetByID", mock.Anything, "8").Return(&persistence.Server{ID: "8", Region: "eu-west-1", State: "stopped"}, nil)
+
 	mockIPRepo := &mockPersistence.IPRepoInterface{}
 
 	mockEventRepo := &mockPersistence.EventRepoInterface{}
 	mockEventRepo.On("Append", mock.Anything, mock.Anything).Return(nil)
 
+	mockRegionRepo := &mockPersistence.RegionRepo{}
+	mockRegionRepo.On("GetByName", mock.Anything, "").Return(nil, nil)
+	mockRegionRepo.On("GetByName", mock.Anything, "eu-west-1").Return(&persistence.Region{Name: "eu-west-1", Status: "disabled"}, nil)
+
+	mockOperationRepo := &mockPersistence.OperationRepo{}
+	mockOperationRepo.On("Create", mock.Anything, mock.MatchedBy(func(op *persistence.Operation) bool {
+		return op.ServerID == "6" && op.Type == string(domain.OperationReboot) && time.Until(op.DueAt) > time.Minute
+	})).Return(nil).Once()
+
 	type fields struct {
 		servers persistence.ServerRepo
 		ips     persistence.IPRepo
 		events  persistence.EventRepo
 	}
 	type args struct {
-		ctx    context.Context
-		id     string
-		action domain.ServerAction
+		ctx     context.Context
+		id      string
+		action  domain.ServerAction
+		ifMatch int64
 	}
 	tests := []struct {
 		name    string
@@ -168,6 +189,62 @@ func Test_serverService_Action(t *testing.T) {
 				events:  mockEventRepo,
 			},
 		},
+		{
+			name: "reboot persists rebooting and schedules completion",
+			args: args{
+				ctx:    context.Background(),
+				id:     "6",
+				action: domain.ActionReboot,
+			},
+			wantErr: false,
+			fields: fields{
+				servers: mockServerRepo,
+				ips:     mockIPRepo,
+				events:  mockEventRepo,
+			},
+		},
+		{
+			name: "conflicting action while rebooting",
+			args: args{
+				ctx:    context.Background(),
+				id:     "7",
+				action: domain.ActionStop,
+			},
+			wantErr: true,
+			fields: fields{
+				servers: mockServerRepo,
+				ips:     mockIPRepo,
+				events:  mockEventRepo,
+			},
+		},
+		{
+			name: "internal action rejected",
+			args: args{
+				ctx:    context.Background(),
+				id:     "7",
+				action: domain.ActionCompleteReboot,
+			},
+			wantErr: true,
+			fields: fields{
+				servers: mockServerRepo,
+				ips:     mockIPRepo,
+				events:  mockEventRepo,
+			},
+		},
+		{
+			name: "start rejected in disabled region",
+			args: args{
+				ctx:    context.Background(),
+				id:     "8",
+				action: domain.ActionStart,
+			},
+			wantErr: true,
+			fields: fields{
+				servers: mockServerRepo,
+				ips:     mockIPRepo,
+				events:  mockEventRepo,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -175,24 +252,306 @@ func Test_serverService_Action(t *testing.T) {
 				servers: tt.fields.servers,
 				ips:     tt.fields.ips,
 				events:  tt.fields.events,
+				ops:     mockOperationRepo,
+				uow: mockPersistence.NewUnitOfWork(persistence.Repos{
+					Servers:    tt.fields.servers,
+					IPs:        tt.fields.ips,
+					Events:     tt.fields.events,
+					Operations: mockOperationRepo,
+					Outbox:     newOutboxRepo(),
+					Usage:      newUsageRepo(),
+				}),
+				queue:   NewOperationQueue(&internal.Config{}),
+				catalog: NewCatalogService(&internal.Config{}, mockRegionRepo),
+				cfg: &internal.Config{
+					RebootDuration:  time.Second,
+					RebootDurations: map[string]time.Duration{"t2.small": time.Hour},
+				},
 			}
-			if err := s.Action(tt.args.ctx, tt.args.id, tt.args.action); (err != nil) != tt.wantErr {
+			if _, err := s.Action(tt.args.ctx, tt.args.id, tt.args.action, tt.args.ifMatch); (err != nil) != tt.wantErr {
 				t.Errorf("serverService.Action() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
+// withID returns a copy of s with a different ID
+func withID(s *persistence.Server, id string) *persistence.Server {
+	c := *s
+	c.ID = id
+	return &c
+}
+
+func Test_serverService_Action_Concurrency(t *testing.T) {
+	mockServerRepo := &mockPersistence.ServerRepoInterface{}
+	mockServerRepo.On("GetByID", mock.Anything, "1").Return(&persistence.Server{ID: "1", State: "running", Version: 3}, nil)
+	mockServerRepo.On("UpdateState", mock.Anything, "1", int64(3), "stopped").Return(nil).Once()
+	mockServerRepo.On("UpdateState", mock.Anything, "1", int64(3), "stopped").Return(persistence.ErrVersionConflict)
+	mockServerRepo.On("UpdateTimestamps", mock.Anything, "1", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockEventRepo := &mockPersistence.EventRepoInterface{}
+	mockEventRepo.On("Append", mock.Anything, mock.Anything).Return(nil)
+
+	s := &serverService{
+		servers: mockServerRepo,
+		events:  mockEventRepo,
+		uow:     mockPersistence.NewUnitOfWork(persistence.Repos{Servers: mockServerRepo, Events: mockEventRepo, Outbox: newOutboxRepo(), Usage: newUsageRepo()}),
+		cfg:     &internal.Config{},
+	}
+
+	got, err := s.Action(context.Background(), "1", domain.ActionStop, 3)
+	if err != nil {
+		t.Fatalf("serverService.Action() error = %v", err)
+	}
+	if got.Version != 4 || got.State != "stopped" {
+		t.Errorf("serverService.Action() = version %d state %s, want version 4 state stopped", got.Version, got.State)
+	}
+	if _, err := s.Action(context.Background(), "1", domain.ActionStop, 2); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("serverService.Action() stale If-Match error = %v, want %v", err, ErrVersionMismatch)
+	}
+	// A second writer that read version 3 loses the compare-and-swap
+	if _, err := s.Action(context.Background(), "1", domain.ActionStop, 0); !errors.Is(err, ErrConcurrentUpdate) {
+		t.Errorf("serverService.Action() lost race error = %v, want %v", err, ErrConcurrentUpdate)
+	}
+	mockEventRepo.AssertNumberOfCalls(t, "Append", 1)
+}
+
+func Test_serverService_Action_EventAppendFailure(t *testing.T) {
+	mockServerRepo := &mockPersistence.ServerRepoInterface{}
+	mockServerRepo.On("GetByID", mock.Anything, "1").Return(&persistence.Server{ID: "1", Type: "t2.micro", State: "running", Version: 1}, nil)
+	mockServerRepo.On("UpdateState", mock.Anything, "1", int64(1), "rebooting").Return(nil)
+	mockServerRepo.On("UpdateTimestamps", mock.Anything, "1", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockEventRepo := &mockPersistence.EventRepoInterface{}
+	mockEventRepo.On("Append", mock.Anything, mock.Anything).Return(errors.New("event log unavailable"))
+	mockOperationRepo := &mockPersistence.OperationRepo{}
+
+	mockOutboxRepo := newOutboxRepo()
+
+	queue := NewOperationQueue(&internal.Config{})
+	s := &serverService{
+		servers: mockServerRepo,
+		events:  mockEventRepo,
+		ops:     mockOperationRepo,
+		uow: mockPersistence.NewUnitOfWork(persistence.Repos{
+			Servers:    mockServerRepo,
+			Events:     mockEventRepo,
+			Operations: mockOperationRepo,
+			Outbox:     mockOutboxRepo,
+			Usage:      newUsageRepo(),
+		}),
+		queue: queue,
+		cfg:   &internal.Config{RebootDuration: time.Second},
+	}
+	// The failed append aborts the unit of work before the reboot operation is created
+	if _, err := s.Action(context.Background(), "1", domain.ActionReboot, 0); err == nil {
+		t.Fatalf("serverService.Action() error = nil, want event append error")
+	}
+	mockOperationRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	if len(queue.ch) != 0 {
+		t.Errorf("serverService.Action() enqueued an operation after a failed unit of work")
+	}
+	mockOutboxRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+}
+
+func Test_serverService_Action_EventContext(t *testing.T) {
+	mockServerRepo := &mockPersistence.ServerRepoInterface{}
+	mockServerRepo.On("GetByID", mock.Anything, "1").Return(&persistence.Server{ID: "1", Region: "eu-west-1", State: "running", Version: 1}, nil)
+	mockServerRepo.On("UpdateState", mock.Anything, "1", int64(1), "stopped").Return(nil)
+	mockServerRepo.On("UpdateTimestamps", mock.Anything, "1", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockEventRepo := &mockPersistence.EventRepoInterface{}
+	mockEventRepo.On("Append", mock.Anything, mock.MatchedBy(func(e *persistence.EventLog) bool {
+		return e.Type == string(domain.EventStopped) && e.FromState == "running" && e.ToState == "stopped" &&
+			e.Action == string(domain.ActionStop) && e.RequestID == "req-1"
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*persistence.EventLog).ID = 99
+	}).Return(nil).Once()
+	// The event is queued for publication in the same unit of work
+	mockOutboxRepo := &mockPersistence.OutboxRepo{}
+	mockOutboxRepo.On("Add", mock.Anything, &persistence.OutboxEntry{EventID: 99, Region: "eu-west-1"}).Return(nil).Once()
+
+	s := &serverService{
+		servers: mockServerRepo,
+		events:  mockEventRepo,
+		uow:     mockPersistence.NewUnitOfWork(persistence.Repos{Servers: mockServerRepo, Events: mockEventRepo, Outbox: mockOutboxRepo, Usage: newUsageRepo()}),
+		cfg:     &internal.Config{},
+	}
+	if _, err := s.Action(logging.WithRequestID(context.Background(), "req-1"), "1", domain.ActionStop, 0); err != nil {
+		t.Fatalf("serverService.Action() error = %v", err)
+	}
+	mockEventRepo.AssertExpectations(t)
+	mockOutboxRepo.AssertExpectations(t)
+}
+
+func Test_serverService_Action_Usage(t *testing.T) {
+	startedAt := time.Now().Add(-time.Hour)
+	mockServerRepo := &mockPersistence.ServerRepoInterface{}
+	mockServerRepo.On("GetByID", mock.Anything, "running").Return(&persistence.Server{ID: "running", Type: "t2.micro", State: "running", StartedAt: &startedAt, Version: 1}, nil)
+	mockServerRepo.On("GetByID", mock.Anything, "stopped").Return(&persistence.Server{ID: "stopped", Type: "t2.micro", Region: "eu-west-1", State: "stopped", Version: 1}, nil)
+	mockServerRepo.On("UpdateState", mock.Anything, mock.Anything, int64(1), mock.Anything).Return(nil)
+	mockServerRepo.On("UpdateTimestamps", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockEventRepo := &mockPersistence.EventRepoInterface{}
+	mockEventRepo.On("Append", mock.Anything, mock.Anything).Return(nil)
+
+	session := &persistence.UsageSession{ID: 7, ServerID: "running", Type: "t2.micro", StartedAt: startedAt, LastBilledAt: startedAt.Add(59 * time.Minute)}
+	usage := &mockPersistence.UsageRepo{}
+	usage.On("GetOpen", mock.Anything, "running").Return(session, nil)
+	// Stopping bills the minute since the last billing and closes the session
+	usage.On("Bill", mock.Anything, session, mock.MatchedBy(func(charges []*persistence.UsageCharge) bool {
+		seconds, _ := sumCharges(charges)
+		return seconds >= 60 && seconds <= 61
+	}), mock.Anything, mock.MatchedBy(func(end *time.Time) bool { return end != nil })).Return(nil).Once()
+	// Starting opens a session priced by the server's type and region
+	usage.On("Open", mock.Anything, mock.MatchedBy(func(u *persistence.UsageSession) bool {
+		return u.ServerID == "stopped" && u.Type == "t2.micro" && u.Region == "eu-west-1" && u.Currency == "USD" && u.EndedAt == nil && u.LastBilledAt.Equal(u.StartedAt)
+	})).Return(nil).Once()
+
+	cfg := &internal.Config{ServerTypes: internal.ServerTypeCatalog{{Name: "t2.micro", HourlyPrice: 11600}}, BillingCurrency: "USD"}
+	s := &serverService{
+		servers: mockServerRepo,
+		events:  mockEventRepo,
+		uow: mockPersistence.NewUnitOfWork(persistence.Repos{
+			Servers: mockServerRepo,
+			Events:  mockEventRepo,
+			Outbox:  newOutboxRepo(),
+			Usage:   usage,
+		}),
+		catalog: NewCatalogService(&internal.Config{}, newUnknownRegionRepo()),
+		prices:  NewPriceService(newPriceRepo(), nil, cfg),
+		cfg:     cfg,
+	}
+	if _, err := s.Action(context.Background(), "running", domain.ActionStop, 0); err != nil {
+		t.Fatalf("serverService.Action(stop) error = %v", err)
+	}
+	if _, err := s.Action(context.Background(), "stopped", domain.ActionStart, 0); err != nil {
+		t.Fatalf("serverService.Action(start) error = %v", err)
+	}
+	usage.AssertExpectations(t)
+}
+
+func Test_serverService_Terminate(t *testing.T) {
+	ipID := uint(9)
+	startedAt := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name       string
+		cause      domain.TerminationCause
+		ifMatch    int64
+		quarantine time.Duration
+		releaseErr error
+		wantErr    error
+	}{
+		{name: "user releases the IP", cause: domain.CauseUser},
+		{name: "reaper quarantines the IP", cause: domain.CauseReaper, ifMatch: 3, quarantine: time.Hour},
+		{name: "stale version", cause: domain.CauseReaper, ifMatch: 2, wantErr: ErrVersionMismatch},
+		{name: "release failure", cause: domain.CauseUser, releaseErr: errors.New("db down"), wantErr: errors.New("db down")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			servers := &mockPersistence.ServerRepoInterface{}
+			servers.On("GetByID", mock.Anything, "srv-1").Return(&persistence.Server{ID: "srv-1", Type: "t2.micro", State: "running", IPID: &ipID, StartedAt: &startedAt, Version: 3}, nil)
+			servers.On("UpdateState", mock.Anything, "srv-1", int64(3), "terminated").Return(nil)
+			servers.On("UpdateTimestamps", mock.Anything, "srv-1", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			servers.On("DetachIP", mock.Anything, "srv-1").Return(nil)
+			ips := &mockPersistence.IPRepoInterface{}
+			ips.On("ReleaseIP", mock.Anything, ipID).Return(tt.releaseErr)
+			ips.On("QuarantineIP", mock.Anything, ipID, mock.MatchedBy(func(until time.Time) bool {
+				return time.Until(until) > 59*time.Minute
+			})).Return(tt.releaseErr)
+			events := &mockPersistence.EventRepoInterface{}
+			events.On("Append", mock.Anything, mock.MatchedBy(func(e *persistence.EventLog) bool {
+				return e.Type == string(domain.EventTerminated) && e.Cause == string(tt.cause)
+			})).Return(nil)
+			session := &persistence.UsageSession{ID: 7, ServerID: "srv-1", Type: "t2.micro", StartedAt: startedAt, LastBilledAt: startedAt}
+			usage := &mockPersistence.UsageRepo{}
+			usage.On("GetOpen", mock.Anything, "srv-1").Return(session, nil)
+			usage.On("Bill", mock.Anything, session, mock.Anything, mock.Anything, mock.MatchedBy(func(end *time.Time) bool { return end != nil })).Return(nil)
+
+			cfg := &internal.Config{IPQuarantine: tt.quarantine}
+			s := &serverService{
+				servers: servers,
+				ips:     ips,
+				events:  events,
+				uow: mockPersistence.NewUnitOfWork(persistence.Repos{
+					Servers: servers,
+					IPs:     ips,
+					Events:  events,
+					Outbox:  newOutboxRepo(),
+					Usage:   usage,
+				}),
+				prices: NewPriceService(newPriceRepo(), nil, cfg),
+				cfg:    cfg,
+			}
+			var got *persistence.Server
+			var err error
+			if tt.cause == domain.CauseUser {
+				got, err = s.Action(context.Background(), "srv-1", domain.ActionTerminate, tt.ifMatch)
+			} else {
+				got, err = s.Terminate(context.Background(), "srv-1", tt.cause, tt.ifMatch)
+			}
+			if tt.wantErr != nil {
+				if err == nil || err.Error() != tt.wantErr.Error() {
+					t.Fatalf("Terminate() error = %v, want %v", err, tt.wantErr)
+				}
+				servers.AssertNotCalled(t, "DetachIP", mock.Anything, mock.Anything)
+				return
+			}
+			if err != nil {
+				t.Fatalf("Terminate() error = %v", err)
+			}
+			if got.State != string(domain.ServerTerminated) || got.IPID != nil {
+				t.Errorf("Terminate() = %+v", got)
+			}
+			if tt.quarantine > 0 {
+				ips.AssertNotCalled(t, "ReleaseIP", mock.Anything, mock.Anything)
+			} else {
+				ips.AssertNotCalled(t, "QuarantineIP", mock.Anything, mock.Anything, mock.Anything)
+			}
+			servers.AssertCalled(t, "DetachIP", mock.Anything, "srv-1")
+			events.AssertExpectations(t)
+			usage.AssertExpectations(t)
+		})
+	}
+}
+
+// newUnknownRegionRepo returns a region registry that knows no regions
+func newUnknownRegionRepo() *mockPersistence.RegionRepo {
+	regions := &mockPersistence.RegionRepo{}
+	regions.On("GetByName", mock.Anything, mock.Anything).Return(nil, nil)
+	return regions
+}
+
+// newUsageRepo returns a usage ledger with no open sessions that accepts new ones
+func newUsageRepo() *mockPersistence.UsageRepo {
+	usage := &mockPersistence.UsageRepo{}
+	usage.On("Open", mock.Anything, mock.Anything).Return(nil)
+	usage.On("GetOpen", mock.Anything, mock.Anything).Return(nil, nil)
+	return usage
+}
+
+// newPriceRepo returns an empty price book, so that uptime is billed at catalog prices
+func newPriceRepo() *mockPersistence.PriceRepo {
+	prices := &mockPersistence.PriceRepo{}
+	prices.On("ListEffective", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	return prices
+}
+
+// newOutboxRepo returns an outbox that accepts every entry
+func newOutboxRepo() *mockPersistence.OutboxRepo {
+	outbox := &mockPersistence.OutboxRepo{}
+	outbox.On("Add", mock.Anything, mock.Anything).Return(nil)
+	return outbox
+}
+
 func Test_serverService_Provision(t *testing.T) {
 	type fields struct {
 		servers persistence.ServerRepo
 		ips     persistence.IPRepo
 		events  persistence.EventRepo
+		ops     persistence.OperationRepo
 	}
 	type args struct {
 		ctx    context.Context
 		region string
 		typ    string
+		labels map[string]string
 	}
 	tests := []struct {
 		name    string
@@ -201,13 +560,44 @@ func Test_serverService_Provision(t *testing.T) {
 		want    string
 		wantErr bool
 	}{
+		{
+			name: "Invalid labels",
+			fields: fields{
+				servers: &mockPersistence.ServerRepoInterface{},
+				ips:     &mockPersistence.IPRepoInterface{},
+				events:  &mockPersistence.EventRepoInterface{},
+			},
+			args: args{
+				ctx:    context.Background(),
+				region: "us-west-1",
+				typ:    "t2.micro",
+				labels: map[string]string{"Team": "payments"},
+			},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name: "Unknown server type",
+			fields: fields{
+				servers: &mockPersistence.ServerRepoInterface{},
+				ips:     &mockPersistence.IPRepoInterface{},
+				events:  &mockPersistence.EventRepoInterface{},
+			},
+			args: args{
+				ctx:    context.Background(),
+				region: "us-west-1",
+				typ:    "m5.huge",
+			},
+			want:    "",
+			wantErr: true,
+		},
 		{
 			name: "Allocate IP error",
 			fields: fields{
 				servers: &mockPersistence.ServerRepoInterface{},
 				ips: func() *mockPersistence.IPRepoInterface {
 					mock := &mockPersistence.IPRepoInterface{}
-					mock.On("AllocateIP", context.Background()).Return(nil, errors.New("allocation error"))
+					mock.On("AllocateIP", context.Background(), "us-west-1").Return(nil, errors.New("allocation error"))
 					return mock
 				}(),
 				events: &mockPersistence.EventRepoInterface{},
@@ -226,7 +616,7 @@ func Test_serverService_Provision(t *testing.T) {
 				servers: &mockPersistence.ServerRepoInterface{},
 				ips: func() *mockPersistence.IPRepoInterface {
 					mock := &mockPersistence.IPRepoInterface{}
-					mock.On("AllocateIP", context.Background()).Return(nil, nil)
+					mock.On("AllocateIP", context.Background(), "us-west-1").Return(nil, nil)
 					return mock
 				}(),
 				events: &mockPersistence.EventRepoInterface{},
@@ -249,8 +639,7 @@ func Test_serverService_Provision(t *testing.T) {
 				}(),
 				ips: func() *mockPersistence.IPRepoInterface {
 					mockIPRepo := &mockPersistence.IPRepoInterface{}
-					mockIPRepo.On("AllocateIP", context.Background()).Return(&persistence.IPAddress{ID: 1, Address: "192.168.1.1"}, nil)
-					mockIPRepo.On("ReleaseIP", context.Background(), uint(1)).Return(nil)
+					mockIPRepo.On("AllocateIP", context.Background(), "us-west-1").Return(&persistence.IPAddress{ID: 1, Address: "192.168.1.1"}, nil)
 					return mockIPRepo
 				}(),
 				events: &mockPersistence.EventRepoInterface{},
@@ -277,9 +666,8 @@ func Test_serverService_Provision(t *testing.T) {
 				}(),
 				ips: func() *mockPersistence.IPRepoInterface {
 					mockIPRepo := &mockPersistence.IPRepoInterface{}
-					mockIPRepo.On("AllocateIP", context.Background()).Return(&persistence.IPAddress{ID: 1, Address: "192.168.1.1"}, nil)
+					mockIPRepo.On("AllocateIP", context.Background(), "us-west-1").Return(&persistence.IPAddress{ID: 1, Address: "192.168.1.1"}, nil)
 					mockIPRepo.On("AssignIPToServer", context.Background(), uint(1), "test-server").Return(errors.New("assign error"))
-					mockIPRepo.On("ReleaseIP", context.Background(), uint(1)).Return(nil)
 					return mockIPRepo
 				}(),
 				events: &mockPersistence.EventRepoInterface{},
@@ -297,16 +685,17 @@ func Test_serverService_Provision(t *testing.T) {
 			fields: fields{
 				servers: func() *mockPersistence.ServerRepoInterface {
 					mockServerRepo := &mockPersistence.ServerRepoInterface{}
-					mockServerRepo.On("Create", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+					mockServerRepo.On("Create", context.Background(), mock.MatchedBy(func(s *persistence.Server) bool {
+						return len(s.Labels) == 2 && s.Labels[0].Key == "env" && s.Labels[1].Key == "team" && s.Labels[1].Value == "payments"
+					})).Run(func(args mock.Arguments) {
 						s := args.Get(1).(*persistence.Server)
 						s.ID = "test-server"
 					}).Return(nil)
-					mockServerRepo.On("UpdateServer", context.Background(), "test-server", mock.Anything).Return(nil)
 					return mockServerRepo
 				}(),
 				ips: func() *mockPersistence.IPRepoInterface {
 					mockIPRepo := &mockPersistence.IPRepoInterface{}
-					mockIPRepo.On("AllocateIP", context.Background()).Return(&persistence.IPAddress{
+					mockIPRepo.On("AllocateIP", context.Background(), "us-west-1").Return(&persistence.IPAddress{
 						ID:      1,
 						Address: "192.168.1.1",
 					}, nil)
@@ -315,14 +704,23 @@ func Test_serverService_Provision(t *testing.T) {
 				}(),
 				events: func() *mockPersistence.EventRepoInterface {
 					mockEventRepo := &mockPersistence.EventRepoInterface{}
-					mockEventRepo.On("Append", context.Background(), mock.Anything).Return(nil).Twice()
+					mockEventRepo.On("Append", context.Background(), mock.Anything).Return(nil).Once()
 					return mockEventRepo
 				}(),
+				ops: func() *mockPersistence.OperationRepo {
+					mockOperationRepo := &mockPersistence.OperationRepo{}
+					mockOperationRepo.On("Create", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+						op := args.Get(1).(*persistence.Operation)
+						op.ID = "test-operation"
+					}).Return(nil)
+					return mockOperationRepo
+				}(),
 			},
 			args: args{
 				ctx:    context.Background(),
 				region: "us-west-1",
 				typ:    "t2.micro",
+				labels: map[string]string{"team": "payments", "env": "prod"},
 			},
 			want:    "test-server",
 			wantErr: false,
@@ -330,21 +728,221 @@ func Test_serverService_Provision(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			queue := NewOperationQueue(&internal.Config{OperationQueueSize: 1})
+			regions := &mockPersistence.RegionRepo{}
+			regions.On("LockByName", mock.Anything, "us-west-1").Return(&persistence.Region{Name: "us-west-1", Status: "enabled", MaxServers: 10}, nil)
+			regions.On("CountActiveInRegion", mock.Anything, "us-west-1").Return(int64(3), nil)
+			catalog := NewCatalogService(&internal.Config{ServerTypes: internal.ServerTypeCatalog{{Name: "t2.micro", BootTime: time.Second}}}, regions)
 			s := &serverService{
+				catalog: catalog,
 				servers: tt.fields.servers,
 				ips:     tt.fields.ips,
 				events:  tt.fields.events,
+				ops:     tt.fields.ops,
+				uow: mockPersistence.NewUnitOfWork(persistence.Repos{
+					Servers:    tt.fields.servers,
+					IPs:        tt.fields.ips,
+					Events:     tt.fields.events,
+					Operations: tt.fields.ops,
+					Outbox:     newOutboxRepo(),
+					Usage:      newUsageRepo(),
+					Regions:    regions,
+				}),
+				queue: queue,
+				cfg:   &internal.Config{ProvisionDelay: time.Second},
 			}
-			got, err := s.Provision(tt.args.ctx, tt.args.region, tt.args.typ)
+			got, err := s.Provision(tt.args.ctx, tt.args.region, tt.args.typ, tt.args.labels)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("serverService.Provision() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if got != tt.want {
-				t.Errorf("serverService.Provision() = %v, want %v", got, tt.want)
+			if err != nil {
+				if len(queue.ch) != 0 {
+					t.Errorf("serverService.Provision() enqueued an operation after a failed unit of work")
+				}
+				return
+			}
+			if got.ServerID != tt.want {
+				t.Errorf("serverService.Provision() = %v, want %v", got.ServerID, tt.want)
+			}
+			if got.Status != string(domain.OperationPending) || len(queue.ch) != 1 {
+				t.Errorf("serverService.Provision() operation = %+v not pending and enqueued", got)
+			}
+		})
+	}
+}
+
+// regionLockingUnitOfWork runs units of work against shared repositories and, like Postgres,
+// holds a region row locked through Regions.LockByName until the unit of work ends
+type regionLockingUnitOfWork struct {
+	repos  persistence.Repos
+	region sync.Mutex
+}
+
+func (u *regionLockingUnitOfWork) Do(ctx context.Context, fn func(tx persistence.Repos) error) error {
+	locked := false
+	defer func() {
+		if locked {
+			u.region.Unlock()
+		}
+	}()
+	regions := &mockPersistence.RegionRepo{}
+	regions.On("LockByName", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		u.region.Lock()
+		locked = true
+	}).Return(func(ctx context.Context, name string) *persistence.Region {
+		r, _ := u.repos.Regions.GetByName(ctx, name)
+		return r
+	}, nil)
+	regions.On("CountActiveInRegion", mock.Anything, mock.Anything).Return(func(ctx context.Context, name string) (int64, error) {
+		return u.repos.Regions.CountActiveInRegion(ctx, name)
+	})
+	repos := u.repos
+	repos.Regions = regions
+	return fn(repos)
+}
+
+func Test_serverService_Provision_lastSlotRace(t *testing.T) {
+	var active atomic.Int64
+	active.Store(2)
+	regions := &mockPersistence.RegionRepo{}
+	regions.On("GetByName", mock.Anything, "us-west-1").Return(&persistence.Region{Name: "us-west-1", Status: "enabled", MaxServers: 3}, nil)
+	regions.On("CountActiveInRegion", mock.Anything, "us-west-1").Return(func(context.Context, string) (int64, error) {
+		return active.Load(), nil
+	})
+	servers := &mockPersistence.ServerRepo{}
+	servers.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*persistence.Server).ID = fmt.Sprintf("srv-%d", active.Add(1))
+	}).Return(nil)
+	ips := &mockPersistence.IPRepo{}
+	ips.On("AllocateIP", mock.Anything, "us-west-1").Return(&persistence.IPAddress{ID: 1, Address: "10.0.0.1"}, nil)
+	ips.On("AssignIPToServer", mock.Anything, uint(1), mock.Anything).Return(nil)
+	events := &mockPersistence.EventRepo{}
+	events.On("Append", mock.Anything, mock.Anything).Return(nil)
+	ops := &mockPersistence.OperationRepo{}
+	ops.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	s := &serverService{
+		catalog: NewCatalogService(&internal.Config{ServerTypes: internal.ServerTypeCatalog{{Name: "t2.micro", BootTime: time.Second}}}, regions),
+		uow: &regionLockingUnitOfWork{repos: persistence.Repos{
+			Servers:    servers,
+			IPs:        ips,
+			Events:     events,
+			Operations: ops,
+			Outbox:     newOutboxRepo(),
+			Usage:      newUsageRepo(),
+			Regions:    regions,
+		}},
+		queue: NewOperationQueue(&internal.Config{OperationQueueSize: 2}),
+		cfg:   &internal.Config{},
+	}
+
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, errs[i] = s.Provision(context.Background(), "us-west-1", "t2.micro", nil)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	var admitted, rejected int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			admitted++
+		case errors.Is(err, ErrRegionAtCapacity):
+			rejected++
+		default:
+			t.Fatalf("serverService.Provision() unexpected error = %v", err)
+		}
+	}
+	if admitted != 1 || rejected != 1 || active.Load() != 3 {
+		t.Errorf("serverService.Provision() admitted %d and rejected %d, %d active; want 1, 1 and 3", admitted, rejected, active.Load())
+	}
+}
+
+func Test_serverService_CompleteOperation(t *testing.T) {
+	provisioning := &persistence.Server{ID: "srv-1", State: string(domain.ServerProvisioning)}
+	running := &persistence.Server{ID: "srv-2", State: string(domain.ServerRunning)}
+
+	mockServerRepo := &mockPersistence.ServerRepo{}
+	mockServerRepo.On("GetByID", mock.Anything, "srv-1").Return(provisioning, nil)
+	mockServerRepo.On("GetByID", mock.Anything, "srv-2").Return(running, nil)
+	mockServerRepo.On("GetByID", mock.Anything, "srv-3").Return(nil, errors.New("db down"))
+	mockServerRepo.On("UpdateState", mock.Anything, "srv-1", mock.Anything, string(domain.ServerRunning)).Return(nil)
+	mockServerRepo.On("UpdateTimestamps", mock.Anything, "srv-1", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockServerRepo.On("GetByID", mock.Anything, "srv-4").Return(&persistence.Server{ID: "srv-4", State: string(domain.ServerRebooting)}, nil)
+	mockServerRepo.On("UpdateState", mock.Anything, "srv-4", mock.Anything, string(domain.ServerRunning)).Return(nil)
+	mockServerRepo.On("UpdateTimestamps", mock.Anything, "srv-4", (*time.Time)(nil), (*time.Time)(nil), (*time.Time)(nil)).Return(nil)
+	// Terminated while provisioning, before terminations released IPs
+	ipID := uint(7)
+	mockServerRepo.On("GetByID", mock.Anything, "srv-5").Return(&persistence.Server{ID: "srv-5", State: string(domain.ServerTerminated), IPID: &ipID}, nil)
+	mockServerRepo.On("DetachIP", mock.Anything, "srv-5").Return(nil).Once()
+	mockIPRepo := &mockPersistence.IPRepo{}
+	mockIPRepo.On("ReleaseIP", mock.Anything, ipID).Return(nil).Once()
+
+	mockEventRepo := &mockPersistence.EventRepo{}
+	mockEventRepo.On("Append", mock.Anything, mock.Anything).Return(nil)
+
+	pending := func(id, serverID string) *persistence.Operation {
+		return &persistence.Operation{ID: id, Type: string(domain.OperationProvision), ServerID: serverID, Status: string(domain.OperationPending)}
+	}
+	mockOperationRepo := &mockPersistence.OperationRepo{}
+	mockOperationRepo.On("GetByID", mock.Anything, "op-1").Return(pending("op-1", "srv-1"), nil)
+	mockOperationRepo.On("GetByID", mock.Anything, "op-2").Return(pending("op-2", "srv-2"), nil)
+	mockOperationRepo.On("GetByID", mock.Anything, "op-3").Return(&persistence.Operation{ID: "op-3", Status: string(domain.OperationSucceeded)}, nil)
+	mockOperationRepo.On("GetByID", mock.Anything, "op-4").Return(nil, nil)
+	mockOperationRepo.On("GetByID", mock.Anything, "op-5").Return(&persistence.Operation{ID: "op-5", Type: string(domain.OperationReboot), ServerID: "srv-4", Status: string(domain.OperationPending)}, nil)
+	mockOperationRepo.On("Complete", mock.Anything, "op-5", string(domain.OperationSucceeded), "").Return(nil)
+	mockOperationRepo.On("Complete", mock.Anything, "op-1", string(domain.OperationSucceeded), "").Return(nil)
+	mockOperationRepo.On("Complete", mock.Anything, "op-2", string(domain.OperationFailed), domain.ErrInvalidTransition.Error()).Return(nil)
+	mockOperationRepo.On("GetByID", mock.Anything, "op-6").Return(pending("op-6", "srv-5"), nil)
+	mockOperationRepo.On("Complete", mock.Anything, "op-6", string(domain.OperationFailed), domain.ErrInvalidTransition.Error()).Return(nil)
+	mockOperationRepo.On("GetByID", mock.Anything, "op-7").Return(&persistence.Operation{ID: "op-7", Type: "resize", ServerID: "srv-2", Status: string(domain.OperationPending)}, nil)
+	mockOperationRepo.On("Complete", mock.Anything, "op-7", string(domain.OperationFailed), errUnsupportedOperation.Error()).Return(nil)
+
+	tests := []struct {
+		name    string
+		id      string
+		wantErr bool
+	}{
+		{name: "provisioning server becomes running", id: "op-1"},
+		{name: "invalid transition fails the operation", id: "op-2"},
+		{name: "completed operation is a no-op", id: "op-3"},
+		{name: "unknown operation", id: "op-4", wantErr: true},
+		{name: "reboot completes back to running", id: "op-5"},
+		{name: "failed provision releases the IP", id: "op-6"},
+		{name: "unknown operation type fails instead of retrying", id: "op-7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &serverService{
+				servers: mockServerRepo,
+				events:  mockEventRepo,
+				ops:     mockOperationRepo,
+				uow: mockPersistence.NewUnitOfWork(persistence.Repos{
+					Servers:    mockServerRepo,
+					IPs:        mockIPRepo,
+					Events:     mockEventRepo,
+					Operations: mockOperationRepo,
+					Outbox:     newOutboxRepo(),
+					Usage:      newUsageRepo(),
+				}),
+				cfg: &internal.Config{},
+			}
+			if err := s.CompleteOperation(context.Background(), tt.id); (err != nil) != tt.wantErr {
+				t.Errorf("serverService.CompleteOperation() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
+	mockOperationRepo.AssertExpectations(t)
+	mockIPRepo.AssertExpectations(t)
 }
 
 func Test_toDomainServer(t *testing.T) {
@@ -362,7 +960,6 @@ func Test_toDomainServer(t *testing.T) {
 		StartedAt:    &time.Time{},
 		StoppedAt:    &time.Time{},
 		TerminatedAt: &time.Time{},
-		Log:          domain.NewEventRingBuffer(100),
 	}
 
 	type args struct {
@@ -559,3 +1156,20 @@ func Test_serverService_GetServerByID(t *testing.T) {
 		})
 	}
 }
+
+func Test_serverService_QueryEvents(t *testing.T) {
+	mockEventRepo := &mockPersistence.EventRepo{}
+	mockEventRepo.On("Query", mock.Anything, persistence.EventQuery{Region: "eu-west-1", Limit: 3}).Return([]persistence.EventLog{{ID: 9}, {ID: 7}, {ID: 4}}, nil)
+	mockEventRepo.On("Query", mock.Anything, persistence.EventQuery{Region: "eu-west-1", BeforeID: 7, Limit: 3}).Return([]persistence.EventLog{{ID: 4}}, nil)
+
+	s := &serverService{events: mockEventRepo}
+
+	page, next, err := s.QueryEvents(context.Background(), persistence.EventQuery{Region: "eu-west-1", Limit: 2})
+	if err != nil || len(page) != 2 || next != 7 {
+		t.Fatalf("serverService.QueryEvents() = %v, %d, %v; want 2 events and cursor 7", page, next, err)
+	}
+	page, next, err = s.QueryEvents(context.Background(), persistence.EventQuery{Region: "eu-west-1", BeforeID: next, Limit: 2})
+	if err != nil || len(page) != 1 || next != 0 {
+		t.Errorf("serverService.QueryEvents() = %v, %d, %v; want the last event and no cursor", page, next, err)
+	}
+}
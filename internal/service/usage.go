@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/persistence"
+)
+
+// hourlyRate returns the catalog price for a server type, falling back to the global rate,
+// for types without a price book entry
+func hourlyRate(cfg *internal.Config, typ string) domain.Micros {
+	if spec, ok := cfg.ServerTypes.Lookup(typ); ok {
+		return spec.HourlyPrice
+	}
+	return cfg.BillingRate
+}
+
+// billingRounding returns the configured rounding for the cost of a billing increment
+func billingRounding(cfg *internal.Config) domain.Rounding {
+	return domain.Rounding{Mode: cfg.BillingRounding, Unit: cfg.BillingRoundingUnit}
+}
+
+// billUsage bills a session for the whole seconds of uptime between its last billing and
+// until, closing it at until when end is set. The sub-second remainder carries over to the
+// next increment, so repeated billing neither loses nor double-counts uptime. The cost of
+// the increment is computed exactly at the rates in effect for the session's type and
+// region during it, and rounded once.
+func billUsage(ctx context.Context, usage persistence.UsageRepo, prices PriceService, session *persistence.UsageSession, until time.Time, end bool, cfg *internal.Config) error {
+	if until.Sub(session.LastBilledAt) < time.Second && !end {
+		return nil
+	}
+	charges, billedTo, err := priceUsage(ctx, prices, session, until, cfg)
+	if err != nil {
+		return err
+	}
+	var endedAt *time.Time
+	if end {
+		endedAt = &until
+	}
+	return usage.Bill(ctx, session, charges, billedTo, endedAt)
+}
+
+// priceUsage prices the whole seconds of a session's uptime between its last billing and
+// until, and returns the charges and the time they run to. Billing and forecasts share it.
+func priceUsage(ctx context.Context, prices PriceService, session *persistence.UsageSession, until time.Time, cfg *internal.Config) ([]*persistence.UsageCharge, time.Time, error) {
+	seconds := int64(until.Sub(session.LastBilledAt) / time.Second)
+	if seconds < 0 {
+		seconds = 0
+	}
+	billedTo := session.LastBilledAt.Add(time.Duration(seconds) * time.Second)
+	priced, err := prices.Charges(ctx, session.Type, session.Region, session.LastBilledAt, billedTo)
+	if err != nil {
+		return nil, billedTo, err
+	}
+	return usageCharges(session, priced, billingRounding(cfg)), billedTo, nil
+}
+
+// usageCharges records priced uptime starting at a session's last billing, split further at
+// billing period boundaries. Amounts are allocated so that they sum to the cost of the whole
+// increment rounded once: each is the rounded cost through the end of its span less the
+// rounded cost through its start.
+func usageCharges(session *persistence.UsageSession, priced []domain.Charge, rounding domain.Rounding) []*persistence.UsageCharge {
+	var charges []*persistence.UsageCharge
+	var spans []domain.Charge
+	var billed domain.Micros
+	at := session.LastBilledAt
+	for _, c := range priced {
+		for c.Duration > 0 {
+			span := c
+			_, periodEnd, _ := domain.PeriodBounds(domain.PeriodOf(at))
+			if next := ceilSecond(at, periodEnd); next.Before(at.Add(c.Duration)) {
+				span.Duration = next.Sub(at)
+			}
+			spans = append(spans, span)
+			cost := rounding.Cost(spans...)
+			charges = append(charges, &persistence.UsageCharge{
+				SessionID: session.ID,
+				ServerID:  session.ServerID,
+				Type:      session.Type,
+				Region:    session.Region,
+				Period:    domain.PeriodOf(at),
+				StartedAt: at,
+				Seconds:   int64(span.Duration / time.Second),
+				Rate:      span.Rate,
+				Amount:    cost - billed,
+				Currency:  session.Currency,
+			})
+			billed = cost
+			at = at.Add(span.Duration)
+			c.Duration -= span.Duration
+		}
+	}
+	return charges
+}
+
+// ceilSecond returns t, moved later if needed to a whole number of seconds after from
+func ceilSecond(from, t time.Time) time.Time {
+	d := t.Sub(from)
+	if r := d % time.Second; r > 0 {
+		d += time.Second - r
+	}
+	return from.Add(d)
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/persistence"
+	mockPersistence "github.com/rhythin/sever-management/internal/persistence/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_billUsage(t *testing.T) {
+	last := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	perHour := &internal.Config{BillingRate: 3600} // one micro-unit per second
+	micro := internal.ServerTypeCatalog{{Name: "t2.micro", HourlyPrice: 11600}}
+	tests := []struct {
+		name        string
+		typ         string
+		cfg         *internal.Config
+		prices      []*persistence.Price
+		until       time.Time
+		end         bool
+		wantCall    bool
+		wantSeconds int64
+		wantCost    domain.Micros
+	}{
+		{name: "whole seconds are billed and the remainder carries over", cfg: perHour, until: last.Add(90*time.Second + 500*time.Millisecond), wantCall: true, wantSeconds: 90, wantCost: 90},
+		{name: "nothing to bill", cfg: perHour, until: last.Add(400 * time.Millisecond)},
+		{name: "closing bills the tail", cfg: perHour, until: last.Add(30 * time.Second), end: true, wantCall: true, wantSeconds: 30, wantCost: 30},
+		{name: "type rate rounded to the micro-unit", typ: "t2.micro", cfg: &internal.Config{ServerTypes: micro}, until: last.Add(time.Minute), wantCall: true, wantSeconds: 60, wantCost: 193},
+		{
+			name: "increment rounded up to whole cents", typ: "t2.micro", until: last.Add(time.Minute), wantCall: true, wantSeconds: 60, wantCost: 10000,
+			cfg: &internal.Config{ServerTypes: micro, BillingRounding: domain.RoundUp, BillingRoundingUnit: 10000},
+		},
+		{
+			name: "increment crossing a price change is billed at both prices", typ: "t2.micro", cfg: &internal.Config{ServerTypes: micro},
+			prices: []*persistence.Price{
+				{ServerType: "t2.micro", HourlyPrice: 3600, EffectiveFrom: time.Unix(0, 0)},
+				{ServerType: "t2.micro", HourlyPrice: 7200, EffectiveFrom: last.Add(30 * time.Second)},
+			},
+			until: last.Add(time.Minute), wantCall: true, wantSeconds: 60, wantCost: 30 + 60,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &persistence.UsageSession{ID: 1, ServerID: "srv-1", Type: tt.typ, LastBilledAt: last}
+			usage := &mockPersistence.UsageRepo{}
+			usage.On("Bill", mock.Anything, session, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			priceRepo := &mockPersistence.PriceRepo{}
+			priceRepo.On("ListEffective", mock.Anything, tt.typ, "", mock.Anything).Return(tt.prices, nil)
+
+			if err := billUsage(context.Background(), usage, NewPriceService(priceRepo, nil, tt.cfg), session, tt.until, tt.end, tt.cfg); err != nil {
+				t.Fatalf("billUsage() error = %v", err)
+			}
+			if !tt.wantCall {
+				usage.AssertNotCalled(t, "Bill", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			args := usage.Calls[0].Arguments
+			seconds, cost := sumCharges(args.Get(2).([]*persistence.UsageCharge))
+			billedTo, end := args.Get(3).(time.Time), args.Get(4).(*time.Time)
+			if seconds != tt.wantSeconds || cost != tt.wantCost {
+				t.Errorf("billUsage() billed %ds for %v, want %ds for %v", seconds, cost, tt.wantSeconds, tt.wantCost)
+			}
+			if want := last.Add(time.Duration(tt.wantSeconds) * time.Second); !billedTo.Equal(want) {
+				t.Errorf("billUsage() billed to %v, want %v", billedTo, want)
+			}
+			if (end != nil) != tt.end || (end != nil && !end.Equal(tt.until)) {
+				t.Errorf("billUsage() end = %v, want closed=%v at %v", end, tt.end, tt.until)
+			}
+		})
+	}
+}
+
+func Test_usageCharges(t *testing.T) {
+	// Thirty seconds either side of a month boundary, starting mid-second
+	last := time.Date(2026, 1, 31, 23, 59, 30, 500_000_000, time.UTC)
+	session := &persistence.UsageSession{ID: 3, ServerID: "srv-1", Type: "t2.micro", Region: "eu-west-1", Currency: "USD", LastBilledAt: last}
+	priced := []domain.Charge{{Rate: 1_000_000, Duration: 20 * time.Second}, {Rate: 2_000_000, Duration: 40 * time.Second}}
+	rounding := domain.Rounding{Mode: domain.RoundHalfEven, Unit: 10000}
+
+	charges := usageCharges(session, priced, rounding)
+
+	want := []struct {
+		period  string
+		seconds int64
+		rate    domain.Micros
+	}{{"2026-01", 20, 1_000_000}, {"2026-01", 10, 2_000_000}, {"2026-02", 30, 2_000_000}}
+	if len(charges) != len(want) {
+		t.Fatalf("usageCharges() = %d charges, want %d", len(charges), len(want))
+	}
+	at := last
+	for i, c := range charges {
+		if c.Period != want[i].period || c.Seconds != want[i].seconds || c.Rate != want[i].rate || !c.StartedAt.Equal(at) {
+			t.Errorf("usageCharges()[%d] = %+v, want %+v from %v", i, c, want[i], at)
+		}
+		if c.SessionID != 3 || c.ServerID != "srv-1" || c.Region != "eu-west-1" || c.Currency != "USD" || c.Amount < 0 {
+			t.Errorf("usageCharges()[%d] = %+v", i, c)
+		}
+		at = at.Add(time.Duration(c.Seconds) * time.Second)
+	}
+	// The amounts add up to the increment's cost rounded once
+	if _, cost := sumCharges(charges); cost != rounding.Cost(priced...) {
+		t.Errorf("usageCharges() amounts sum to %v, want %v", cost, rounding.Cost(priced...))
+	}
+}
+
+// sumCharges returns the seconds and amount billed by charges
+func sumCharges(charges []*persistence.UsageCharge) (int64, domain.Micros) {
+	var seconds int64
+	var cost domain.Micros
+	for _, c := range charges {
+		seconds += c.Seconds
+		cost += c.Amount
+	}
+	return seconds, cost
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/persistence"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// Headers set on every webhook delivery
+const (
+	WebhookIDHeader        = "X-Webhook-Id"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery" // stable across retries, for receiver-side deduplication
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature" // "sha256=" + SignWebhookPayload(secret, timestamp, body)
+)
+
+// webhookDeliveryBatch bounds the deliveries attempted per poll
+const webhookDeliveryBatch = 100
+
+// WebhookDispatcher is the outbox sink for webhooks. It queues a delivery of each event to
+// every webhook subscribed to its type, then POSTs the deliveries with exponential backoff
+// until they succeed or exhaust their attempts.
+
+type WebhookDispatcher struct {
+	repo   persistence.WebhookRepo
+	client *http.Client
+	cfg    *internal.Config
+	wake   chan struct{} // nudges the delivery loop when new deliveries are queued
+}
+
+func NewWebhookDispatcher(repo persistence.WebhookRepo, cfg *internal.Config) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		repo:   repo,
+		client: &http.Client{Timeout: cfg.WebhookTimeout},
+		cfg:    cfg,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Run delivers queued webhooks until ctx is done
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	zap.S().Infow("WebhookDispatcher started")
+	d.deliverLoop(ctx)
+	zap.S().Infow("WebhookDispatcher stopped")
+}
+
+func (d *WebhookDispatcher) Name() string {
+	return "webhooks"
+}
+
+// Send queues a delivery of e to every webhook subscribed to its type. Queuing is
+// idempotent per webhook and event, so a redelivered outbox entry is not sent twice.
+func (d *WebhookDispatcher) Send(ctx context.Context, e PublishedEvent) error {
+	hooks, err := d.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(newEventEnvelope(e))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var deliveries []*persistence.WebhookDelivery
+	for _, hook := range hooks {
+		if types := WebhookEventTypes(hook); types != nil && !slices.Contains(types, e.Type) {
+			continue
+		}
+		deliveries = append(deliveries, &persistence.WebhookDelivery{
+			WebhookID:     hook.ID,
+			EventID:       e.ID,
+			EventType:     e.Type,
+			Payload:       string(payload),
+			Status:        string(domain.DeliveryPending),
+			NextAttemptAt: now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := d.repo.CreateDeliveries(ctx, deliveries); err != nil {
+		return err
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (d *WebhookDispatcher) deliverLoop(ctx context.Context) {
+	poll := time.NewTicker(d.pollInterval())
+	defer poll.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-poll.C:
+		}
+		d.deliverDue(ctx)
+	}
+}
+
+// deliverDue claims the deliveries whose next attempt is due and attempts them. Every replica
+// runs the dispatcher; the claim keeps the others from sending the same deliveries until it
+// lapses, which it only does if this replica stops before recording the attempts.
+func (d *WebhookDispatcher) deliverDue(ctx context.Context) {
+	now := time.Now()
+	deliveries, err := d.repo.ClaimDueDeliveries(ctx, now, now.Add(d.claimFor()), webhookDeliveryBatch)
+	if err != nil {
+		zap.S().Errorw("WebhookDispatcher failed to claim due deliveries", "error", err)
+		return
+	}
+	// Webhooks are sent to concurrently and each one's deliveries in order, so a slow or dead
+	// receiver holds up only its own deliveries
+	var hooks []string
+	byHook := make(map[string][]*persistence.WebhookDelivery)
+	for _, del := range deliveries {
+		if _, ok := byHook[del.WebhookID]; !ok {
+			hooks = append(hooks, del.WebhookID)
+		}
+		byHook[del.WebhookID] = append(byHook[del.WebhookID], del)
+	}
+	var g errgroup.Group
+	g.SetLimit(d.concurrency())
+	for _, hook := range hooks {
+		g.Go(func() error {
+			for _, del := range byHook[hook] {
+				d.attempt(ctx, del)
+			}
+			return nil
+		})
+	}
+	g.Wait()
+}
+
+// attempt POSTs one delivery and records the outcome: succeeded, rescheduled with backoff,
+// or dead once the maximum number of attempts is reached
+func (d *WebhookDispatcher) attempt(ctx context.Context, del *persistence.WebhookDelivery) {
+	log := zap.S().With("deliveryID", del.ID, "webhookID", del.WebhookID, "eventID", del.EventID)
+	status, err := d.post(ctx, del)
+	now := time.Now()
+	del.Attempts++
+	del.ResponseStatus = status
+	switch {
+	case err == nil:
+		del.Status = string(domain.DeliverySucceeded)
+		del.LastError = ""
+		del.DeliveredAt = &now
+		log.Infow("Webhook delivered", "attempts", del.Attempts)
+	case del.Attempts >= d.maxAttempts():
+		del.Status = string(domain.DeliveryDead)
+		del.LastError = err.Error()
+		log.Warnw("Webhook delivery dead-lettered", "attempts", del.Attempts, "error", err)
+	default:
+		del.LastError = err.Error()
+		del.NextAttemptAt = now.Add(d.backoff(del.Attempts))
+		log.Warnw("Webhook delivery failed; will retry", "attempts", del.Attempts, "nextAttemptAt", del.NextAttemptAt, "error", err)
+	}
+	if err := d.repo.UpdateDelivery(ctx, del); err != nil {
+		log.Errorw("WebhookDispatcher failed to record delivery attempt", "error", err)
+	}
+}
+
+// post sends a signed delivery and returns the receiver's status code; non-2xx responses are errors
+func (d *WebhookDispatcher) post(ctx context.Context, del *persistence.WebhookDelivery) (int, error) {
+	if del.Webhook == nil {
+		return 0, errors.New("webhook no longer exists")
+	}
+	body := []byte(del.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, del.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookIDHeader, del.WebhookID)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(del.ID), 10))
+	req.Header.Set(WebhookEventHeader, del.EventType)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(del.Webhook.Secret, timestamp, body))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before the retry following the given number of failed attempts
+func (d *WebhookDispatcher) backoff(attempts int) time.Duration {
+	base, limit := d.cfg.WebhookBackoff, d.cfg.WebhookMaxBackoff
+	if base <= 0 {
+		base = 5 * time.Second
+	}
+	if limit <= 0 {
+		limit = time.Hour
+	}
+	delay := base
+	for i := 1; i < attempts && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
+
+// claimFor bounds how long a batch of deliveries stays claimed: long enough to attempt each
+// of them up to the timeout, should they all be for one webhook
+func (d *WebhookDispatcher) claimFor() time.Duration {
+	timeout := d.cfg.WebhookTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return timeout * (webhookDeliveryBatch + 1)
+}
+
+func (d *WebhookDispatcher) concurrency() int {
+	if d.cfg.WebhookConcurrency > 0 {
+		return d.cfg.WebhookConcurrency
+	}
+	return 8
+}
+
+func (d *WebhookDispatcher) maxAttempts() int {
+	if d.cfg.WebhookMaxAttempts > 0 {
+		return d.cfg.WebhookMaxAttempts
+	}
+	return 8
+}
+
+func (d *WebhookDispatcher) pollInterval() time.Duration {
+	if d.cfg.WebhookPollInterval > 0 {
+		return d.cfg.WebhookPollInterval
+	}
+	return time.Second
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rhythin/sever-management/internal"
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/packets"
+	"github.com/rhythin/sever-management/internal/persistence"
+	mockPersistence "github.com/rhythin/sever-management/internal/persistence/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_WebhookDispatcher_Send(t *testing.T) {
+	repo := &mockPersistence.WebhookRepo{}
+	repo.On("List", mock.Anything).Return([]*persistence.Webhook{
+		{ID: "all"},
+		{ID: "stops", EventTypes: "stopped"},
+		{ID: "terminations", EventTypes: "terminated"},
+	}, nil)
+	var queued []*persistence.WebhookDelivery
+	repo.On("CreateDeliveries", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		queued = args.Get(1).([]*persistence.WebhookDelivery)
+	}).Return(nil)
+
+	d := NewWebhookDispatcher(repo, &internal.Config{})
+	event := PublishedEvent{EventLog: persistence.EventLog{ID: 42, ServerID: "srv-1", Sequence: 3, Type: "stopped"}, Region: "eu-west-1"}
+	if err := d.Send(context.Background(), event); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if len(queued) != 2 || queued[0].WebhookID != "all" || queued[1].WebhookID != "stops" {
+		t.Fatalf("Send() queued %+v, want deliveries for webhooks all and stops", queued)
+	}
+	var payload packets.EventEnvelope
+	if err := json.Unmarshal([]byte(queued[0].Payload), &payload); err != nil {
+		t.Fatalf("Send() queued invalid JSON: %v", err)
+	}
+	if payload.EventID != 42 || payload.Region != "eu-west-1" || payload.ServerID != "srv-1" || payload.Sequence != 3 || queued[0].Status != string(domain.DeliveryPending) {
+		t.Errorf("Send() queued %+v with payload %+v", queued[0], payload)
+	}
+}
+
+func Test_WebhookDispatcher_deliverDue(t *testing.T) {
+	const secret = "s3cret"
+	cfg := &internal.Config{WebhookMaxAttempts: 3, WebhookBackoff: time.Second, WebhookMaxBackoff: time.Minute}
+	tests := []struct {
+		name         string
+		respond      int
+		attempts     int // before this attempt
+		wantStatus   domain.WebhookDeliveryStatus
+		wantAttempts int
+		wantBackoff  time.Duration
+	}{
+		{name: "delivered", respond: http.StatusNoContent, wantStatus: domain.DeliverySucceeded, wantAttempts: 1},
+		{name: "first failure retries after base backoff", respond: http.StatusInternalServerError, wantStatus: domain.DeliveryPending, wantAttempts: 1, wantBackoff: time.Second},
+		{name: "second failure doubles backoff", respond: http.StatusBadGateway, attempts: 1, wantStatus: domain.DeliveryPending, wantAttempts: 2, wantBackoff: 2 * time.Second},
+		{name: "last failure dead-letters", respond: http.StatusInternalServerError, attempts: 2, wantStatus: domain.DeliveryDead, wantAttempts: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received http.Header
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				received = r.Header
+				// Receivers authenticate deliveries by recomputing the signature
+				if r.Header.Get(WebhookSignatureHeader) != "sha256="+SignWebhookPayload(secret, r.Header.Get(WebhookTimestampHeader), body) {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.WriteHeader(tt.respond)
+			}))
+			defer receiver.Close()
+
+			delivery := &persistence.WebhookDelivery{
+				ID:        7,
+				WebhookID: "hook-1",
+				Webhook:   &persistence.Webhook{ID: "hook-1", URL: receiver.URL, Secret: secret},
+				EventID:   42,
+				EventType: "stopped",
+				Payload:   `{"event_id":42}`,
+				Status:    string(domain.DeliveryPending),
+				Attempts:  tt.attempts,
+			}
+			repo := &mockPersistence.WebhookRepo{}
+			repo.On("ClaimDueDeliveries", mock.Anything, mock.Anything, mock.Anything, webhookDeliveryBatch).Return([]*persistence.WebhookDelivery{delivery}, nil)
+			var recorded *persistence.WebhookDelivery
+			repo.On("UpdateDelivery", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				recorded = args.Get(1).(*persistence.WebhookDelivery)
+			}).Return(nil)
+
+			before := time.Now()
+			NewWebhookDispatcher(repo, cfg).deliverDue(context.Background())
+
+			if received.Get(WebhookDeliveryHeader) != "7" || received.Get(WebhookEventHeader) != "stopped" || received.Get(WebhookIDHeader) != "hook-1" {
+				t.Errorf("receiver got headers %v", received)
+			}
+			if recorded == nil {
+				t.Fatalf("deliverDue() did not record the attempt")
+			}
+			if recorded.Status != string(tt.wantStatus) || recorded.Attempts != tt.wantAttempts || recorded.ResponseStatus != tt.respond {
+				t.Errorf("deliverDue() recorded status %s after %d attempts (HTTP %d), want %s after %d (HTTP %d)",
+					recorded.Status, recorded.Attempts, recorded.ResponseStatus, tt.wantStatus, tt.wantAttempts, tt.respond)
+			}
+			if tt.wantStatus == domain.DeliverySucceeded && (recorded.DeliveredAt == nil || recorded.LastError != "") {
+				t.Errorf("deliverDue() recorded success without a delivery time: %+v", recorded)
+			}
+			if tt.wantBackoff > 0 {
+				if wait := recorded.NextAttemptAt.Sub(before); wait < tt.wantBackoff || wait > tt.wantBackoff+time.Second {
+					t.Errorf("deliverDue() scheduled the retry in %v, want %v", wait, tt.wantBackoff)
+				}
+			}
+		})
+	}
+}
+
+func Test_WebhookDispatcher_deliverDue_Claims(t *testing.T) {
+	repo := &mockPersistence.WebhookRepo{}
+	var claimed time.Duration
+	repo.On("ClaimDueDeliveries", mock.Anything, mock.Anything, mock.Anything, webhookDeliveryBatch).Run(func(args mock.Arguments) {
+		claimed = args.Get(2).(time.Time).Sub(args.Get(1).(time.Time))
+	}).Return(nil, nil)
+
+	// Other replicas must not send the batch while this one may still be attempting it
+	NewWebhookDispatcher(repo, &internal.Config{WebhookTimeout: time.Second}).deliverDue(context.Background())
+	if claimed < webhookDeliveryBatch*time.Second {
+		t.Errorf("deliverDue() claimed deliveries for %v, want at least %v", claimed, webhookDeliveryBatch*time.Second)
+	}
+}
+
+func Test_WebhookDispatcher_deliverDue_SlowReceiver(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+	fast := make(chan string, 2)
+	quick := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fast <- r.Header.Get(WebhookDeliveryHeader)
+	}))
+	defer quick.Close()
+
+	slowHook := &persistence.Webhook{ID: "hook-slow", URL: slow.URL}
+	quickHook := &persistence.Webhook{ID: "hook-quick", URL: quick.URL}
+	repo := &mockPersistence.WebhookRepo{}
+	repo.On("ClaimDueDeliveries", mock.Anything, mock.Anything, mock.Anything, webhookDeliveryBatch).Return([]*persistence.WebhookDelivery{
+		{ID: 1, WebhookID: slowHook.ID, Webhook: slowHook},
+		{ID: 2, WebhookID: slowHook.ID, Webhook: slowHook},
+		{ID: 3, WebhookID: quickHook.ID, Webhook: quickHook},
+		{ID: 4, WebhookID: quickHook.ID, Webhook: quickHook},
+	}, nil)
+	repo.On("UpdateDelivery", mock.Anything, mock.Anything).Return(nil)
+
+	go NewWebhookDispatcher(repo, &internal.Config{WebhookConcurrency: 2}).deliverDue(context.Background())
+
+	// The quick webhook gets both its deliveries, in order, while the slow one still holds its first
+	for _, want := range []string{"3", "4"} {
+		select {
+		case got := <-fast:
+			if got != want {
+				t.Errorf("quick receiver got delivery %s, want %s", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("quick receiver waited behind the slow one for delivery %s", want)
+		}
+	}
+}
+
+func Test_WebhookDispatcher_backoff(t *testing.T) {
+	d := NewWebhookDispatcher(nil, &internal.Config{WebhookBackoff: 5 * time.Second, WebhookMaxBackoff: time.Minute})
+	for attempts, want := range map[int]time.Duration{1: 5 * time.Second, 2: 10 * time.Second, 4: 40 * time.Second, 5: time.Minute, 60: time.Minute} {
+		if got := d.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
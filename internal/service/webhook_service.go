@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/rhythin/sever-management/internal/domain"
+	"github.com/rhythin/sever-management/internal/logging"
+	"github.com/rhythin/sever-management/internal/persistence"
+)
+
+var (
+	// ErrWebhookNotFound is returned when a webhook ID is unknown
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrInvalidWebhook is returned when a webhook's URL or event types are malformed
+	ErrInvalidWebhook = errors.New("invalid webhook")
+)
+
+// WebhookService manages webhook subscriptions
+
+type webhookService struct {
+	repo persistence.WebhookRepo
+}
+
+func NewWebhookService(repo persistence.WebhookRepo) WebhookService {
+	return &webhookService{repo: repo}
+}
+
+// Register validates and stores a webhook with a freshly generated signing secret
+func (s *webhookService) Register(ctx context.Context, rawURL string, types []string) (*persistence.Webhook, error) {
+	log := logging.S(ctx)
+	log.Infow("WebhookService.Register called", "url", rawURL, "types", types)
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	for _, t := range types {
+		if !domain.IsValidEventType(domain.EventType(t)) {
+			return nil, fmt.Errorf("%w: unknown event type %s", ErrInvalidWebhook, t)
+		}
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	hook := &persistence.Webhook{
+		URL:        rawURL,
+		EventTypes: strings.Join(types, ","),
+		Secret:     hex.EncodeToString(secret),
+	}
+	if err := s.repo.Create(ctx, hook); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+func (s *webhookService) List(ctx context.Context) ([]*persistence.Webhook, error) {
+	return s.repo.List(ctx)
+}
+
+// Get looks up a webhook, returning ErrWebhookNotFound if it does not exist
+func (s *webhookService) Get(ctx context.Context, id string) (*persistence.Webhook, error) {
+	hook, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if hook == nil {
+		return nil, ErrWebhookNotFound
+	}
+	return hook, nil
+}
+
+// Delete removes a webhook and stops any deliveries still pending for it
+func (s *webhookService) Delete(ctx context.Context, id string) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// ListDeliveries returns a webhook's most recent deliveries, newest first
+func (s *webhookService) ListDeliveries(ctx context.Context, id string, limit int) ([]*persistence.WebhookDelivery, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(ctx, id, limit)
+}
+
+// WebhookEventTypes returns the event types a webhook subscribes to; nil means every type
+func WebhookEventTypes(hook *persistence.Webhook) []string {
+	if hook.EventTypes == "" {
+		return nil
+	}
+	return strings.Split(hook.EventTypes, ",")
+}
+
+// SignWebhookPayload returns the hex HMAC-SHA256 of "timestamp.body" under secret.
+// Receivers recompute it to authenticate a delivery and reject stale timestamps to prevent replays.
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rhythin/sever-management/internal/persistence"
+	mockPersistence "github.com/rhythin/sever-management/internal/persistence/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_webhookService_Register(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		types     []string
+		wantTypes string
+		wantErr   error
+	}{
+		{name: "all types", url: "https://hooks.example.com/servers"},
+		{name: "filtered types", url: "http://localhost:9000/hook", types: []string{"stopped", "terminated"}, wantTypes: "stopped,terminated"},
+		{name: "relative url", url: "/hook", wantErr: ErrInvalidWebhook},
+		{name: "unsupported scheme", url: "ftp://example.com/hook", wantErr: ErrInvalidWebhook},
+		{name: "unknown type", url: "https://example.com/hook", types: []string{"exploded"}, wantErr: ErrInvalidWebhook},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockPersistence.WebhookRepo{}
+			repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+			s := &webhookService{repo: repo}
+
+			hook, err := s.Register(context.Background(), tt.url, tt.types)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("webhookService.Register() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			if hook.EventTypes != tt.wantTypes || len(hook.Secret) != 64 {
+				t.Errorf("webhookService.Register() = %+v, want types %q and a 32-byte hex secret", hook, tt.wantTypes)
+			}
+		})
+	}
+}
+
+func Test_webhookService_ListDeliveries_NotFound(t *testing.T) {
+	repo := &mockPersistence.WebhookRepo{}
+	repo.On("GetByID", mock.Anything, "missing").Return(nil, nil)
+	s := &webhookService{repo: repo}
+
+	if _, err := s.ListDeliveries(context.Background(), "missing", 10); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("webhookService.ListDeliveries() error = %v, want ErrWebhookNotFound", err)
+	}
+	if err := s.Delete(context.Background(), "missing"); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("webhookService.Delete() error = %v, want ErrWebhookNotFound", err)
+	}
+	repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestWebhookEventTypes(t *testing.T) {
+	if got := WebhookEventTypes(&persistence.Webhook{}); got != nil {
+		t.Errorf("WebhookEventTypes() = %v, want nil for every type", got)
+	}
+	if got := WebhookEventTypes(&persistence.Webhook{EventTypes: "started,stopped"}); len(got) != 2 || got[1] != "stopped" {
+		t.Errorf("WebhookEventTypes() = %v, want [started stopped]", got)
+	}
+}
@@ -6,34 +6,281 @@ CREATE TABLE IF NOT EXISTS servers (
     type VARCHAR(32) NOT NULL,
     ip_id INTEGER REFERENCES ip_addresses(id),
     state VARCHAR(32) NOT NULL,
+    version BIGINT NOT NULL DEFAULT 1,
     created_at TIMESTAMP NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
     started_at TIMESTAMP,
     stopped_at TIMESTAMP,
-    terminated_at TIMESTAMP
+    terminated_at TIMESTAMP,
+    reap_after TIMESTAMP, -- postponed reap time for the current idle period
+    reap_warned_for TIMESTAMP -- reap time the last reap warning announced
 );
 
+-- Key/value labels for grouping costs, e.g. team=payments
+CREATE TABLE IF NOT EXISTS server_labels (
+    server_id UUID NOT NULL REFERENCES servers(id),
+    key VARCHAR(63) NOT NULL,
+    value VARCHAR(63) NOT NULL DEFAULT '',
+    PRIMARY KEY (server_id, key)
+);
+
+CREATE TABLE IF NOT EXISTS ip_pools (
+    id UUID PRIMARY KEY,
+    name VARCHAR(64) UNIQUE NOT NULL,
+    region VARCHAR(64) NOT NULL, -- servers in other regions never get its addresses
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ip_pools_region ON ip_pools(region);
+
+CREATE TABLE IF NOT EXISTS ip_pool_cidrs (
+    id SERIAL PRIMARY KEY,
+    pool_id UUID NOT NULL REFERENCES ip_pools(id),
+    cidr VARCHAR(18) UNIQUE NOT NULL, -- overlaps no other row, retired or not
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    retired_at TIMESTAMP -- no longer allocated from
+);
+
+CREATE INDEX IF NOT EXISTS idx_ip_pool_cidrs_pool_id ON ip_pool_cidrs(pool_id);
+
 CREATE TABLE IF NOT EXISTS ip_addresses (
     id SERIAL PRIMARY KEY,
     address VARCHAR(64) UNIQUE NOT NULL,
+    cidr_id INTEGER REFERENCES ip_pool_cidrs(id), -- NULL for addresses no pool covers, which are never allocated
     allocated BOOLEAN NOT NULL DEFAULT FALSE,
-    server_id UUID REFERENCES servers(id)
+    server_id UUID REFERENCES servers(id),
+    quarantined_until TIMESTAMP -- a released address is not reallocated before this
 );
 
+CREATE INDEX IF NOT EXISTS idx_ip_addresses_cidr_id ON ip_addresses(cidr_id);
+
 CREATE TABLE IF NOT EXISTS billings (
     id SERIAL PRIMARY KEY,
     server_id UUID UNIQUE REFERENCES servers(id),
     accumulated_seconds BIGINT NOT NULL DEFAULT 0,
     last_billed_at TIMESTAMP,
-    total_cost DOUBLE PRECISION NOT NULL DEFAULT 0
+    total_cost_micros BIGINT NOT NULL DEFAULT 0, -- millionths of a currency unit
+    currency VARCHAR(3)
 );
 
 CREATE TABLE IF NOT EXISTS event_logs (
     id SERIAL PRIMARY KEY,
-    server_id UUID REFERENCES servers(id),
+    server_id TEXT NOT NULL DEFAULT '', -- empty for fleet-level events such as budget alerts
+    stream TEXT NOT NULL DEFAULT '', -- what the sequence counts: the server ID, or e.g. budget:<id>
     timestamp TIMESTAMP NOT NULL DEFAULT NOW(),
+    sequence BIGINT NOT NULL DEFAULT 0,
     type VARCHAR(32) NOT NULL,
-    message TEXT
+    message TEXT,
+    from_state VARCHAR(32),
+    to_state VARCHAR(32),
+    action VARCHAR(32),
+    cause VARCHAR(16), -- on terminated events: user or reaper
+    request_id VARCHAR(64)
 );
 
 CREATE INDEX IF NOT EXISTS idx_event_logs_server_id ON event_logs(server_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_logs_stream_sequence ON event_logs(stream, sequence);
+CREATE INDEX IF NOT EXISTS idx_event_logs_timestamp ON event_logs(timestamp);
+
+CREATE TABLE IF NOT EXISTS operations (
+    id UUID PRIMARY KEY,
+    type VARCHAR(32) NOT NULL,
+    server_id UUID REFERENCES servers(id),
+    status VARCHAR(16) NOT NULL,
+    error TEXT,
+    request_id VARCHAR(64),
+    due_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_operations_status ON operations(status);
+
+CREATE TABLE IF NOT EXISTS regions (
+    name VARCHAR(32) PRIMARY KEY,
+    status VARCHAR(16) NOT NULL DEFAULT 'enabled',
+    max_servers INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id UUID PRIMARY KEY,
+    url TEXT NOT NULL,
+    event_types TEXT NOT NULL DEFAULT '', -- comma-separated; empty delivers every type
+    secret VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id SERIAL PRIMARY KEY,
+    webhook_id UUID NOT NULL REFERENCES webhooks(id),
+    event_id INTEGER NOT NULL,
+    event_type VARCHAR(32) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL, -- pending, succeeded, dead
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    response_status INTEGER,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries(webhook_id, event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id SERIAL PRIMARY KEY,
+    event_id INTEGER NOT NULL REFERENCES event_logs(id),
+    region VARCHAR(32),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    dispatched_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_dispatched_at ON outbox(dispatched_at);
+CREATE INDEX IF NOT EXISTS idx_outbox_created_at ON outbox(created_at);
+
+CREATE TABLE IF NOT EXISTS usage_sessions (
+    id SERIAL PRIMARY KEY,
+    server_id UUID NOT NULL REFERENCES servers(id),
+    type VARCHAR(32) NOT NULL,
+    region VARCHAR(32),
+    started_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP,
+    last_billed_at TIMESTAMP NOT NULL,
+    billed_seconds BIGINT NOT NULL DEFAULT 0,
+    cost_micros BIGINT NOT NULL DEFAULT 0,
+    currency VARCHAR(3),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_sessions_server_id ON usage_sessions(server_id);
+-- At most one open session per server
+CREATE UNIQUE INDEX IF NOT EXISTS idx_usage_sessions_open ON usage_sessions(server_id) WHERE ended_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS prices (
+    id SERIAL PRIMARY KEY,
+    server_type VARCHAR(32) NOT NULL,
+    region VARCHAR(32) NOT NULL DEFAULT '', -- empty for all regions
+    hourly_price_micros BIGINT NOT NULL,
+    currency VARCHAR(3),
+    effective_from TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_prices_key ON prices(server_type, region, effective_from);
+
+-- Billed uptime, split at period boundaries and price changes
+CREATE TABLE IF NOT EXISTS usage_charges (
+    id SERIAL PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES usage_sessions(id),
+    server_id UUID NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    region VARCHAR(32),
+    period VARCHAR(7) NOT NULL, -- YYYY-MM
+    started_at TIMESTAMP NOT NULL,
+    seconds BIGINT NOT NULL,
+    rate_micros BIGINT NOT NULL,
+    amount_micros BIGINT NOT NULL,
+    currency VARCHAR(3),
+    invoice_id INTEGER, -- set when a closed invoice takes the charge
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_charges_session_id ON usage_charges(session_id);
+CREATE INDEX IF NOT EXISTS idx_usage_charges_server_id ON usage_charges(server_id);
+CREATE INDEX IF NOT EXISTS idx_usage_charges_invoice_id ON usage_charges(invoice_id);
+CREATE INDEX IF NOT EXISTS idx_usage_charges_uninvoiced ON usage_charges(period) WHERE invoice_id IS NULL;
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id SERIAL PRIMARY KEY,
+    period VARCHAR(7) NOT NULL UNIQUE, -- YYYY-MM
+    period_start TIMESTAMP NOT NULL,
+    period_end TIMESTAMP NOT NULL,
+    status VARCHAR(16) NOT NULL, -- draft or closed
+    currency VARCHAR(3),
+    total_micros BIGINT NOT NULL DEFAULT 0,
+    closed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS invoice_lines (
+    id SERIAL PRIMARY KEY,
+    invoice_id INTEGER NOT NULL REFERENCES invoices(id),
+    server_id UUID NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    region VARCHAR(32),
+    seconds BIGINT NOT NULL,
+    rate_micros BIGINT NOT NULL,
+    amount_micros BIGINT NOT NULL,
+    adjusts_period VARCHAR(7) -- set on late charges for an earlier, closed period
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_lines_invoice_id ON invoice_lines(invoice_id);
+
+CREATE TABLE IF NOT EXISTS budgets (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    region VARCHAR(32) NOT NULL DEFAULT '', -- empty for all regions
+    selector TEXT, -- JSON object of labels servers must all have
+    limit_micros BIGINT NOT NULL, -- per calendar month
+    currency VARCHAR(3),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS budget_thresholds (
+    id SERIAL PRIMARY KEY,
+    budget_id UUID NOT NULL REFERENCES budgets(id),
+    percent INTEGER NOT NULL,
+    action VARCHAR(16) NOT NULL, -- event, webhook or stop
+    webhook_id UUID -- for the webhook action
+);
+
+CREATE INDEX IF NOT EXISTS idx_budget_thresholds_budget_id ON budget_thresholds(budget_id);
+
+CREATE TABLE IF NOT EXISTS budget_alerts (
+    id SERIAL PRIMARY KEY,
+    budget_id UUID NOT NULL,
+    threshold_id INTEGER NOT NULL,
+    period VARCHAR(7) NOT NULL, -- YYYY-MM
+    percent INTEGER NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    spend_micros BIGINT NOT NULL, -- when the threshold fired
+    fired_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_budget_alerts_budget_id ON budget_alerts(budget_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budget_alerts_threshold_period ON budget_alerts(threshold_id, period);
+
+CREATE TABLE IF NOT EXISTS reaper_policies (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    region VARCHAR(32) NOT NULL DEFAULT '', -- empty for all regions
+    type VARCHAR(32) NOT NULL DEFAULT '', -- empty for all types
+    selector TEXT, -- JSON object of labels servers must all have
+    idle_timeout BIGINT NOT NULL, -- nanoseconds
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS job_runs (
+    id SERIAL PRIMARY KEY,
+    job VARCHAR(64) NOT NULL,
+    trigger VARCHAR(16) NOT NULL, -- schedule or manual
+    status VARCHAR(16) NOT NULL, -- running, succeeded, failed or skipped
+    error TEXT,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_runs_job ON job_runs(job);